@@ -6,9 +6,17 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log/slog"
+	"net/http"
+	"net/url"
 	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/marintailor/asana-resource-exporter/internal"
 )
@@ -20,29 +28,135 @@ const (
 	defaultRateLimit  int    = 150
 	defaultRetryAfter int    = 5
 
+	// Request retry defaults
+	retryDelay    time.Duration = time.Second
+	maxRetryDelay time.Duration = time.Minute
+	maxRetries    int           = 20
+
+	// Failover defaults
+	defaultFailoverAfter int = 3
+
+	// Latency throttle defaults
+	defaultThrottleFactor float64 = 0.5
+
+	// Empty result retry defaults
+	defaultRetryOnEmpty int           = 0
+	defaultOwner        string        = "me"
+	emptyRetryDelay     time.Duration = time.Second
+
 	// Logging defaults
 	defaultLogFormat string = "text"
 	defaultLogOutput string = ""
 
+	// Hook defaults
+	defaultPostHookTimeout time.Duration = time.Minute
+
+	// Pagination defaults
+	defaultPageSize int = 100
+	maxPageSize     int = 100
+	maxTracedPages  int = 1000
+
+	// Filter defaults
+	completedSinceNow string = "now"
+	completedAll      string = "all"
+	completedTrue     string = "true"
+	completedFalse    string = "false"
+
+	// Error defaults
+	maxErrorBodySize int = 4 << 10
+
 	// File system defaults
-	permissions int = 0o755
+	defaultMaxFilenameLength int    = 200
+	defaultEmptyName         string = "unnamed"
+	gidPlaceholder           string = "{gid}"
+	permissions              int    = 0o755
+	rawDirName               string = "_raw"
+	runDirPrefix             string = "run-"
+
+	// File write retry defaults
+	writeRetryDelay    time.Duration = 100 * time.Millisecond
+	maxWriteRetryDelay time.Duration = 5 * time.Second
+
+	// Probe defaults
+	probeTimeout = 10 * time.Second
+
+	// Warmup defaults
+	warmupTimeout = 10 * time.Second
+
+	// Shutdown defaults
+	forceExitCode   = 130
+	cleanupTimeout  = 30 * time.Second
+	shutdownTimeout = 5 * time.Second
+	writeGrace      = 5 * time.Second
 )
 
+// apiClient performs authenticated, rate-limited requests against the Asana
+// API. It is implemented by internal.Client for a single token and by
+// internal.Pool for several.
+type apiClient interface {
+	Request(ctx context.Context, url string, body io.Reader) (*http.Response, error)
+	PostJSON(ctx context.Context, url string, body io.Reader) (*http.Response, error)
+	CloseIdleConnections()
+	ConnStats() (conns, reused int64)
+	Redact(s string) string
+	SetRate(r int)
+}
+
+// newAPIClient returns a Client for a single token, or for none in modes that
+// do not need one, and a Pool spreading requests across several tokens.
+func newAPIClient(tokens []string, rate int, log *slog.Logger, opts []internal.Option) (apiClient, error) {
+	if len(tokens) > 1 {
+		log.Debug("using token pool", slog.Int("tokens", len(tokens)))
+		return internal.NewPool(tokens, rate, log, opts...)
+	}
+
+	var token string
+	if len(tokens) == 1 {
+		token = tokens[0]
+	}
+	return internal.NewClient(token, rate, opts...)
+}
+
 // app orchestrates the resource export operations, managing configuration,
 // logging, API client, and concurrency control.
 type app struct {
 	cfg    *config            // Application configuration
 	log    *slog.Logger       // Structured logger
-	client *internal.Client   // Asana API client
+	client apiClient          // Asana API client
 	cancel context.CancelFunc // Context cancellation function
 	wg     sync.WaitGroup     // Tracks running goroutines
+	writes sync.WaitGroup     // Tracks export file writes in progress
 	done   chan struct{}      // Signals application shutdown
+
+	shutdownFuncs []shutdownFunc // Auxiliary server teardown, run before client cleanup
+	seen          *seenSet       // Resources exported by previous runs; nil unless dedupe is enabled
+	gov           *governor      // Caps concurrent interval runs; nil unless max goroutines is set
+	budget        *byteBudget    // Bytes written by the current run; nil unless max total bytes is set
+	progress      *progressBar   // Progress line of the current run; nil unless enabled and stdout is a terminal
+	notifier      apiClient      // Client posting run notifications; nil unless a notify URL is set
+	failedOver    bool           // Requests of the current run go to the fallback entrypoint
+	flights       flightGroup    // Page requests in flight, shared by identical concurrent requests
+
+	logging logging  // Resolved logging settings, reported by dump-config
+	dump    bool     // Print the effective configuration instead of exporting
+	probe   bool     // Check the token and connectivity instead of exporting
+	tokens  []string // API tokens the client uses, reported redacted by dump-config
+}
+
+// shutdownFunc stops an auxiliary component such as a metrics or health server.
+type shutdownFunc struct {
+	name string                          // Component name used in log messages
+	fn   func(ctx context.Context) error // Stops the component, honoring ctx deadline
 }
 
 // options holds application configuration and logging settings parsed from command-line flags.
 type options struct {
-	cfg config  // Application configuration settings
-	log logging // Logging configuration settings
+	cfg          config  // Application configuration settings
+	log          logging // Logging configuration settings
+	dumpConfig   bool    // Print the effective configuration and exit
+	probe        bool    // Check the token and connectivity and exit
+	validateOnly bool    // Validate the configuration, report each check, and exit
+	listFormats  bool    // Print the supported output formats and exit
 }
 
 // config defines API-related configuration settings for the application.
@@ -50,8 +164,142 @@ type config struct {
 	entrypoint string // Asana API endpoint URL
 	interval   string // Export interval duration (e.g., "10s", "1m")
 	resource   string // Resource type to export (e.g., "project", "user")
+	workspace  string // GID of the workspace resources are listed from
+	owner      string // GID of the owner of listed resources, or "me", for types requiring one
+	project    string // GID of the parent project of listed resources, for types nested in projects
+	alias      string // Comma-separated alias=type pairs accepted by resource (e.g. "todos=task")
 	rate       int    // API request rate limit per minute
+	warmup     bool   // Replace rate with the limit advertised by the API before exporting
 	dataDir    string // Directory path for storing exported resources
+
+	autoWorkspaces bool // Export from every workspace of the token, each under its own subdirectory of dataDir
+
+	startupJitter  time.Duration // Upper bound of the random delay before the first request; 0 starts immediately
+	resumeSchedule bool          // Continue the interval phase of a previous process from the schedule in dataDir
+
+	closeIdleConns bool // Close idle connections after each interval run
+	maxRedirects   int  // Maximum number of redirects followed per request; 0 disables redirects
+	maxGoroutines  int  // Maximum number of concurrent interval runs; 0 disables the cap
+
+	idleConnTimeout     time.Duration // Time idle connections are kept open; 0 uses the net/http default
+	maxIdleConnsPerHost int           // Idle connections kept per host; 0 uses the net/http default
+	dnsCacheTTL         time.Duration // Time DNS lookups are cached in process; 0 disables the cache
+	unixSocket          string        // Unix domain socket API connections are dialed to; empty uses TCP
+	clientCert          string        // PEM file of the client certificate presented for mutual TLS; empty presents none
+	clientKey           string        // PEM file of the private key of clientCert
+	accept              string        // Accept header sent with every request
+	decoder             string        // Name of the decoder of response pages (e.g. "json")
+	disableHTTP2        bool          // Restrict connections to HTTP/1.1
+	fallbackEntrypoint  string        // Entrypoint the rest of a run is sent to once the primary failed; empty disables failover
+	failoverAfter       int           // Consecutive failed page requests to the primary entrypoint before failing over
+
+	strictJSON bool // Reject response envelopes holding fields other than data, next_page, and count
+
+	throttleLatency time.Duration // Average response latency above which the request rate is lowered; 0 disables throttling
+	throttleRecover time.Duration // Average response latency at or below which the lowered rate is restored
+	throttleFactor  float64       // Fraction of the request rate used while it is lowered
+
+	activeWindow   string        // Daily window during which interval exports run (e.g. "22:00-06:00")
+	activeWindowTZ string        // Time zone of activeWindow; empty uses timezone
+	window         *activeWindow // Parsed activeWindow; nil runs at every tick
+
+	timezone string         // IANA time zone timestamps are formatted in (e.g. "Europe/Berlin")
+	location *time.Location // Loaded timezone
+
+	retention         string        // Maximum age of export files kept in dataDir
+	retentionDuration time.Duration // Parsed retention; older export files are deleted before each run
+
+	networkRetries int // Number of retries of requests failing without a response
+	httpRetries    int // Number of retries of requests answered with a 5xx status
+
+	retryStatus   string       // Comma-separated statuses retried like 5xx statuses (e.g. "420,598")
+	retryStatuses map[int]bool // Parsed retryStatus
+
+	retryOnEmpty int  // Number of retries when the API returns an empty resource list
+	pageSize     int  // Number of resources requested per page
+	maxPages     int  // Maximum pages fetched per listing before the export stops; 0 is unlimited
+	preserveRaw  bool // Store unmodified API response pages under _raw
+	strict       bool // Treat empty, skipped, or partial results as errors
+	verboseErrs  bool // Include API error response bodies in error messages
+	resume       bool // Checkpoint pagination progress and resume from it
+
+	tracePagination bool // Log the next_page values of every fetched page
+	optPretty       bool // Request pretty-printed responses with opt_pretty
+	verifyCount     bool // Check each page against the item count it declares
+
+	countOnly   bool   // Count resources without exporting them
+	countOutput string // Optional JSON file receiving the counts of a count-only run
+
+	minCount       int     // Minimum number of resources a run must find; 0 disables the check
+	maxDropPercent float64 // Maximum drop of the resource count from the last run, in percent; 0 disables the check
+
+	summaryFormat string // Format the run summary is reported in: text or json
+	progressBar   bool   // Draw a progress line of the run on stdout when it is a terminal
+	summaryFile   string // Optional file receiving the run summary, replaced after each run
+
+	dedupe      bool // Skip resources unchanged since they were exported by a previous run
+	resetDedupe bool // Forget the resources exported by previous runs
+
+	dirPerRun         bool   // Write each run under a fresh timestamped run directory
+	maxFilenameLength int    // Maximum export file name length in bytes; 0 disables truncation
+	emptyName         string // Name component of files of unnamed resources; {gid} is replaced by the GID
+	noTimestamp       bool   // Omit the timestamp from file names, so each run replaces the previous files
+	onCollision       string // Policy when a file name already holds a different resource: overwrite, gid-suffix, or error
+
+	writeDelay   time.Duration // Pause between resource file writes; 0 disables pacing
+	writeRetries int           // Number of retries of resource file writes failing with a transient error
+
+	outputFormat  string // Format resources are written in: json, jsonl, or jsonl.gz
+	maxFileSize   int64  // Maximum uncompressed bytes per NDJSON file before rolling over; 0 disables rollover
+	maxTotalBytes int64  // Maximum bytes written to export files per run; 0 disables the cap
+	flushEachLine bool   // Flush NDJSON output after every record instead of buffering it
+	writeIndex    bool   // Maintain an index file mapping resource GIDs to the files holding them
+	gidsOut       string // Optional file receiving the GIDs of exported resources, one per line
+	errorFile     string // Optional file records of resources that failed to export are appended to
+	dedupContent  bool   // Store identical file contents once and link export files to them
+	checksums     bool   // Write a sidecar .sha256 file next to every export file
+	newline       bool   // End each json file with a newline; NDJSON records always end in one
+	partition     bool   // Write json files under {yyyy}/{mm}/{dd} subdirectories of their modified_at date
+	flattenDir    bool   // Write export files to the data directory itself instead of a subdirectory per resource type
+	exportGraph   bool   // Write the dependency and membership graph of exported tasks to graph.json
+
+	fields     string   // Comma-separated opt_fields requested from the API
+	fieldsFile string   // Path to a file listing additional opt_fields
+	optFields  []string // Resolved opt_fields from fields and fieldsFile
+
+	gids    string   // Comma-separated GIDs of specific resources to export
+	gidList []string // Parsed gids; when set, resources are fetched by GID in batches
+
+	schemaFile string  // Path to a JSON Schema exported resources are validated against
+	schema     *schema // Parsed schemaFile; nil disables validation
+
+	filterExpr string     // Client-side predicate resources must match to be exported
+	filter     filterExpr // Parsed filterExpr; nil exports all resources
+	namePrefix string     // Prefix resource names must start with, applied server-side where supported
+
+	modifiedSince string        // Absolute RFC3339 lower bound for modified_since
+	since         string        // Relative lower bound for modified_since (e.g. "24h", "7d")
+	sinceDuration time.Duration // Parsed since value, applied at the start of each run
+
+	completedSince string // Lower bound for completed_since of task exports: RFC3339 or "now"
+	completed      string // Completion state of exported tasks: true, false, or all
+
+	postHook        string        // Shell command run after each successful export
+	postHookTimeout time.Duration // Maximum duration of the post-export hook
+
+	notifyURL    string // Webhook URL the status of every run is posted to; empty disables notifications
+	notifyFormat string // Payload posted to notifyURL: json or slack
+
+	tokenFile string // File listing API tokens, one per line, used round-robin
+
+	record string // Directory where API interactions are recorded
+	replay string // Directory from which API interactions are replayed
+
+	noFetch bool   // Re-process raw pages stored with preserveRaw instead of fetching
+	fromRaw string // Directory of raw pages read by noFetch; defaults to the resource's _raw directory
+
+	signingKey    string // Shared secret for HMAC request signing; empty disables signing
+	signingHeader string // Header name carrying the request signature
 }
 
 // logging defines logging-related configuration settings.
@@ -59,19 +307,26 @@ type logging struct {
 	debug  bool   // Enable debug logging level
 	format string // Log format (json or text)
 	output string // Log output destination (file path or stdout)
+
+	dedupeErrors bool // Collapse repeated identical warnings and errors into a line with their count
 }
 
 // newApp creates and configures a new application instance with settings from
 // command-line flags and environment variables. It initializes logging and the API client.
 // Args contain the command-line arguments (e.g., os.Args).
 func newApp(args []string) (*app, error) {
-	var a app
-
 	opts, err := newOptions(args)
 	if err != nil {
 		return nil, fmt.Errorf("new options: %w", err)
 	}
 
+	return newAppFromOptions(opts)
+}
+
+// newAppFromOptions creates a new application instance from parsed options.
+func newAppFromOptions(opts options) (*app, error) {
+	var a app
+
 	cfg, err := newConfig(opts)
 	if err != nil {
 		return nil, fmt.Errorf("new config: %w", err)
@@ -82,22 +337,52 @@ func newApp(args []string) (*app, error) {
 		return nil, fmt.Errorf("new logger: %w", err)
 	}
 
-	token, ok := os.LookupEnv("ASANA_API_TOKEN")
-	if !ok {
+	tokens, err := loadTokens(cfg.tokenFile)
+	if err != nil {
+		return nil, fmt.Errorf("load tokens: %w", err)
+	}
+	if len(tokens) == 0 && cfg.replay == "" && !cfg.noFetch && !opts.dumpConfig {
 		return nil, errors.New("token not present")
 	}
 
-	client, err := internal.NewClient(token, cfg.rate)
+	clientOpts, err := clientOptions(cfg, log)
+	if err != nil {
+		return nil, fmt.Errorf("client options: %w", err)
+	}
+
+	client, err := newAPIClient(tokens, cfg.rate, log, clientOpts)
 	if err != nil {
 		return nil, fmt.Errorf("new client: %w", err)
 	}
 
+	if cfg.notifyURL != "" {
+		if a.notifier, err = newNotifier(); err != nil {
+			return nil, fmt.Errorf("new notifier: %w", err)
+		}
+	}
+
 	a.cfg = cfg
 	a.log = log
 	a.client = client
+	a.gov = newGovernor(cfg.maxGoroutines, log)
+	a.logging = opts.log
+	a.dump = opts.dumpConfig
+	a.probe = opts.probe
+	a.tokens = tokens
+	if h, ok := log.Handler().(*dedupeHandler); ok {
+		a.registerShutdown("error log dedupe", h.close)
+	}
+	if cfg.progressBar {
+		if a.progress = newProgressBar(os.Stdout); a.progress == nil {
+			a.log.Debug("progress bar disabled, stdout is not a terminal")
+		}
+	}
+
+	safe := *cfg
+	safe.signingKey = secret(safe.signingKey)
 
 	a.log.Debug("app details",
-		slog.String("config", fmt.Sprintf("%+v", opts.cfg)),
+		slog.String("config", fmt.Sprintf("%+v", safe)),
 		slog.String("logging", fmt.Sprintf("%+v", opts.log)))
 
 	return &a, nil
@@ -111,44 +396,785 @@ func newOptions(args []string) (options, error) {
 	flags := flag.NewFlagSet(args[0], flag.ExitOnError)
 	flags.StringVar(&o.cfg.entrypoint, "entrypoint", defaultEntrypoint, "Asana API entrypoint")
 	flags.StringVar(&o.cfg.interval, "interval", defaultInterval, "interval duration at which to fetch data; ex: 10s, 1m; default: none")
+	flags.DurationVar(&o.cfg.startupJitter, "startup-jitter", 0, "wait a random duration of up to this long before the first request, to spread the load of many exporters started together; ex: 30s; default: start immediately")
+	flags.BoolVar(&o.cfg.resumeSchedule, "resume-schedule", false, "in interval mode, persist the time of every tick in the data directory and continue its phase after a restart instead of starting fresh")
 	flags.IntVar(&o.cfg.rate, "rate", defaultRateLimit, "request rate limit per minute. ex: 10, 150")
 	flags.StringVar(&o.cfg.resource, "resource", "", "Asana resource type to be exported. ex: project, user")
+	flags.StringVar(&o.cfg.alias, "alias", "", "comma-separated alias=type pairs of friendly names accepted by -resource; ex: todos=tasks,people=user")
+	flags.StringVar(&o.cfg.workspace, "workspace", "", "GID of the workspace to export resources from; required for goal and portfolio")
+	flags.BoolVar(&o.cfg.autoWorkspaces, "auto-workspaces", false, "export the resource type from every workspace the token has access to, each into {data-dir}/{workspace_gid}, instead of a single -workspace")
+	flags.StringVar(&o.cfg.project, "project", "", "GID of the project whose sections are exported; default: sections of every project in the workspace")
+	flags.StringVar(&o.cfg.owner, "owner", defaultOwner, "GID of the user whose portfolios are exported, or 'me' for the token owner")
 	flags.BoolVar(&o.log.debug, "debug", false, "enable debug log messages")
 	flags.StringVar(&o.log.format, "log-format", defaultLogFormat, "log message format. ex: json, text")
+	flags.BoolVar(&o.log.dedupeErrors, "dedupe-errors", false, "log repeated identical warnings and errors once, then as a single line with their count every 10s and at shutdown; ex: \"request failed (x42)\"")
 	flags.StringVar(&o.log.output, "log-output", defaultLogOutput, "path to file where to store log message; ex: relative/path/app.log, /absolute/path/app/log; default: STDOUT")
 	flags.StringVar(&o.cfg.dataDir, "data-dir", "data", "directory path where exported resources will be stored")
+	flags.IntVar(&o.cfg.pageSize, "page-size", defaultPageSize, "number of resources requested per page; 1-100")
+	flags.IntVar(&o.cfg.maxPages, "max-pages", 0, "stop fetching after this many pages per listing and mark the run truncated, guarding against runaway pagination; default: unlimited")
+	flags.BoolVar(&o.cfg.preserveRaw, "preserve-raw", false, "also store each raw API response page under {data-dir}/{resource}/_raw")
+	flags.StringVar(&o.cfg.fields, "fields", "", "comma-separated list of opt_fields to request; ex: name,notes,owner")
+	flags.StringVar(&o.cfg.fieldsFile, "fields-file", "", "path to a file listing opt_fields, separated by newlines or commas; lines starting with # are ignored")
+	flags.StringVar(&o.cfg.gids, "gids", "", "comma-separated GIDs of specific resources to export, fetched through the batch API; default: all resources")
+	flags.StringVar(&o.cfg.schemaFile, "schema", "", "path to a JSON Schema file; resources that do not match are stored under _invalid; default: no validation")
+	flags.StringVar(&o.cfg.namePrefix, "name-prefix", "", "only export resources whose name starts with this prefix, searched server-side with typeahead where the resource type supports it and a workspace is set")
+	flags.StringVar(&o.cfg.filterExpr, "filter-expr", "", "only export resources matching this predicate; ex: 'resource_type==project && name^=Q3'")
+	flags.StringVar(&o.cfg.completed, "completed", completedAll, "export only completed (true) or incomplete (false) tasks, or all of them")
+	flags.StringVar(&o.cfg.completedSince, "completed-since", "", "only export tasks that are incomplete or were completed since this RFC3339 timestamp, or \"now\" for incomplete tasks only; ex: 2024-06-01T00:00:00Z")
+	flags.StringVar(&o.cfg.modifiedSince, "modified-since", "", "only export resources modified since this RFC3339 timestamp; ex: 2024-06-01T00:00:00Z")
+	flags.StringVar(&o.cfg.since, "since", "", "only export resources modified within this duration before each run; ex: 24h, 7d, 2w")
+	flags.StringVar(&o.cfg.activeWindow, "active-window", "", "only run interval exports within this daily window; ex: 22:00-06:00; default: always")
+	flags.StringVar(&o.cfg.activeWindowTZ, "active-window-tz", "", "IANA time zone of the active window; ex: Europe/Berlin; default: -timezone")
+	flags.StringVar(&o.cfg.timezone, "timezone", defaultTimezone, "IANA time zone of timestamps in file names and of the active window; ex: Europe/Berlin")
+	flags.IntVar(&o.cfg.maxRedirects, "max-redirects", internal.DefaultMaxRedirects, "maximum number of redirects followed per request; 0 makes any redirect an error")
+	flags.BoolVar(&o.cfg.disableHTTP2, "disable-http2", false, "use HTTP/1.1 instead of negotiating HTTP/2, for proxies that mishandle it")
+	flags.StringVar(&o.cfg.accept, "accept", internal.DefaultAccept, "Accept header sent with every request")
+	flags.StringVar(&o.cfg.decoder, "decoder", decoderJSON, "decoder of response pages: "+strings.Join(decoderNames(), ", "))
+	flags.BoolVar(&o.cfg.strictJSON, "strict-json", false, "fail on response envelopes holding a field other than data, next_page, and count, as a canary for API schema changes; fields of resources are not checked")
+	flags.DurationVar(&o.cfg.idleConnTimeout, "idle-conn-timeout", 0, "time idle API connections are kept open for reuse; default: 90s")
+	flags.IntVar(&o.cfg.maxIdleConnsPerHost, "max-idle-conns-per-host", 0, "number of idle API connections kept open per host; default: 2")
+	flags.DurationVar(&o.cfg.dnsCacheTTL, "dns-cache-ttl", 0, "cache DNS lookups in process for this duration; ex: 5m; default: no cache")
+	flags.IntVar(&o.cfg.maxGoroutines, "max-goroutines", 0, "maximum number of interval runs exporting at once when runs overlap; default: no limit")
+	flags.StringVar(&o.cfg.fallbackEntrypoint, "fallback-entrypoint", "", "secondary Asana API entrypoint, e.g. a redundant gateway, the rest of a run is sent to once page requests to -entrypoint failed -failover-after times in a row; each run starts on -entrypoint; default: no failover")
+	flags.DurationVar(&o.cfg.throttleLatency, "throttle-latency", 0, "lower the request rate while the average latency of the last "+strconv.Itoa(internal.LatencyWindow)+" responses exceeds this duration; ex: 2s; default: no throttling")
+	flags.DurationVar(&o.cfg.throttleRecover, "throttle-recover-latency", 0, "average response latency at or below which the lowered request rate is restored; default: 80% of -throttle-latency")
+	flags.Float64Var(&o.cfg.throttleFactor, "throttle-rate-factor", defaultThrottleFactor, "fraction of the request rate used while -throttle-latency is exceeded, between 0 and 1 exclusive")
+	flags.IntVar(&o.cfg.failoverAfter, "failover-after", defaultFailoverAfter, "consecutive page requests failing without a response or with a 5xx status, after request retries, before failing over to -fallback-entrypoint")
+	flags.StringVar(&o.cfg.unixSocket, "unix-socket", "", "path to a Unix domain socket of a local API proxy all requests are sent to; the entrypoint host is a placeholder; default: TCP")
+	flags.StringVar(&o.cfg.clientCert, "client-cert", "", "PEM file of a client certificate presented to gateways requiring mutual TLS; requires -client-key; default: none")
+	flags.StringVar(&o.cfg.clientKey, "client-key", "", "PEM file of the private key of -client-cert")
+	flags.BoolVar(&o.cfg.warmup, "warmup", false, "make a single request before exporting and use the rate limit advertised in its response headers instead of -rate, if any")
+	flags.BoolVar(&o.cfg.closeIdleConns, "close-idle-conns", false, "close idle API connections after each interval run instead of keeping them until the next one")
+	flags.StringVar(&o.cfg.retention, "retention", "", "delete export files older than this duration before each run; ex: 72h, 30d, 4w; default: keep all files")
+	flags.IntVar(&o.cfg.maxFilenameLength, "max-filename-length", defaultMaxFilenameLength, "maximum export file name length in bytes; longer resource names are truncated; 0: no limit")
+	flags.BoolVar(&o.cfg.dedupe, "dedupe-across-runs", false, "skip resources whose content is unchanged since a previous run exported them")
+	flags.BoolVar(&o.cfg.resetDedupe, "reset-dedupe", false, "forget the resources exported by previous runs before deduplicating")
+	flags.StringVar(&o.cfg.emptyName, "empty-name-placeholder", defaultEmptyName, "name used in the file names of resources with an empty name; {gid} is replaced by the resource GID; ex: {gid}, untitled-{gid}")
+	flags.BoolVar(&o.cfg.noTimestamp, "no-timestamp", false, "omit the timestamp from json file names, so each run replaces the files of the previous one")
+	flags.StringVar(&o.cfg.onCollision, "on-collision", collisionOverwrite, "what to do when a json file name already holds a different resource, e.g. two resources with the same name: overwrite, gid-suffix (append the GID), or error")
+	flags.StringVar(&o.cfg.outputFormat, "output-format", formatJSON, "format resources are written in: "+strings.Join(outputFormatNames(), ", ")+"; see -list-output-formats")
+	flags.Int64Var(&o.cfg.maxTotalBytes, "max-total-bytes", 0, "maximum bytes written to export files per run, measured on disk, after which the export stops and is reported as truncated; default: no limit")
+	flags.StringVar(&o.cfg.gidsOut, "gids-out", "", "file receiving the GID of every exported resource, one per line, replaced after each run; ex: exported-gids.txt")
+	flags.StringVar(&o.cfg.errorFile, "error-file", "", "file a JSON record of every resource that failed to export is appended to, one per line; ex: errors.jsonl")
+	flags.BoolVar(&o.cfg.checksums, "sidecar-checksums", false, "write a <filename>"+checksumSuffix+" file next to every export file holding its SHA-256 sum in sha256sum format")
+	flags.BoolVar(&o.cfg.dedupContent, "dedup-content", false, "store the content of identical export files once under "+contentDirName+" and hard link, or symlink, the files to it; no effect with NDJSON output")
+	flags.BoolVar(&o.cfg.newline, "trailing-newline", true, "end each json file with a newline; NDJSON records are always newline-terminated")
+	flags.BoolVar(&o.cfg.partition, "partition-by-modified", false, "write json files under {yyyy}/{mm}/{dd} subdirectories of the resource directory by the modified_at date of each resource, or the run date if it has none; requests modified_at")
+	flags.BoolVar(&o.cfg.exportGraph, "export-graph", false, "write the dependencies, dependents, and memberships of exported tasks as an adjacency list to "+graphFileName+" in the task directory; requests memberships")
+	flags.BoolVar(&o.cfg.flattenDir, "flatten-dir", false, "write export files of every resource type to the data directory itself instead of a {resource_type} subdirectory; json file names include the GID")
+	flags.BoolVar(&o.cfg.writeIndex, "write-index", false, "maintain an "+indexFileName+" file in the resource directory mapping each GID to its name, file and resource type")
+	flags.BoolVar(&o.cfg.flushEachLine, "flush-each-line", false, "flush NDJSON output after every record so consumers tailing the file see it immediately, at the cost of throughput")
+	flags.Int64Var(&o.cfg.maxFileSize, "max-file-size", 0, "maximum uncompressed size in bytes of an NDJSON file before rolling over to a new part; default: no limit")
+	flags.IntVar(&o.cfg.writeRetries, "write-retries", 0, "number of times, at most 20, to retry with backoff a resource file write failing with a transient error, e.g. EAGAIN or a stale NFS handle; default: no retry")
+	flags.DurationVar(&o.cfg.writeDelay, "write-delay", 0, "pause between resource file writes, e.g. for NFS-backed data directories; ex: 5ms; default: no delay")
+	flags.BoolVar(&o.cfg.dirPerRun, "output-dir-per-run", false, "write each run under a fresh {data-dir}/run-{timestamp} directory")
+	flags.BoolVar(&o.cfg.countOnly, "count-only", false, "count resources per resource type without exporting them")
+	flags.StringVar(&o.cfg.summaryFormat, "summary-format", summaryText, "format of the run summary: text (logged) or json (stdout, see README for the schema); both are written to -summary-file if set")
+	flags.BoolVar(&o.cfg.progressBar, "progress-bar", false, "draw a progress line of pages fetched and resources written on stdout, logging to stderr instead; only when stdout is a terminal")
+	flags.StringVar(&o.cfg.summaryFile, "summary-file", "", "file receiving the summary of each run in -summary-format, replaced after every run, also when the export failed; ex: out/summary.json")
+	flags.StringVar(&o.cfg.countOutput, "count-output", "", "JSON file receiving the counts of a count-only run; default: summary log only")
+	flags.BoolVar(&o.cfg.tracePagination, "trace-pagination", false, "log the next_page offset, path, and uri of each fetched page, up to 1000 pages")
+	flags.BoolVar(&o.cfg.optPretty, "opt-pretty", false, "request pretty-printed API responses with opt_pretty, for debugging with -preserve-raw")
+	flags.BoolVar(&o.cfg.verifyCount, "verify-count", false, "check each page against the item count it declares and warn on mismatch")
+	flags.IntVar(&o.cfg.minCount, "min-count", 0, "warn, or fail in strict mode, when a run finds fewer resources than this; default: no minimum")
+	flags.Float64Var(&o.cfg.maxDropPercent, "max-drop-percent", 0, "warn, or fail in strict mode, when the resource count drops by more than this percentage from the last run; ex: 20; default: no check")
+	flags.BoolVar(&o.cfg.resume, "resume", false, "checkpoint pagination progress after each page and resume an interrupted export from it")
+	flags.BoolVar(&o.cfg.strict, "strict", false, "treat empty resource lists, skipped resources, and incomplete pagination as errors")
+	flags.BoolVar(&o.cfg.verboseErrs, "verbose-errors", false, "include the token-redacted response body of failed API requests in error messages, capped at 4 KiB")
+	flags.IntVar(&o.cfg.networkRetries, "network-retries", 0, "number of times, at most 20, to retry with backoff a request failing without a response, e.g. on DNS failures, refused or reset connections, and timeouts; default: no retry")
+	flags.IntVar(&o.cfg.httpRetries, "http-retries", 0, "number of times, at most 20, to retry with backoff a request answered with a 5xx status; default: no retry")
+	flags.StringVar(&o.cfg.retryStatus, "retry-status", "", "comma-separated HTTP statuses retried like 5xx statuses within -http-retries, e.g. of non-standard gateways; ex: 420,598; default: none")
+	flags.IntVar(&o.cfg.retryOnEmpty, "retry-on-empty", defaultRetryOnEmpty, "number of times to retry with backoff when the API returns no resources; default: no retry")
+	flags.StringVar(&o.cfg.postHook, "post-hook", "", "shell command to run after each successful export; default: none")
+	flags.DurationVar(&o.cfg.postHookTimeout, "post-hook-timeout", defaultPostHookTimeout, "maximum duration of the post-export hook")
+	flags.StringVar(&o.cfg.notifyURL, "notify-url", "", "webhook URL a JSON status of every run, successful or not, is posted to; default: none")
+	flags.StringVar(&o.cfg.notifyFormat, "notify-format", notifyJSON, "payload posted to -notify-url: "+strings.Join(notifyFormats, ", "))
+	flags.StringVar(&o.cfg.tokenFile, "token-file", "", "file listing API tokens, one per line, to spread requests across; default: ASANA_API_TOKENS or ASANA_API_TOKEN")
+	flags.StringVar(&o.cfg.record, "record", "", "directory where every API request and response is recorded; default: none")
+	flags.StringVar(&o.cfg.replay, "replay", "", "directory of recordings to serve API requests from instead of the network; default: none")
+	flags.BoolVar(&o.cfg.noFetch, "no-fetch", false, "re-process raw pages stored with -preserve-raw instead of fetching from the API")
+	flags.StringVar(&o.cfg.fromRaw, "from-raw", "", "directory of raw pages read with -no-fetch; default: {data-dir}/{resource}/_raw")
+	flags.StringVar(&o.cfg.signingKey, "signing-key", "", "secret key used to sign requests with HMAC-SHA256; default: no signing")
+	flags.StringVar(&o.cfg.signingHeader, "signing-header", internal.DefaultSigningHeader, "header name carrying the HMAC request signature")
+
+	flags.BoolVar(&o.dumpConfig, "dump-config", false, "print the effective configuration as JSON, with secrets redacted, and exit")
+	flags.BoolVar(&o.probe, "probe", false, "check connectivity and the API token with a single request for the authenticated user, then exit")
+	flags.BoolVar(&o.listFormats, "list-output-formats", false, "print the supported output formats with a description of each and exit")
+	flags.BoolVar(&o.validateOnly, "validate-only", false, "validate flags, configuration file, environment, entrypoint, data directory, and token without exporting, print a JSON report of each check, and exit non-zero if any failed; with -probe, connectivity is checked too")
+
+	var configPath string
+	flags.StringVar(&configPath, "config", "", "JSON file of flag values, or - to read it from stdin; command line flags take precedence; default: none")
 
 	if err := flags.Parse(args[1:]); err != nil {
 		return options{}, fmt.Errorf("parse flags: %w", err)
 	}
 
+	if configPath != "" {
+		if err := applyConfigFile(flags, configPath, os.Stdin); err != nil {
+			return options{}, err
+		}
+	}
+
 	return o, nil
 }
 
 // newConfig validates and creates a new configuration from the provided options.
-// It ensures required fields are set and values are within acceptable ranges.
+// Environment variable references ($VAR or ${VAR}) in the entrypoint, the
+// fallback entrypoint, and the data directory are expanded first. It ensures
+// required fields are set and values are within acceptable ranges, reporting
+// every validation failure at once as a joined error.
 func newConfig(opts options) (*config, error) {
+	var errs []error
+
 	if opts.cfg.entrypoint == "" {
-		return nil, errors.New("entrypoint not provided")
+		errs = append(errs, errors.New("entrypoint not provided"))
+	} else if entrypoint, err := expandEnv(opts.cfg.entrypoint); err != nil {
+		errs = append(errs, fmt.Errorf("entrypoint: %w", err))
+	} else {
+		opts.cfg.entrypoint = entrypoint
+	}
+
+	if opts.cfg.fallbackEntrypoint != "" {
+		if err := validateEntrypoint(opts.cfg.entrypoint); err != nil {
+			errs = append(errs, fmt.Errorf("entrypoint: %w", err))
+		}
+		if fallback, err := expandEnv(opts.cfg.fallbackEntrypoint); err != nil {
+			errs = append(errs, fmt.Errorf("fallback entrypoint: %w", err))
+		} else if err := validateEntrypoint(fallback); err != nil {
+			errs = append(errs, fmt.Errorf("fallback entrypoint: %w", err))
+		} else if fallback == opts.cfg.entrypoint {
+			errs = append(errs, errors.New("fallback entrypoint must differ from the entrypoint"))
+		} else {
+			opts.cfg.fallbackEntrypoint = fallback
+		}
+		if opts.cfg.failoverAfter < 1 {
+			errs = append(errs, errors.New("failover after must be at least 1"))
+		}
+	}
+
+	if dataDir, err := expandEnv(opts.cfg.dataDir); err != nil {
+		errs = append(errs, fmt.Errorf("data dir: %w", err))
+	} else if err := checkDataDir(dataDir); err != nil {
+		errs = append(errs, fmt.Errorf("data dir: %w", err))
+	} else {
+		opts.cfg.dataDir = dataDir
 	}
-	if opts.cfg.resource == "" {
-		return nil, errors.New("resource type not provided")
+
+	aliases, err := parseAliases(opts.cfg.alias)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("alias: %w", err))
+	}
+	opts.cfg.resource = strings.TrimSpace(opts.cfg.resource)
+	if name, ok := aliases[opts.cfg.resource]; ok {
+		opts.cfg.resource = name
+	}
+
+	rt, err := lookupResourceType(opts.cfg.resource)
+	switch {
+	case opts.cfg.resource == "":
+		// Without a resource type, a run would export nothing and succeed.
+		if !opts.probe || opts.validateOnly {
+			errs = append(errs, fmt.Errorf("resource type not provided, must be one of: %s", strings.Join(resourceTypeNames(), ", ")))
+		}
+	case err != nil:
+		errs = append(errs, err)
+	case rt.requiresWorkspace && opts.cfg.workspace == "" && !opts.cfg.autoWorkspaces:
+		errs = append(errs, fmt.Errorf("resource type %s requires a workspace", opts.cfg.resource))
+	case rt.requiresOwner && opts.cfg.owner == "":
+		errs = append(errs, fmt.Errorf("resource type %s requires an owner", opts.cfg.resource))
+	case rt.parent == "" && opts.cfg.project != "":
+		errs = append(errs, fmt.Errorf("project is not supported for resource type %s", opts.cfg.resource))
+	case rt.parent != "" && opts.cfg.project == "" && opts.cfg.gids == "" && !opts.cfg.noFetch:
+		if opts.cfg.workspace == "" && !opts.cfg.autoWorkspaces {
+			errs = append(errs, fmt.Errorf("resource type %s requires a %s or a workspace to list them from", opts.cfg.resource, rt.parent))
+		}
+		if opts.cfg.resume || opts.cfg.preserveRaw {
+			errs = append(errs, fmt.Errorf("resume and preserve raw require a %s for resource type %s", rt.parent, opts.cfg.resource))
+		}
 	}
 	if opts.cfg.rate < 1 {
-		return nil, errors.New("rate limit must be positive")
+		errs = append(errs, errors.New("rate limit must be positive"))
+	}
+	if _, err := intervalDuration(opts.cfg.interval); err != nil {
+		errs = append(errs, err)
+	}
+	if opts.cfg.pageSize < 1 || opts.cfg.pageSize > maxPageSize {
+		errs = append(errs, fmt.Errorf("page size must be between 1 and %d", maxPageSize))
+	}
+	if opts.cfg.maxPages < 0 {
+		errs = append(errs, errors.New("max pages must not be negative"))
+	}
+	if opts.cfg.retryOnEmpty < 0 {
+		errs = append(errs, errors.New("retry on empty must not be negative"))
+	}
+	if opts.cfg.networkRetries < 0 || opts.cfg.networkRetries > maxRetries {
+		errs = append(errs, fmt.Errorf("network retries must be between 0 and %d", maxRetries))
+	}
+	if opts.cfg.httpRetries < 0 || opts.cfg.httpRetries > maxRetries {
+		errs = append(errs, fmt.Errorf("http retries must be between 0 and %d", maxRetries))
+	}
+	if opts.cfg.maxFilenameLength < 0 {
+		errs = append(errs, errors.New("max filename length must not be negative"))
+	}
+	if strings.TrimSpace(opts.cfg.emptyName) == "" {
+		opts.cfg.emptyName = defaultEmptyName
+	}
+	if opts.cfg.outputFormat == "" {
+		opts.cfg.outputFormat = formatJSON
+	}
+	if !slices.Contains(outputFormatNames(), opts.cfg.outputFormat) {
+		errs = append(errs, fmt.Errorf("output format must be one of: %s", strings.Join(outputFormatNames(), ", ")))
+	}
+	if opts.cfg.maxFileSize < 0 {
+		errs = append(errs, errors.New("max file size must not be negative"))
+	}
+	if opts.cfg.maxTotalBytes < 0 {
+		errs = append(errs, errors.New("max total bytes must not be negative"))
+	}
+	if opts.cfg.onCollision == "" {
+		opts.cfg.onCollision = collisionOverwrite
+	}
+	if !slices.Contains(collisionPolicies, opts.cfg.onCollision) {
+		errs = append(errs, fmt.Errorf("on collision must be one of: %s", strings.Join(collisionPolicies, ", ")))
+	}
+	if opts.cfg.noTimestamp && opts.cfg.outputFormat != formatJSON {
+		errs = append(errs, errors.New("no timestamp requires the json output format"))
+	}
+	if opts.cfg.partition && opts.cfg.outputFormat != formatJSON {
+		errs = append(errs, errors.New("partition by modified requires the json output format"))
+	}
+	if opts.cfg.dedupContent && opts.cfg.retention != "" {
+		errs = append(errs, errors.New("dedup content cannot be combined with retention, as linked files share the modification time of their content"))
+	}
+	if opts.cfg.noTimestamp && opts.cfg.retention != "" {
+		errs = append(errs, errors.New("no timestamp cannot be combined with retention, which only matches timestamped files"))
+	}
+	if opts.cfg.maxFileSize > 0 && opts.cfg.outputFormat == formatJSON {
+		errs = append(errs, errors.New("max file size requires an ndjson output format"))
+	}
+	if opts.cfg.flushEachLine && opts.cfg.outputFormat == formatJSON {
+		errs = append(errs, errors.New("flush each line requires an ndjson output format"))
+	}
+	if opts.cfg.writeRetries < 0 || opts.cfg.writeRetries > maxRetries {
+		errs = append(errs, fmt.Errorf("write retries must be between 0 and %d", maxRetries))
+	}
+	if opts.cfg.writeDelay < 0 {
+		errs = append(errs, errors.New("write delay must not be negative"))
+	}
+	if opts.cfg.maxRedirects < 0 {
+		errs = append(errs, errors.New("max redirects must not be negative"))
+	}
+	if strings.TrimSpace(opts.cfg.accept) == "" {
+		opts.cfg.accept = internal.DefaultAccept
+	}
+	if opts.cfg.maxGoroutines < 0 {
+		errs = append(errs, errors.New("max goroutines must not be negative"))
+	}
+	if opts.cfg.idleConnTimeout < 0 || opts.cfg.maxIdleConnsPerHost < 0 || opts.cfg.dnsCacheTTL < 0 {
+		errs = append(errs, errors.New("connection settings must not be negative"))
+	}
+	if opts.cfg.unixSocket != "" {
+		if err := checkUnixSocket(opts.cfg.unixSocket); err != nil {
+			errs = append(errs, fmt.Errorf("unix socket: %w", err))
+		}
+		if opts.cfg.dnsCacheTTL > 0 {
+			errs = append(errs, errors.New("unix socket cannot be combined with dns cache ttl"))
+		}
+	}
+	if (opts.cfg.clientCert == "") != (opts.cfg.clientKey == "") {
+		errs = append(errs, errors.New("client cert and client key must be set together"))
+	}
+
+	if opts.cfg.countOnly && (opts.cfg.preserveRaw || opts.cfg.resume) {
+		errs = append(errs, errors.New("count only cannot be combined with preserve raw or resume"))
+	}
+	if opts.cfg.gidsOut != "" && opts.cfg.countOnly {
+		errs = append(errs, errors.New("gids out cannot be combined with count only"))
+	}
+	if opts.cfg.flattenDir && opts.cfg.partition {
+		errs = append(errs, errors.New("flatten dir cannot be combined with partition by modified"))
+	}
+	if opts.cfg.exportGraph {
+		if opts.cfg.resource != "task" {
+			errs = append(errs, fmt.Errorf("export graph is only supported for task exports, not %q", opts.cfg.resource))
+		}
+		if opts.cfg.countOnly || opts.cfg.noFetch {
+			errs = append(errs, errors.New("export graph cannot be combined with count only or no fetch"))
+		}
+	}
+	if opts.cfg.decoder == "" {
+		opts.cfg.decoder = decoderJSON
+	}
+	if _, ok := decoders[opts.cfg.decoder]; !ok {
+		errs = append(errs, fmt.Errorf("decoder must be one of: %s", strings.Join(decoderNames(), ", ")))
+	}
+	switch {
+	case opts.cfg.throttleLatency < 0:
+		errs = append(errs, errors.New("throttle latency must not be negative"))
+	case opts.cfg.throttleLatency == 0:
+		if opts.cfg.throttleRecover != 0 {
+			errs = append(errs, errors.New("throttle recover latency requires throttle latency"))
+		}
+	default:
+		if opts.cfg.throttleRecover == 0 {
+			opts.cfg.throttleRecover = opts.cfg.throttleLatency * 4 / 5
+		}
+		if opts.cfg.throttleRecover < 0 || opts.cfg.throttleRecover > opts.cfg.throttleLatency {
+			errs = append(errs, errors.New("throttle recover latency must be positive and not above throttle latency"))
+		}
+		if opts.cfg.throttleFactor <= 0 || opts.cfg.throttleFactor >= 1 {
+			errs = append(errs, errors.New("throttle rate factor must be between 0 and 1"))
+		}
+	}
+	if opts.cfg.strictJSON && opts.cfg.decoder != decoderJSON {
+		errs = append(errs, errors.New("strict json requires the json decoder"))
+	}
+	if opts.cfg.summaryFormat == "" {
+		opts.cfg.summaryFormat = summaryText
+	}
+	if !slices.Contains(summaryFormats, opts.cfg.summaryFormat) {
+		errs = append(errs, fmt.Errorf("summary format must be one of: %s", strings.Join(summaryFormats, ", ")))
+	}
+
+	if opts.cfg.countOutput != "" && !opts.cfg.countOnly {
+		errs = append(errs, errors.New("count output requires count only"))
+	}
+	if opts.cfg.resetDedupe && !opts.cfg.dedupe {
+		errs = append(errs, errors.New("reset dedupe requires dedupe across runs"))
+	}
+	if opts.cfg.autoWorkspaces {
+		if opts.cfg.resource == "workspace" {
+			errs = append(errs, errors.New("auto workspaces cannot be used to export workspaces"))
+		}
+		if opts.cfg.workspace != "" || opts.cfg.project != "" || opts.cfg.gids != "" || opts.cfg.noFetch {
+			errs = append(errs, errors.New("auto workspaces cannot be combined with workspace, project, gids, or no fetch"))
+		}
+		// These files are replaced by the run of every workspace, or, for the
+		// seen set, loaded from the data directory once at startup.
+		if opts.cfg.dedupe || opts.cfg.summaryFile != "" || opts.cfg.countOutput != "" || opts.cfg.gidsOut != "" {
+			errs = append(errs, errors.New("auto workspaces cannot be combined with dedupe across runs, summary file, count output, or gids out"))
+		}
+	}
+	if opts.cfg.record != "" && opts.cfg.replay != "" {
+		errs = append(errs, errors.New("record and replay are mutually exclusive"))
+	}
+	if opts.cfg.fromRaw != "" && !opts.cfg.noFetch {
+		errs = append(errs, errors.New("from raw requires no fetch"))
+	}
+	if opts.cfg.noFetch {
+		if opts.cfg.preserveRaw || opts.cfg.resume || opts.cfg.countOnly || opts.cfg.retention != "" || opts.cfg.warmup {
+			errs = append(errs, errors.New("no fetch cannot be combined with preserve raw, resume, count only, retention, or warmup"))
+		}
+		if opts.cfg.fromRaw == "" {
+			opts.cfg.fromRaw = filepath.Join(opts.cfg.dataDir, opts.cfg.resource, rawDirName)
+		}
+		if info, err := os.Stat(opts.cfg.fromRaw); err != nil {
+			errs = append(errs, fmt.Errorf("from raw: %w", err))
+		} else if !info.IsDir() {
+			errs = append(errs, fmt.Errorf("from raw: %s is not a directory", opts.cfg.fromRaw))
+		}
+	}
+	if opts.cfg.startupJitter < 0 {
+		errs = append(errs, errors.New("startup jitter must not be negative"))
+	}
+	if opts.cfg.minCount < 0 {
+		errs = append(errs, errors.New("min count must not be negative"))
+	}
+	if opts.cfg.maxDropPercent < 0 || opts.cfg.maxDropPercent > 100 {
+		errs = append(errs, errors.New("max drop percent must be between 0 and 100"))
+	}
+	if opts.cfg.resumeSchedule && opts.cfg.interval == "" {
+		errs = append(errs, errors.New("resume schedule requires an interval"))
+	}
+	if opts.cfg.notifyURL != "" {
+		if u, err := url.Parse(opts.cfg.notifyURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, errors.New("notify url must be an absolute http or https URL"))
+		}
+	}
+	if opts.cfg.notifyFormat == "" {
+		opts.cfg.notifyFormat = notifyJSON
+	}
+	if !slices.Contains(notifyFormats, opts.cfg.notifyFormat) {
+		errs = append(errs, fmt.Errorf("notify format must be one of: %s", strings.Join(notifyFormats, ", ")))
+	}
+	if opts.cfg.postHook != "" && opts.cfg.postHookTimeout <= 0 {
+		errs = append(errs, errors.New("post hook timeout must be positive"))
+	}
+	if opts.cfg.modifiedSince != "" && opts.cfg.since != "" {
+		errs = append(errs, errors.New("modified since and since are mutually exclusive"))
+	}
+	if opts.cfg.modifiedSince != "" {
+		if _, err := time.Parse(time.RFC3339, opts.cfg.modifiedSince); err != nil {
+			errs = append(errs, fmt.Errorf("invalid modified since: %w", err))
+		}
+	}
+	if opts.cfg.completedSince != "" {
+		if opts.cfg.resource != "task" {
+			errs = append(errs, fmt.Errorf("completed since is only supported for task exports, not %q", opts.cfg.resource))
+		}
+		if opts.cfg.completedSince != completedSinceNow {
+			if _, err := time.Parse(time.RFC3339, opts.cfg.completedSince); err != nil {
+				errs = append(errs, fmt.Errorf("invalid completed since: %w", err))
+			}
+		}
+	}
+	switch opts.cfg.completed {
+	case "":
+		opts.cfg.completed = completedAll
+	case completedAll:
+	case completedTrue, completedFalse:
+		if opts.cfg.resource != "task" {
+			errs = append(errs, fmt.Errorf("completed is only supported for task exports, not %q", opts.cfg.resource))
+		}
+		if opts.cfg.completed == completedFalse && opts.cfg.completedSince != "" {
+			errs = append(errs, errors.New("completed false cannot be combined with completed since"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("completed must be one of: %s", strings.Join([]string{completedTrue, completedFalse, completedAll}, ", ")))
+	}
+	if opts.cfg.since != "" {
+		d, err := parseSince(opts.cfg.since)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid since: %w", err))
+		}
+		opts.cfg.sinceDuration = d
+	}
+	if opts.cfg.retention != "" {
+		d, err := parseSince(opts.cfg.retention)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid retention: %w", err))
+		}
+		opts.cfg.retentionDuration = d
+	}
+
+	if opts.cfg.timezone == "" {
+		opts.cfg.timezone = defaultTimezone
+	}
+	loc, err := loadTimezone(opts.cfg.timezone)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("invalid timezone: %w", err))
+	}
+	opts.cfg.location = loc
+
+	if opts.cfg.activeWindow != "" {
+		tz := opts.cfg.activeWindowTZ
+		if tz == "" {
+			tz = opts.cfg.timezone
+		}
+		window, err := parseWindow(opts.cfg.activeWindow, tz)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid active window: %w", err))
+		}
+		opts.cfg.window = window
+	}
+
+	retryStatuses, err := parseStatuses(opts.cfg.retryStatus)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("invalid retry status: %w", err))
+	}
+	opts.cfg.retryStatuses = retryStatuses
+
+	gidList, err := parseGIDs(opts.cfg.gids)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("invalid gids: %w", err))
+	}
+	opts.cfg.gidList = gidList
+
+	if opts.cfg.filterExpr != "" {
+		filter, err := parseFilter(opts.cfg.filterExpr)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid filter expression: %w", err))
+		}
+		opts.cfg.filter = filter
+	}
+	if opts.cfg.completed == completedTrue {
+		opts.cfg.filter = opts.cfg.filter.and(condition{path: []string{"completed"}, op: "==", value: "true"})
+	}
+
+	if opts.cfg.namePrefix != "" && strings.TrimSpace(opts.cfg.namePrefix) == "" {
+		errs = append(errs, errors.New("name prefix must not be blank"))
+	}
+
+	if opts.cfg.schemaFile != "" {
+		schema, err := loadSchema(opts.cfg.schemaFile)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("schema: %w", err))
+		}
+		opts.cfg.schema = schema
+	}
+
+	optFields, err := resolveFields(opts.cfg.fields, opts.cfg.fieldsFile)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("fields: %w", err))
+	}
+	switch {
+	case opts.cfg.countOnly && opts.cfg.filterExpr == "":
+		optFields = countFields
+	case len(optFields) == 0:
+		optFields = rt.defaultFields
+	}
+	if opts.cfg.namePrefix != "" && !slices.Contains(optFields, "name") {
+		optFields = append(slices.Clone(optFields), "name")
+	}
+	if opts.cfg.completed == completedTrue && !slices.Contains(optFields, "completed") {
+		optFields = append(slices.Clone(optFields), "completed")
+	}
+	if opts.cfg.partition && !slices.Contains(optFields, "modified_at") {
+		optFields = append(slices.Clone(optFields), "modified_at")
+	}
+	if opts.cfg.exportGraph {
+		for _, f := range graphFields {
+			if !slices.Contains(optFields, f) {
+				optFields = append(slices.Clone(optFields), f)
+			}
+		}
+	}
+	opts.cfg.optFields = optFields
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
 	}
 
 	return &opts.cfg, nil
 }
 
+// checkUnixSocket verifies that path exists and is a Unix domain socket.
+func checkUnixSocket(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("%s is not a socket", path)
+	}
+
+	return nil
+}
+
+// checkDataDir verifies that exported resources can be written to dir. The
+// directory may not exist yet, in which case its nearest existing ancestor
+// must be a writable directory so it can be created.
+func checkDataDir(dir string) error {
+	if dir == "" {
+		return nil
+	}
+
+	path := filepath.Clean(dir)
+	for {
+		info, err := os.Stat(path)
+		if err == nil {
+			if !info.IsDir() {
+				return fmt.Errorf("%q is not a directory", path)
+			}
+			break
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+
+		parent := filepath.Dir(path)
+		if parent == path {
+			return nil
+		}
+		path = parent
+	}
+
+	f, err := os.CreateTemp(path, ".write-check-*")
+	if err != nil {
+		return fmt.Errorf("%q is not writable: %w", path, err)
+	}
+	name := f.Name()
+	_ = f.Close()
+
+	return os.Remove(name)
+}
+
+// parseGIDs splits a comma-separated GID list, ignoring blanks. Each GID must
+// consist of digits only.
+func parseGIDs(s string) ([]string, error) {
+	var gids []string
+	for _, gid := range strings.Split(s, ",") {
+		gid = strings.TrimSpace(gid)
+		if gid == "" {
+			continue
+		}
+		if strings.Trim(gid, "0123456789") != "" {
+			return nil, fmt.Errorf("gid %q must be numeric", gid)
+		}
+		gids = append(gids, gid)
+	}
+
+	return gids, nil
+}
+
+// parseStatuses splits a comma-separated list of HTTP statuses, ignoring
+// blanks. Each status must be an error status between 400 and 599, as other
+// responses are not failures to retry.
+func parseStatuses(s string) (map[int]bool, error) {
+	var statuses map[int]bool
+	for _, status := range strings.Split(s, ",") {
+		status = strings.TrimSpace(status)
+		if status == "" {
+			continue
+		}
+		code, err := strconv.Atoi(status)
+		if err != nil {
+			return nil, fmt.Errorf("status %q must be numeric", status)
+		}
+		if code < 400 || code > 599 {
+			return nil, fmt.Errorf("status %d must be between 400 and 599", code)
+		}
+		if statuses == nil {
+			statuses = make(map[int]bool)
+		}
+		statuses[code] = true
+	}
+
+	return statuses, nil
+}
+
+// parseSince parses a relative duration for the since and retention options.
+// In addition to time.ParseDuration units it accepts whole days ("7d") and
+// weeks ("2w"). The duration must be positive.
+func parseSince(s string) (time.Duration, error) {
+	var d time.Duration
+	switch unit := s[len(s)-1:]; unit {
+	case "d", "w":
+		n, err := strconv.Atoi(s[:len(s)-1])
+		if err != nil {
+			return 0, fmt.Errorf("parse %q: %w", s, err)
+		}
+		d = time.Duration(n) * 24 * time.Hour
+		if unit == "w" {
+			d *= 7
+		}
+	default:
+		var err error
+		if d, err = time.ParseDuration(s); err != nil {
+			return 0, err
+		}
+	}
+
+	if d <= 0 {
+		return 0, fmt.Errorf("duration %q must be positive", s)
+	}
+
+	return d, nil
+}
+
+// resolveFields merges the comma-separated fields list with the fields listed
+// in file, if any. The file may separate fields by newlines or commas; blank
+// lines and lines starting with # are ignored. Duplicates are removed while
+// preserving first-seen order.
+func resolveFields(fields, file string) ([]string, error) {
+	list := fields
+	if file != "" {
+		content, err := os.ReadFile(filepath.Clean(file))
+		if err != nil {
+			return nil, fmt.Errorf("read fields file: %w", err)
+		}
+
+		for _, line := range strings.Split(string(content), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			list += "," + line
+		}
+	}
+
+	var result []string
+	seen := make(map[string]bool)
+	for _, f := range strings.Split(list, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" || seen[f] {
+			continue
+		}
+		seen[f] = true
+		result = append(result, f)
+	}
+
+	return result, nil
+}
+
+// expandEnv replaces $VAR and ${VAR} references in s with the values of the
+// corresponding environment variables. Unlike os.ExpandEnv, it returns an error
+// naming every referenced variable that is not set, rather than silently
+// substituting an empty string.
+func expandEnv(s string) (string, error) {
+	var missing []string
+	expanded := os.Expand(s, func(name string) string {
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			missing = append(missing, name)
+		}
+		return v
+	})
+
+	if len(missing) > 0 {
+		return "", fmt.Errorf("undefined environment variables: %s", strings.Join(missing, ", "))
+	}
+
+	return expanded, nil
+}
+
+// clientOptions builds the API client options from the configuration.
+func clientOptions(cfg *config, log *slog.Logger) ([]internal.Option, error) {
+	opts := []internal.Option{
+		internal.WithLogger(log),
+		internal.WithMaxRedirects(cfg.maxRedirects),
+		internal.WithAccept(cfg.accept),
+		internal.WithKeepAlive(cfg.idleConnTimeout, cfg.maxIdleConnsPerHost),
+	}
+
+	switch {
+	case cfg.unixSocket != "":
+		opts = append(opts, internal.WithUnixSocket(cfg.unixSocket))
+	case cfg.dnsCacheTTL > 0:
+		opts = append(opts, internal.WithDNSCache(cfg.dnsCacheTTL))
+	}
+
+	if cfg.disableHTTP2 {
+		opts = append(opts, internal.WithoutHTTP2())
+	}
+
+	if cfg.clientCert != "" {
+		opts = append(opts, internal.WithClientCertificate(cfg.clientCert, cfg.clientKey))
+	}
+
+	if cfg.throttleLatency > 0 {
+		opts = append(opts, internal.WithLatencyThrottle(cfg.throttleLatency, cfg.throttleRecover, cfg.throttleFactor))
+	}
+
+	if cfg.signingKey != "" {
+		signer, err := internal.NewHMACSigner(cfg.signingKey, cfg.signingHeader)
+		if err != nil {
+			return nil, fmt.Errorf("new signer: %w", err)
+		}
+		opts = append(opts, internal.WithSigner(signer))
+	}
+
+	switch {
+	case cfg.record != "":
+		opts = append(opts, internal.WithRecorder(cfg.record))
+	case cfg.replay != "":
+		opts = append(opts, internal.WithReplayer(cfg.replay))
+	}
+
+	return opts, nil
+}
+
 // validLogFormat checks if the provided log format is supported (json or text).
 func validLogFormat(format string) bool {
 	return format == "json" || format == "text"
 }
 
 // newLogger creates a new structured logger with the given options.
-// It configures the log level, format (JSON or text), and output destination (file or stdout).
+// It configures the log level, format (JSON or text), and output destination (file, or
+// stdout, or stderr while a progress bar is drawn on stdout).
 // The logger supports debug level messages when enabled through options.
+// With dedupe-errors, repeated warnings and errors are collapsed; see
+// dedupeHandler.
 func newLogger(opts options) (*slog.Logger, error) {
 	if !validLogFormat(opts.log.format) {
 		return nil, fmt.Errorf("unsupported log format: %s", opts.log.format)
@@ -162,6 +1188,9 @@ func newLogger(opts options) (*slog.Logger, error) {
 			return nil, fmt.Errorf("log output: %w", err)
 		}
 		output = file
+	case opts.cfg.progressBar && isTerminal(os.Stdout):
+		// Keep the progress bar on stdout clear of log lines.
+		output = os.Stderr
 	default:
 		output = os.Stdout
 	}
@@ -171,10 +1200,17 @@ func newLogger(opts options) (*slog.Logger, error) {
 		logOpts.Level = slog.LevelDebug
 	}
 
+	var handler slog.Handler
 	switch opts.log.format {
 	case "json":
-		return slog.New(slog.NewJSONHandler(output, &logOpts)), nil
+		handler = slog.NewJSONHandler(output, &logOpts)
 	default:
-		return slog.New(slog.NewTextHandler(output, &logOpts)), nil
+		handler = slog.NewTextHandler(output, &logOpts)
+	}
+
+	if opts.log.dedupeErrors {
+		handler = newDedupeHandler(handler, dedupeFlushInterval)
 	}
+
+	return slog.New(handler), nil
 }
@@ -1,9 +1,14 @@
 package main
 
 import (
+	"encoding/json"
+	"maps"
+	"net"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestNewApp(t *testing.T) {
@@ -42,6 +47,30 @@ func TestNewApp(t *testing.T) {
 			token:     "test-token",
 			wantError: true,
 		},
+		{
+			name:      "valid configuration with request signing",
+			args:      []string{"cmd", "-resource", "project", "-signing-key", "secret", "-signing-header", "X-Gateway-Signature"},
+			token:     "test-token",
+			wantError: false,
+		},
+		{
+			name:      "replay without token",
+			args:      []string{"cmd", "-resource", "project", "-replay", os.TempDir()},
+			token:     "",
+			wantError: false,
+		},
+		{
+			name:      "record and replay",
+			args:      []string{"cmd", "-resource", "project", "-record", os.TempDir(), "-replay", os.TempDir()},
+			token:     "test-token",
+			wantError: true,
+		},
+		{
+			name:      "dump config without token",
+			args:      []string{"cmd", "-resource", "project", "-dump-config"},
+			token:     "",
+			wantError: false,
+		},
 		{
 			name:      "valid configuration with silent logging",
 			args:      []string{"cmd", "-resource", "project", "-rate", "60"},
@@ -79,6 +108,101 @@ func TestNewApp(t *testing.T) {
 	}
 }
 
+func TestAppDumpConfig(t *testing.T) {
+	t.Setenv("ASANA_TEST_ENV", "staging")
+	t.Setenv("ASANA_API_TOKEN", "test-token")
+
+	fieldsFile := filepath.Join(t.TempDir(), "fields.txt")
+	if err := os.WriteFile(fieldsFile, []byte("notes\nowner\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write fields file: %v", err)
+	}
+
+	dataDir := filepath.Join(t.TempDir(), "${ASANA_TEST_ENV}")
+	app, err := newApp([]string{"cmd", "-resource", "project", "-dump-config",
+		"-data-dir", dataDir, "-fields", "name", "-fields-file", fieldsFile, "-signing-key", "secret",
+		"-notify-url", "https://hooks.example.com/services/secret"})
+	if err != nil {
+		t.Fatalf("newApp() error = %v", err)
+	}
+	if !app.dump {
+		t.Fatal("newApp() dump = false, want true")
+	}
+
+	var buf strings.Builder
+	if err := app.dumpConfig(&buf); err != nil {
+		t.Fatalf("dumpConfig() error = %v", err)
+	}
+	out := buf.String()
+
+	for _, leak := range []string{"test-token", "secret"} {
+		if strings.Contains(out, leak) {
+			t.Errorf("dumpConfig() output contains %q:\n%s", leak, out)
+		}
+	}
+
+	var got effectiveConfig
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("Failed to decode output: %v", err)
+	}
+	if got.Token != redacted || got.SigningKey != redacted || got.NotifyURL != redacted {
+		t.Errorf("dumpConfig() token = %q, signing key = %q, notify url = %q, want all %q",
+			got.Token, got.SigningKey, got.NotifyURL, redacted)
+	}
+	if filepath.Base(got.DataDir) != "staging" {
+		t.Errorf("dumpConfig() data dir = %q, want expanded variable", got.DataDir)
+	}
+	if strings.Join(got.Fields, ",") != "name,notes,owner" {
+		t.Errorf("dumpConfig() fields = %v, want [name notes owner]", got.Fields)
+	}
+}
+
+func TestAppDumpConfigTokenSources(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "tokens.txt")
+	if err := os.WriteFile(tokenFile, []byte("file-token-1\nfile-token-2\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write token file: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		tokens string
+		args   []string
+		want   string
+	}{
+		{"token list", "env-token-1,env-token-2", nil, redacted},
+		{"token file", "", []string{"-token-file", tokenFile}, redacted},
+		{"unset", "", nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("ASANA_API_TOKEN", "")
+			t.Setenv("ASANA_API_TOKENS", tt.tokens)
+
+			args := append([]string{"cmd", "-resource", "project", "-dump-config"}, tt.args...)
+			app, err := newApp(args)
+			if err != nil {
+				t.Fatalf("newApp() error = %v", err)
+			}
+
+			var buf strings.Builder
+			if err := app.dumpConfig(&buf); err != nil {
+				t.Fatalf("dumpConfig() error = %v", err)
+			}
+			if strings.Contains(buf.String(), "-token-") {
+				t.Errorf("dumpConfig() output contains a token:\n%s", buf.String())
+			}
+
+			var got effectiveConfig
+			if err := json.Unmarshal([]byte(buf.String()), &got); err != nil {
+				t.Fatalf("Failed to decode output: %v", err)
+			}
+			if got.Token != tt.want {
+				t.Errorf("dumpConfig() token = %q, want %q", got.Token, tt.want)
+			}
+		})
+	}
+}
+
 func TestNewOptions(t *testing.T) {
 
 	tests := []struct {
@@ -180,6 +304,7 @@ func TestNewConfig(t *testing.T) {
 					entrypoint: defaultEntrypoint,
 					resource:   "project",
 					rate:       60,
+					pageSize:   defaultPageSize,
 				},
 			},
 			wantErr: false,
@@ -215,17 +340,957 @@ func TestNewConfig(t *testing.T) {
 			},
 			wantErr: true,
 		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			cfg, err := newConfig(tt.opts)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("newConfig() error = %v, wantErr %v", err, tt.wantErr)
-				return
-			}
-			if err == nil && cfg == nil {
-				t.Error("newConfig() returned nil config without error")
+		{
+			name: "modified since and since",
+			opts: options{
+				cfg: config{
+					entrypoint:    defaultEntrypoint,
+					resource:      "task",
+					rate:          60,
+					pageSize:      defaultPageSize,
+					modifiedSince: "2024-06-01T00:00:00Z",
+					since:         "7d",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid modified since",
+			opts: options{
+				cfg: config{
+					entrypoint:    defaultEntrypoint,
+					resource:      "task",
+					rate:          60,
+					pageSize:      defaultPageSize,
+					modifiedSince: "yesterday",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "completed since now",
+			opts: options{
+				cfg: config{
+					entrypoint:     defaultEntrypoint,
+					resource:       "task",
+					rate:           60,
+					pageSize:       defaultPageSize,
+					completedSince: completedSinceNow,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid completed since",
+			opts: options{
+				cfg: config{
+					entrypoint:     defaultEntrypoint,
+					resource:       "task",
+					rate:           60,
+					pageSize:       defaultPageSize,
+					completedSince: "last week",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "completed since for non-task resource",
+			opts: options{
+				cfg: config{
+					entrypoint:     defaultEntrypoint,
+					resource:       "project",
+					rate:           60,
+					pageSize:       defaultPageSize,
+					completedSince: "2024-06-01T00:00:00Z",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "completed false with completed since",
+			opts: options{
+				cfg: config{
+					entrypoint:     defaultEntrypoint,
+					resource:       "task",
+					rate:           60,
+					pageSize:       defaultPageSize,
+					completed:      completedFalse,
+					completedSince: completedSinceNow,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "completed for non-task resource",
+			opts: options{
+				cfg: config{
+					entrypoint: defaultEntrypoint,
+					resource:   "project",
+					rate:       60,
+					pageSize:   defaultPageSize,
+					completed:  completedTrue,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid completed",
+			opts: options{
+				cfg: config{
+					entrypoint: defaultEntrypoint,
+					resource:   "task",
+					rate:       60,
+					pageSize:   defaultPageSize,
+					completed:  "yes",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "page size too large",
+			opts: options{
+				cfg: config{
+					entrypoint: defaultEntrypoint,
+					resource:   "project",
+					rate:       60,
+					pageSize:   maxPageSize + 1,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative retry on empty",
+			opts: options{
+				cfg: config{
+					entrypoint:   defaultEntrypoint,
+					resource:     "project",
+					rate:         60,
+					pageSize:     defaultPageSize,
+					retryOnEmpty: -1,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "count only with resume",
+			opts: options{
+				cfg: config{
+					entrypoint: defaultEntrypoint,
+					resource:   "project",
+					rate:       60,
+					pageSize:   defaultPageSize,
+					countOnly:  true,
+					resume:     true,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative max redirects",
+			opts: options{
+				cfg: config{
+					entrypoint:   defaultEntrypoint,
+					resource:     "project",
+					rate:         60,
+					pageSize:     defaultPageSize,
+					maxRedirects: -1,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "unsupported resource",
+			opts: options{
+				cfg: config{
+					entrypoint: defaultEntrypoint,
+					resource:   "attachment",
+					rate:       60,
+					pageSize:   defaultPageSize,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid alias",
+			opts: options{
+				cfg: config{
+					entrypoint: defaultEntrypoint,
+					resource:   "todos",
+					alias:      "todos=chores",
+					rate:       60,
+					pageSize:   defaultPageSize,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "resource requires workspace",
+			opts: options{
+				cfg: config{
+					entrypoint: defaultEntrypoint,
+					resource:   "goal",
+					rate:       60,
+					pageSize:   defaultPageSize,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "resource with workspace",
+			opts: options{
+				cfg: config{
+					entrypoint: defaultEntrypoint,
+					resource:   "goal",
+					workspace:  "12345",
+					rate:       60,
+					pageSize:   defaultPageSize,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "portfolio without owner",
+			opts: options{
+				cfg: config{
+					entrypoint: defaultEntrypoint,
+					resource:   "portfolio",
+					workspace:  "12345",
+					rate:       60,
+					pageSize:   defaultPageSize,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown output format",
+			opts: options{
+				cfg: config{
+					entrypoint:   defaultEntrypoint,
+					resource:     "project",
+					rate:         60,
+					pageSize:     defaultPageSize,
+					outputFormat: "csv",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "max file size with json output",
+			opts: options{
+				cfg: config{
+					entrypoint:  defaultEntrypoint,
+					resource:    "project",
+					rate:        60,
+					pageSize:    defaultPageSize,
+					maxFileSize: 1 << 20,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "max file size with jsonl.gz output",
+			opts: options{
+				cfg: config{
+					entrypoint:   defaultEntrypoint,
+					resource:     "project",
+					rate:         60,
+					pageSize:     defaultPageSize,
+					outputFormat: formatJSONLGzip,
+					maxFileSize:  1 << 20,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "negative max goroutines",
+			opts: options{
+				cfg: config{
+					entrypoint:    defaultEntrypoint,
+					resource:      "project",
+					rate:          60,
+					pageSize:      defaultPageSize,
+					maxGoroutines: -1,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative dns cache ttl",
+			opts: options{
+				cfg: config{
+					entrypoint:  defaultEntrypoint,
+					resource:    "project",
+					rate:        60,
+					pageSize:    defaultPageSize,
+					dnsCacheTTL: -time.Minute,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "auto workspaces for a type requiring a workspace",
+			opts: options{
+				cfg: config{
+					entrypoint:     defaultEntrypoint,
+					resource:       "goal",
+					rate:           60,
+					pageSize:       defaultPageSize,
+					autoWorkspaces: true,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "auto workspaces with workspace",
+			opts: options{
+				cfg: config{
+					entrypoint:     defaultEntrypoint,
+					resource:       "project",
+					workspace:      "12345",
+					rate:           60,
+					pageSize:       defaultPageSize,
+					autoWorkspaces: true,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "auto workspaces with summary file",
+			opts: options{
+				cfg: config{
+					entrypoint:     defaultEntrypoint,
+					resource:       "project",
+					rate:           60,
+					pageSize:       defaultPageSize,
+					autoWorkspaces: true,
+					summaryFile:    "summary.txt",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "too many write retries",
+			opts: options{
+				cfg: config{
+					entrypoint:   defaultEntrypoint,
+					resource:     "project",
+					rate:         60,
+					pageSize:     defaultPageSize,
+					writeRetries: maxRetries + 1,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "too many network retries",
+			opts: options{
+				cfg: config{
+					entrypoint:     defaultEntrypoint,
+					resource:       "project",
+					rate:           60,
+					pageSize:       defaultPageSize,
+					networkRetries: maxRetries + 1,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "too many http retries",
+			opts: options{
+				cfg: config{
+					entrypoint:  defaultEntrypoint,
+					resource:    "project",
+					rate:        60,
+					pageSize:    defaultPageSize,
+					httpRetries: maxRetries + 1,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "flatten dir with partition",
+			opts: options{
+				cfg: config{
+					entrypoint: defaultEntrypoint,
+					resource:   "project",
+					rate:       60,
+					pageSize:   defaultPageSize,
+					flattenDir: true,
+					partition:  true,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "export graph",
+			opts: options{
+				cfg: config{
+					entrypoint:  defaultEntrypoint,
+					resource:    "task",
+					rate:        60,
+					pageSize:    defaultPageSize,
+					exportGraph: true,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "export graph of projects",
+			opts: options{
+				cfg: config{
+					entrypoint:  defaultEntrypoint,
+					resource:    "project",
+					rate:        60,
+					pageSize:    defaultPageSize,
+					exportGraph: true,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "export graph with count only",
+			opts: options{
+				cfg: config{
+					entrypoint:  defaultEntrypoint,
+					resource:    "task",
+					rate:        60,
+					pageSize:    defaultPageSize,
+					exportGraph: true,
+					countOnly:   true,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "notify url without scheme",
+			opts: options{
+				cfg: config{
+					entrypoint: defaultEntrypoint,
+					resource:   "project",
+					rate:       60,
+					pageSize:   defaultPageSize,
+					notifyURL:  "hooks.example.com/run",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid notify format",
+			opts: options{
+				cfg: config{
+					entrypoint:   defaultEntrypoint,
+					resource:     "project",
+					rate:         60,
+					pageSize:     defaultPageSize,
+					notifyURL:    "https://hooks.example.com/run",
+					notifyFormat: "teams",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "client cert without key",
+			opts: options{
+				cfg: config{
+					entrypoint: defaultEntrypoint,
+					resource:   "project",
+					rate:       60,
+					pageSize:   defaultPageSize,
+					clientCert: "client.crt",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid retention",
+			opts: options{
+				cfg: config{
+					entrypoint: defaultEntrypoint,
+					resource:   "project",
+					rate:       60,
+					pageSize:   defaultPageSize,
+					retention:  "forever",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "non-numeric gid",
+			opts: options{
+				cfg: config{
+					entrypoint: defaultEntrypoint,
+					resource:   "project",
+					rate:       60,
+					pageSize:   defaultPageSize,
+					gids:       "123,abc",
+				},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := newConfig(tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("newConfig() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err == nil && cfg == nil {
+				t.Error("newConfig() returned nil config without error")
+			}
+		})
+	}
+}
+
+func TestNewConfigThrottle(t *testing.T) {
+	tests := []struct {
+		name        string
+		latency     time.Duration
+		recover     time.Duration
+		factor      float64
+		wantRecover time.Duration
+		wantErr     bool
+	}{
+		{"disabled", 0, 0, defaultThrottleFactor, 0, false},
+		{"default recovery", 2 * time.Second, 0, defaultThrottleFactor, 1600 * time.Millisecond, false},
+		{"recovery", 2 * time.Second, time.Second, 0.25, time.Second, false},
+		{"recovery without latency", 0, time.Second, defaultThrottleFactor, 0, true},
+		{"recovery above latency", time.Second, 2 * time.Second, defaultThrottleFactor, 0, true},
+		{"negative latency", -time.Second, 0, defaultThrottleFactor, 0, true},
+		{"factor of one", time.Second, 0, 1, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := newConfig(options{cfg: config{
+				entrypoint:      defaultEntrypoint,
+				resource:        "project",
+				rate:            60,
+				pageSize:        defaultPageSize,
+				throttleLatency: tt.latency,
+				throttleRecover: tt.recover,
+				throttleFactor:  tt.factor,
+			}})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && cfg.throttleRecover != tt.wantRecover {
+				t.Errorf("throttleRecover = %v, want %v", cfg.throttleRecover, tt.wantRecover)
+			}
+		})
+	}
+}
+
+func TestNewConfigNoResource(t *testing.T) {
+	tests := []struct {
+		name     string
+		resource string
+		alias    string
+		probe    bool
+		wantErr  bool
+	}{
+		{"empty", "", "", false, true},
+		{"blank", "  ", "", false, true},
+		{"padded", " project ", "", false, false},
+		{"alias", "todos", "todos=task", false, false},
+		{"probe without resource", "", "", true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newConfig(options{
+				cfg: config{
+					entrypoint: defaultEntrypoint,
+					resource:   tt.resource,
+					alias:      tt.alias,
+					rate:       60,
+					pageSize:   defaultPageSize,
+				},
+				probe: tt.probe,
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !strings.Contains(err.Error(), "resource type not provided, must be one of: custom_field, goal") {
+				t.Errorf("newConfig() error = %q, want it to name the supported types", err)
+			}
+		})
+	}
+}
+
+func TestNewConfigMultipleErrors(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "data")
+	if err := os.WriteFile(file, nil, 0o600); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	_, err := newConfig(options{
+		cfg: config{
+			interval: "10ms",
+			dataDir:  file,
+			pageSize: defaultPageSize,
+		},
+	})
+	if err == nil {
+		t.Fatal("newConfig() error = nil, want error")
+	}
+
+	for _, want := range []string{
+		"entrypoint not provided",
+		"resource type not provided",
+		"rate limit must be positive",
+		"interval must be at least 1 second",
+		"is not a directory",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("newConfig() error = %q, want it to contain %q", err, want)
+		}
+	}
+}
+
+func TestCheckDataDir(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file")
+	if err := os.WriteFile(file, nil, 0o600); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		dir     string
+		wantErr bool
+	}{
+		{"existing directory", dir, false},
+		{"missing directory", filepath.Join(dir, "a", "b"), false},
+		{"file", file, true},
+		{"below file", filepath.Join(file, "sub"), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkDataDir(tt.dir); (err != nil) != tt.wantErr {
+				t.Errorf("checkDataDir() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("checkDataDir() left %d entries in directory, want 1", len(entries))
+	}
+}
+
+func TestCheckUnixSocket(t *testing.T) {
+	dir := t.TempDir()
+	socket := filepath.Join(dir, "proxy.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("Failed to listen on unix socket: %v", err)
+	}
+	defer listener.Close()
+
+	file := filepath.Join(dir, "file")
+	if err := os.WriteFile(file, nil, 0o600); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		wantErr bool
+	}{
+		{"socket", socket, false},
+		{"missing", filepath.Join(dir, "missing.sock"), true},
+		{"regular file", file, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkUnixSocket(tt.path); (err != nil) != tt.wantErr {
+				t.Errorf("checkUnixSocket() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewConfigAlias(t *testing.T) {
+	cfg, err := newConfig(options{
+		cfg: config{
+			entrypoint: defaultEntrypoint,
+			resource:   "todos",
+			alias:      "todos=tasks",
+			rate:       60,
+			pageSize:   defaultPageSize,
+		},
+	})
+	if err != nil {
+		t.Fatalf("newConfig() error = %v", err)
+	}
+	if cfg.resource != "task" {
+		t.Errorf("newConfig() resource = %q, want %q", cfg.resource, "task")
+	}
+	if strings.Join(cfg.optFields, ",") != strings.Join(resourceTypes["task"].defaultFields, ",") {
+		t.Errorf("newConfig() optFields = %v, want task default fields", cfg.optFields)
+	}
+}
+
+func TestNewConfigDefaultFields(t *testing.T) {
+	tests := []struct {
+		name      string
+		resource  string
+		fields    string
+		countOnly bool
+		want      []string
+	}{
+		{
+			name:     "resource default",
+			resource: "task",
+			want:     resourceTypes["task"].defaultFields,
+		},
+		{
+			name:     "fields override default",
+			resource: "task",
+			fields:   "name,notes",
+			want:     []string{"name", "notes"},
+		},
+		{
+			name:      "count only",
+			resource:  "task",
+			fields:    "name,notes",
+			countOnly: true,
+			want:      countFields,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := newConfig(options{
+				cfg: config{
+					entrypoint: defaultEntrypoint,
+					resource:   tt.resource,
+					rate:       60,
+					pageSize:   defaultPageSize,
+					fields:     tt.fields,
+					countOnly:  tt.countOnly,
+				},
+			})
+			if err != nil {
+				t.Fatalf("newConfig() error = %v", err)
+			}
+			if strings.Join(cfg.optFields, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("newConfig() optFields = %v, want %v", cfg.optFields, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewConfigEnvExpansion(t *testing.T) {
+	t.Setenv("ASANA_TEST_HOST", "https://asana.example.com")
+	t.Setenv("ASANA_TEST_ENV", "staging")
+
+	tests := []struct {
+		name           string
+		entrypoint     string
+		dataDir        string
+		wantEntrypoint string
+		wantDataDir    string
+		wantErr        bool
+	}{
+		{
+			name:           "set variables",
+			entrypoint:     "$ASANA_TEST_HOST/api/1.0",
+			dataDir:        "/exports/${ASANA_TEST_ENV}",
+			wantEntrypoint: "https://asana.example.com/api/1.0",
+			wantDataDir:    "/exports/staging",
+			wantErr:        false,
+		},
+		{
+			name:           "no variables",
+			entrypoint:     defaultEntrypoint,
+			dataDir:        "data",
+			wantEntrypoint: defaultEntrypoint,
+			wantDataDir:    "data",
+			wantErr:        false,
+		},
+		{
+			name:       "unset entrypoint variable",
+			entrypoint: "$ASANA_TEST_UNSET_HOST/api/1.0",
+			dataDir:    "data",
+			wantErr:    true,
+		},
+		{
+			name:       "unset data dir variable",
+			entrypoint: defaultEntrypoint,
+			dataDir:    "/exports/${ASANA_TEST_UNSET_ENV}",
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := newConfig(options{
+				cfg: config{
+					entrypoint: tt.entrypoint,
+					dataDir:    tt.dataDir,
+					resource:   "project",
+					rate:       60,
+					pageSize:   defaultPageSize,
+				},
+			})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("newConfig() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				return
+			}
+			if cfg.entrypoint != tt.wantEntrypoint {
+				t.Errorf("newConfig() entrypoint = %v, want %v", cfg.entrypoint, tt.wantEntrypoint)
+			}
+			if cfg.dataDir != tt.wantDataDir {
+				t.Errorf("newConfig() dataDir = %v, want %v", cfg.dataDir, tt.wantDataDir)
+			}
+		})
+	}
+}
+
+func TestParseGIDs(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []string
+		wantErr bool
+	}{
+		{"empty", "", nil, false},
+		{"list", "123, 456,,789", []string{"123", "456", "789"}, false},
+		{"non-numeric", "12a", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseGIDs(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseGIDs() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("parseGIDs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseStatuses(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    map[int]bool
+		wantErr bool
+	}{
+		{"empty", "", nil, false},
+		{"list", "420, 598,,420", map[int]bool{420: true, 598: true}, false},
+		{"non-numeric", "42x", nil, true},
+		{"success status", "200", nil, true},
+		{"out of range", "600", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseStatuses(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseStatuses() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("parseStatuses() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"hours", "24h", 24 * time.Hour, false},
+		{"minutes", "90m", 90 * time.Minute, false},
+		{"days", "7d", 7 * 24 * time.Hour, false},
+		{"weeks", "2w", 14 * 24 * time.Hour, false},
+		{"zero", "0d", 0, true},
+		{"negative", "-1h", 0, true},
+		{"invalid days", "xd", 0, true},
+		{"invalid", "soon", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSince(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseSince() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("parseSince() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveFields(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "fields.txt")
+	content := "# task fields\nnotes\n  assignee.name , due_on\n\n# duplicates are dropped\nname\n"
+	if err := os.WriteFile(file, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write fields file: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		fields  string
+		file    string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "no fields",
+			want: nil,
+		},
+		{
+			name:   "flag only",
+			fields: "name, notes,,owner",
+			want:   []string{"name", "notes", "owner"},
+		},
+		{
+			name: "file only",
+			file: file,
+			want: []string{"notes", "assignee.name", "due_on", "name"},
+		},
+		{
+			name:   "flag and file merged",
+			fields: "name,completed",
+			file:   file,
+			want:   []string{"name", "completed", "notes", "assignee.name", "due_on"},
+		},
+		{
+			name:    "missing file",
+			file:    filepath.Join(dir, "missing.txt"),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveFields(tt.fields, tt.file)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("resolveFields() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("resolveFields() = %v, want %v", got, tt.want)
 			}
 		})
 	}
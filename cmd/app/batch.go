@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxBatchActions is the maximum number of actions Asana accepts in a single
+// batch request.
+const maxBatchActions = 10
+
+// batchAction is a single request bundled into a batch API call.
+type batchAction struct {
+	Method       string        `json:"method"`            // HTTP method of the action, lower case
+	RelativePath string        `json:"relative_path"`     // Path relative to the API entrypoint
+	Options      *batchOptions `json:"options,omitempty"` // Optional per-action options
+}
+
+// batchOptions holds the options of a batch action.
+type batchOptions struct {
+	Fields []string `json:"fields,omitempty"` // opt_fields requested for the action
+}
+
+// batchResult is the response to a single batch action.
+type batchResult struct {
+	StatusCode int `json:"status_code"` // HTTP status code of the action
+	Body       struct {
+		Data   json.RawMessage `json:"data"` // Resource returned by the action
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"` // Errors reported for a failed action
+	} `json:"body"`
+}
+
+// fetchGIDs retrieves the configured resources by GID through the Asana batch
+// API, bundling up to maxBatchActions lookups into a single request. The
+// resources of each batch are passed to handle as one page in the standard
+// data envelope, in the order the GIDs were given. A failed lookup is
+// tolerated unless strict mode is enabled. When preserve-raw is enabled, each
+// batch response is stored unmodified under dir.
+func (a *app) fetchGIDs(ctx context.Context, dir string, handle func(data []byte) error) error {
+	a.log.Debug("fetch resources by gid", slog.Int("gids", len(a.cfg.gidList)))
+
+	runTime := time.Now()
+	for start := 0; start < len(a.cfg.gidList); start += maxBatchActions {
+		gids := a.cfg.gidList[start:min(start+maxBatchActions, len(a.cfg.gidList))]
+
+		actions := make([]batchAction, len(gids))
+		for i, gid := range gids {
+			actions[i] = batchAction{
+				Method:       "get",
+				RelativePath: fmt.Sprintf("/%s/%s", resourceTypes[a.cfg.resource].path, gid),
+			}
+			if len(a.cfg.optFields) > 0 {
+				actions[i].Options = &batchOptions{Fields: a.cfg.optFields}
+			}
+		}
+
+		raw, results, err := a.batch(ctx, actions)
+		if err != nil {
+			return err
+		}
+
+		if a.cfg.preserveRaw {
+			if err := a.storeRaw(raw, dir, runTime, start/maxBatchActions+1); err != nil {
+				return fmt.Errorf("store raw response: %w", err)
+			}
+		}
+
+		data, err := a.batchPage(results, gids)
+		if err != nil {
+			return err
+		}
+		if err := handle(data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// batchPage wraps the resources of successful batch results in the standard
+// data envelope. Failed results are tolerated unless strict mode is enabled;
+// they are reported by GID, or by position when gids is shorter than results,
+// and recorded in the error file.
+func (a *app) batchPage(results []batchResult, gids []string) ([]byte, error) {
+	var page struct {
+		Data []json.RawMessage `json:"data"`
+	}
+	for i, res := range results {
+		if res.StatusCode != http.StatusOK {
+			var gid string
+			id := fmt.Sprintf("#%d", i+1)
+			if i < len(gids) {
+				gid, id = gids[i], gids[i]
+			}
+			var msgs []string
+			for _, e := range res.Body.Errors {
+				msgs = append(msgs, e.Message)
+			}
+			err := fmt.Errorf("resource %s: status %d: %s", id, res.StatusCode, strings.Join(msgs, "; "))
+			a.recordError(gid, phaseFetch, err)
+			if err := a.degrade(err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		page.Data = append(page.Data, res.Body.Data)
+	}
+
+	data, err := json.Marshal(page)
+	if err != nil {
+		return nil, fmt.Errorf("marshal page: %w", err)
+	}
+
+	return data, nil
+}
+
+// batch submits actions to the batch endpoint and returns the raw response
+// along with one result per action, in the same order.
+func (a *app) batch(ctx context.Context, actions []batchAction) ([]byte, []batchResult, error) {
+	var req struct {
+		Data struct {
+			Actions []batchAction `json:"actions"`
+		} `json:"data"`
+	}
+	req.Data.Actions = actions
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshal batch: %w", err)
+	}
+
+	data, err := a.call(ctx, http.MethodPost, a.entrypoint()+"/batch", body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("batch request: %w", err)
+	}
+
+	var resp struct {
+		Data []batchResult `json:"data"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, nil, fmt.Errorf("unmarshal batch: %w", err)
+	}
+	if len(resp.Data) != len(actions) {
+		return nil, nil, errors.New("batch response does not match the number of actions")
+	}
+
+	return data, resp.Data, nil
+}
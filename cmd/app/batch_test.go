@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/marintailor/asana-resource-exporter/internal"
+)
+
+func TestAppFetchGIDs(t *testing.T) {
+	tests := []struct {
+		name      string
+		gids      int
+		missing   string
+		strict    bool
+		wantCalls int
+		wantCount int
+		wantErr   bool
+	}{
+		{
+			name:      "single batch",
+			gids:      3,
+			wantCalls: 1,
+			wantCount: 3,
+		},
+		{
+			name:      "split into batches of ten",
+			gids:      23,
+			wantCalls: 3,
+			wantCount: 23,
+		},
+		{
+			name:      "missing resource is skipped",
+			gids:      3,
+			missing:   "2",
+			wantCalls: 1,
+			wantCount: 2,
+		},
+		{
+			name:      "missing resource fails in strict mode",
+			gids:      3,
+			missing:   "2",
+			strict:    true,
+			wantCalls: 1,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				if r.Method != http.MethodPost || r.URL.Path != "/batch" {
+					t.Errorf("request = %s %s, want POST /batch", r.Method, r.URL.Path)
+				}
+
+				var req struct {
+					Data struct {
+						Actions []batchAction `json:"actions"`
+					} `json:"data"`
+				}
+				if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+					t.Fatalf("Failed to decode batch request: %v", err)
+				}
+				if len(req.Data.Actions) > maxBatchActions {
+					t.Errorf("batch has %d actions, want at most %d", len(req.Data.Actions), maxBatchActions)
+				}
+
+				var results []string
+				for _, action := range req.Data.Actions {
+					if action.Options == nil || strings.Join(action.Options.Fields, ",") != "name,notes" {
+						t.Errorf("action options = %+v, want fields name,notes", action.Options)
+					}
+					gid := strings.TrimPrefix(action.RelativePath, "/projects/")
+					if gid == tt.missing {
+						results = append(results, `{"status_code": 404, "body": {"errors": [{"message": "Unknown object"}]}}`)
+						continue
+					}
+					results = append(results, fmt.Sprintf(`{"status_code": 200, "body": {"data": {"gid": %q, "name": "p%s"}}}`, gid, gid))
+				}
+				_, _ = fmt.Fprintf(w, `{"data": [%s]}`, strings.Join(results, ","))
+			}))
+			defer server.Close()
+
+			var gids []string
+			for i := 1; i <= tt.gids; i++ {
+				gids = append(gids, fmt.Sprint(i))
+			}
+
+			client, _ := internal.NewClient("token", 600)
+			app := &app{
+				cfg: &config{
+					entrypoint: server.URL,
+					resource:   "project",
+					rate:       600,
+					gidList:    gids,
+					optFields:  []string{"name", "notes"},
+					strict:     tt.strict,
+				},
+				log:    slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+				client: client,
+			}
+
+			count := 0
+			var order []string
+			err := app.fetchGIDs(context.Background(), app.cfg.dataDir, func(data []byte) error {
+				var page struct {
+					Data []Resource `json:"data"`
+				}
+				if err := json.Unmarshal(data, &page); err != nil {
+					return err
+				}
+				for _, rc := range page.Data {
+					order = append(order, rc.GID)
+				}
+				count += len(page.Data)
+				return nil
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("fetchGIDs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("batch requests = %d, want %d", calls, tt.wantCalls)
+			}
+			if tt.wantErr {
+				return
+			}
+			if count != tt.wantCount {
+				t.Errorf("fetchGIDs() returned %d resources, want %d", count, tt.wantCount)
+			}
+			if len(order) > 0 && order[0] != "1" {
+				t.Errorf("first resource = %q, want %q", order[0], "1")
+			}
+		})
+	}
+}
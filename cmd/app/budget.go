@@ -0,0 +1,83 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync/atomic"
+)
+
+// errBudgetExceeded is returned by writes that would exceed max-total-bytes.
+var errBudgetExceeded = errors.New("max total bytes reached")
+
+// byteBudget tracks the bytes written to export files during a run against
+// the max-total-bytes limit. A nil budget is unlimited.
+type byteBudget struct {
+	max  int64        // Maximum bytes written per run
+	used atomic.Int64 // Bytes written so far
+}
+
+// newByteBudget returns a budget of max bytes, or nil if max is zero.
+func newByteBudget(max int64) *byteBudget {
+	if max == 0 {
+		return nil
+	}
+	return &byteBudget{max: max}
+}
+
+// take reserves n bytes about to be written, or returns errBudgetExceeded if
+// they would exceed the budget. Concurrent writers cannot overshoot it, as the
+// bytes are only reserved if no other writer took any in the meantime.
+func (b *byteBudget) take(n int) error {
+	if b == nil {
+		return nil
+	}
+	for {
+		used := b.used.Load()
+		if used+int64(n) > b.max {
+			return errBudgetExceeded
+		}
+		if b.used.CompareAndSwap(used, used+int64(n)) {
+			return nil
+		}
+	}
+}
+
+// check returns errBudgetExceeded once the budget is used up, for writers
+// that only learn their size while writing.
+func (b *byteBudget) check() error {
+	if b == nil || b.used.Load() < b.max {
+		return nil
+	}
+	return errBudgetExceeded
+}
+
+// budgetWriter adds the bytes written through it to a budget, so compressed
+// output is accounted for at its size on disk.
+type budgetWriter struct {
+	io.Writer
+	b *byteBudget
+}
+
+// Write writes p and adds the bytes written to the budget.
+func (w budgetWriter) Write(p []byte) (int, error) {
+	n, err := w.Writer.Write(p)
+	w.b.used.Add(int64(n))
+	return n, err
+}
+
+// truncateOnBudget marks the run truncated if err stems from the byte budget
+// being used up, which is tolerated unless strict mode is enabled.
+func (a *app) truncateOnBudget(err error, sum *summary) error {
+	if !errors.Is(err, errBudgetExceeded) {
+		return err
+	}
+
+	sum.truncated = true
+	a.log.Warn("max total bytes reached, export stopped",
+		slog.Int64("max_total_bytes", a.cfg.maxTotalBytes),
+		slog.Int64("written_bytes", a.budget.used.Load()))
+
+	return a.degrade(fmt.Errorf("export truncated: %w", err))
+}
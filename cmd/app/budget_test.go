@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/marintailor/asana-resource-exporter/internal"
+)
+
+func TestAppRunExportMaxTotalBytes(t *testing.T) {
+	// Every resource is stored as a line of the same length.
+	record := `{"gid":"1","name":"A","resource_type":"project"}` + "\n"
+
+	tests := []struct {
+		name          string
+		maxTotalBytes int64
+		strict        bool
+		wantFiles     int
+		wantErr       bool
+	}{
+		{"unlimited", 0, false, 3, false},
+		{"within budget", int64(3 * len(record)), false, 3, false},
+		{"truncated", int64(3*len(record) - 1), false, 2, false},
+		{"truncated in strict mode", int64(3*len(record) - 1), true, 2, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"data": [
+					{"gid":"1","name":"A","resource_type":"project"},
+					{"gid":"2","name":"B","resource_type":"project"},
+					{"gid":"3","name":"C","resource_type":"project"}
+				], "next_page": null}`))
+			}))
+			defer server.Close()
+
+			var logs strings.Builder
+			dataDir := t.TempDir()
+			client, _ := internal.NewClient("token", 600)
+			app := &app{
+				cfg: &config{
+					entrypoint:    server.URL,
+					resource:      "project",
+					rate:          600,
+					pageSize:      defaultPageSize,
+					dataDir:       dataDir,
+					emptyName:     defaultEmptyName,
+					outputFormat:  formatJSON,
+					newline:       true,
+					maxTotalBytes: tt.maxTotalBytes,
+					strict:        tt.strict,
+				},
+				log:    slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{})),
+				client: client,
+			}
+
+			err := app.runExport(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("runExport() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, errBudgetExceeded) {
+				t.Errorf("runExport() error = %v, want %v", err, errBudgetExceeded)
+			}
+
+			files, err := os.ReadDir(filepath.Join(dataDir, "project"))
+			if err != nil {
+				t.Fatalf("Failed to read resource directory: %v", err)
+			}
+			if len(files) != tt.wantFiles {
+				t.Errorf("Expected %d files, got %d", tt.wantFiles, len(files))
+			}
+
+			truncated := strings.Contains(logs.String(), `"truncated":true`)
+			if truncated != (tt.wantFiles < 3) {
+				t.Errorf("Summary truncated = %v, want %v", truncated, tt.wantFiles < 3)
+			}
+		})
+	}
+}
+
+func TestBudgetWriter(t *testing.T) {
+	b := newByteBudget(10)
+	w := budgetWriter{Writer: io.Discard, b: b}
+
+	if _, err := w.Write([]byte("0123456789")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := b.check(); !errors.Is(err, errBudgetExceeded) {
+		t.Errorf("check() error = %v, want %v", err, errBudgetExceeded)
+	}
+}
+
+func TestAppRunExportMaxTotalBytesChecksums(t *testing.T) {
+	record := `{"gid":"1","name":"A","resource_type":"project"}` + "\n"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data": [
+			{"gid":"1","name":"A","resource_type":"project"},
+			{"gid":"2","name":"B","resource_type":"project"},
+			{"gid":"3","name":"C","resource_type":"project"}
+		], "next_page": null}`))
+	}))
+	defer server.Close()
+
+	var logs strings.Builder
+	client, _ := internal.NewClient("token", 600)
+	app := &app{
+		cfg: &config{
+			entrypoint:    server.URL,
+			resource:      "project",
+			rate:          600,
+			pageSize:      defaultPageSize,
+			dataDir:       t.TempDir(),
+			emptyName:     defaultEmptyName,
+			outputFormat:  formatJSONL,
+			flushEachLine: true,
+			checksums:     true,
+			maxTotalBytes: int64(len(record)),
+		},
+		log:    slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{})),
+		client: client,
+	}
+
+	if err := app.runExport(context.Background()); err != nil {
+		t.Fatalf("runExport() error = %v", err)
+	}
+	if !strings.Contains(logs.String(), `"truncated":true`) {
+		t.Errorf("Expected the checksummed NDJSON output to count against the budget, got %s", logs.String())
+	}
+}
+
+func TestByteBudgetTakeConcurrent(t *testing.T) {
+	b := newByteBudget(100)
+
+	var wg sync.WaitGroup
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = b.take(3)
+		}()
+	}
+	wg.Wait()
+
+	// 33 writers fit; the others must not overshoot the budget.
+	if used := b.used.Load(); used != 99 {
+		t.Errorf("Expected 99 bytes taken, got %d", used)
+	}
+}
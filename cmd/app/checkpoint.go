@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// checkpointFileName is the name of the pagination checkpoint file stored in
+// each resource directory.
+const checkpointFileName = ".checkpoint.json"
+
+// checkpoint records pagination progress for a resource export so that an
+// interrupted export can resume at the next page instead of starting over.
+type checkpoint struct {
+	Resource  string    `json:"resource"`   // Resource type being exported
+	Offset    string    `json:"offset"`     // Offset token of the next page to fetch
+	Pages     int       `json:"pages"`      // Number of pages already exported
+	Count     int       `json:"count"`      // Number of resources already exported
+	UpdatedAt time.Time `json:"updated_at"` // Time the checkpoint was last saved
+}
+
+// advance records a completed page holding n resources and the offset of the
+// page that follows it.
+func (c *checkpoint) advance(offset string, n int) {
+	c.Offset = offset
+	c.Pages++
+	c.Count += n
+	c.UpdatedAt = time.Now()
+}
+
+// checkpointPath returns the checkpoint file path for the configured resource.
+func (a *app) checkpointPath() string {
+	return filepath.Join(a.cfg.dataDir, a.cfg.resource, checkpointFileName)
+}
+
+// loadCheckpoint reads the saved checkpoint for the configured resource.
+// It returns nil without error if no checkpoint exists, and ignores a
+// checkpoint saved for a different resource type.
+func (a *app) loadCheckpoint() (*checkpoint, error) {
+	data, err := os.ReadFile(a.checkpointPath())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read file: %w", err)
+	}
+
+	var cp checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, fmt.Errorf("unmarshal checkpoint: %w", err)
+	}
+
+	if cp.Resource != a.cfg.resource || cp.Offset == "" {
+		a.log.Warn("ignoring unusable checkpoint", slog.String("path", a.checkpointPath()))
+		return nil, nil
+	}
+
+	return &cp, nil
+}
+
+// saveCheckpoint persists cp, replacing any previous checkpoint atomically.
+func (a *app) saveCheckpoint(cp checkpoint) error {
+	if err := a.resourceDir(filepath.Dir(a.checkpointPath())); err != nil {
+		return fmt.Errorf("resource directory: %w", err)
+	}
+
+	path, err := a.dataPath(a.checkpointPath())
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return fmt.Errorf("marshal checkpoint: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("write file: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("rename file: %w", err)
+	}
+
+	a.log.Debug("checkpoint saved", slog.Int("pages", cp.Pages), slog.Int("count", cp.Count))
+	return nil
+}
+
+// removeCheckpoint deletes the checkpoint once an export has completed.
+func (a *app) removeCheckpoint() error {
+	if err := os.Remove(a.checkpointPath()); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
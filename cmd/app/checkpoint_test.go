@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/marintailor/asana-resource-exporter/internal"
+)
+
+func TestAppCheckpointRoundTrip(t *testing.T) {
+	app := &app{
+		cfg: &config{
+			resource: "project",
+			dataDir:  t.TempDir(),
+		},
+		log: slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+	}
+
+	cp, err := app.loadCheckpoint()
+	if err != nil || cp != nil {
+		t.Fatalf("loadCheckpoint() = %v, %v, want nil, nil", cp, err)
+	}
+
+	saved := checkpoint{Resource: "project"}
+	saved.advance("offset-2", 100)
+	saved.advance("offset-3", 100)
+	if err := app.saveCheckpoint(saved); err != nil {
+		t.Fatalf("saveCheckpoint() error = %v", err)
+	}
+
+	cp, err = app.loadCheckpoint()
+	if err != nil {
+		t.Fatalf("loadCheckpoint() error = %v", err)
+	}
+	if cp.Offset != "offset-3" || cp.Pages != 2 || cp.Count != 200 {
+		t.Errorf("loadCheckpoint() = %+v, want offset-3, 2 pages, 200 resources", cp)
+	}
+
+	app.cfg.resource = "user"
+	if cp, err := app.loadCheckpoint(); err != nil || cp != nil {
+		t.Errorf("loadCheckpoint() for other resource = %v, %v, want nil, nil", cp, err)
+	}
+	app.cfg.resource = "project"
+
+	if err := app.removeCheckpoint(); err != nil {
+		t.Fatalf("removeCheckpoint() error = %v", err)
+	}
+	if _, err := os.Stat(app.checkpointPath()); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("checkpoint file still exists after removeCheckpoint()")
+	}
+}
+
+func TestAppFetchDataResume(t *testing.T) {
+	tests := []struct {
+		name        string
+		rejectSaved bool
+		wantOffsets []string
+		wantPages   int
+	}{
+		{
+			name:        "resume from saved offset",
+			rejectSaved: false,
+			wantOffsets: []string{"page-3", "page-4"},
+			wantPages:   2,
+		},
+		{
+			name:        "restart when offset rejected",
+			rejectSaved: true,
+			wantOffsets: []string{"page-3", "", "page-2", "page-3", "page-4"},
+			wantPages:   4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var offsets []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				offset := r.URL.Query().Get("offset")
+				offsets = append(offsets, offset)
+				switch offset {
+				case "":
+					_, _ = w.Write([]byte(`{"data": [{"gid": "1"}], "next_page": {"offset": "page-2"}}`))
+				case "page-2":
+					_, _ = w.Write([]byte(`{"data": [{"gid": "2"}], "next_page": {"offset": "page-3"}}`))
+				case "page-3":
+					if tt.rejectSaved && len(offsets) == 1 {
+						w.WriteHeader(http.StatusBadRequest)
+						return
+					}
+					_, _ = w.Write([]byte(`{"data": [{"gid": "3"}], "next_page": {"offset": "page-4"}}`))
+				default:
+					_, _ = w.Write([]byte(`{"data": [{"gid": "4"}], "next_page": null}`))
+				}
+			}))
+			defer server.Close()
+
+			client, _ := internal.NewClient("token", 600)
+			app := &app{
+				cfg: &config{
+					entrypoint: server.URL,
+					resource:   "project",
+					rate:       600,
+					pageSize:   defaultPageSize,
+					dataDir:    t.TempDir(),
+					resume:     true,
+				},
+				log:    slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+				client: client,
+			}
+
+			saved := checkpoint{Resource: "project"}
+			saved.advance("page-2", 1)
+			saved.advance("page-3", 1)
+			if err := app.saveCheckpoint(saved); err != nil {
+				t.Fatalf("saveCheckpoint() error = %v", err)
+			}
+
+			pages := 0
+			err := app.fetchData(context.Background(), app.cfg.dataDir, func(data []byte) error {
+				pages++
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("fetchData() error = %v", err)
+			}
+
+			if strings.Join(offsets, ",") != strings.Join(tt.wantOffsets, ",") {
+				t.Errorf("requested offsets = %q, want %q", offsets, tt.wantOffsets)
+			}
+			if pages != tt.wantPages {
+				t.Errorf("fetchData() handled %d pages, want %d", pages, tt.wantPages)
+			}
+			if _, err := os.Stat(filepath.Join(app.cfg.dataDir, "project", checkpointFileName)); !errors.Is(err, os.ErrNotExist) {
+				t.Error("checkpoint file not removed after completed export")
+			}
+		})
+	}
+}
+
+func TestAppFetchDataCheckpointAfterHandledPage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("offset") {
+		case "":
+			_, _ = w.Write([]byte(`{"data": [{"gid": "1"}, {"gid": "2"}], "next_page": {"offset": "page-2"}}`))
+		default:
+			_, _ = w.Write([]byte(`{"data": [{"gid": "3"}], "next_page": null}`))
+		}
+	}))
+	defer server.Close()
+
+	client, _ := internal.NewClient("token", 600)
+	app := &app{
+		cfg: &config{
+			entrypoint: server.URL,
+			resource:   "project",
+			rate:       600,
+			pageSize:   defaultPageSize,
+			dataDir:    t.TempDir(),
+			resume:     true,
+		},
+		log:    slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+		client: client,
+	}
+
+	errInterrupted := errors.New("interrupted")
+	pages := 0
+	err := app.fetchData(context.Background(), app.cfg.dataDir, func(data []byte) error {
+		pages++
+		if pages == 2 {
+			return errInterrupted
+		}
+		return nil
+	})
+	if !errors.Is(err, errInterrupted) {
+		t.Fatalf("fetchData() error = %v, want %v", err, errInterrupted)
+	}
+
+	cp, err := app.loadCheckpoint()
+	if err != nil {
+		t.Fatalf("loadCheckpoint() error = %v", err)
+	}
+	if cp == nil || cp.Offset != "page-2" || cp.Pages != 1 || cp.Count != 2 {
+		t.Errorf("loadCheckpoint() = %+v, want offset page-2, 1 page, 2 resources", cp)
+	}
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"path/filepath"
+)
+
+// checksumSuffix is appended to the name of an export file to name its
+// sidecar checksum file.
+const checksumSuffix = ".sha256"
+
+// writeChecksum writes the sidecar checksum file of the export file at path,
+// holding its SHA-256 sum in the format of sha256sum, so the file can be
+// verified with `sha256sum -c`.
+func (a *app) writeChecksum(ctx context.Context, path string, sum []byte) error {
+	line := hex.EncodeToString(sum) + "  " + filepath.Base(path) + "\n"
+	sidecar := path + checksumSuffix
+
+	return a.retryWrite(ctx, sidecar, func() error { return writeFile(sidecar, []byte(line)) })
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/marintailor/asana-resource-exporter/internal"
+)
+
+func TestAppRunExportSidecarChecksums(t *testing.T) {
+	tests := []struct {
+		name         string
+		outputFormat string
+		dedupContent bool
+		wantFiles    int
+	}{
+		{"json", formatJSON, false, 2},
+		{"json with dedup content", formatJSON, true, 2},
+		{"jsonl.gz", formatJSONLGzip, false, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"data": [
+					{"gid": "1", "name": "Alpha", "resource_type": "project"},
+					{"gid": "2", "name": "Beta", "resource_type": "project"}
+				], "next_page": null}`))
+			}))
+			defer server.Close()
+
+			dataDir := t.TempDir()
+			client, _ := internal.NewClient("token", 600)
+			app := &app{
+				cfg: &config{
+					entrypoint:   server.URL,
+					resource:     "project",
+					rate:         600,
+					pageSize:     defaultPageSize,
+					dataDir:      dataDir,
+					emptyName:    defaultEmptyName,
+					outputFormat: tt.outputFormat,
+					dedupContent: tt.dedupContent,
+					checksums:    true,
+				},
+				log:    slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+				client: client,
+			}
+
+			if err := app.runExport(context.Background()); err != nil {
+				t.Fatalf("runExport() error = %v", err)
+			}
+
+			rcDir := filepath.Join(dataDir, "project")
+			files, err := filepath.Glob(filepath.Join(rcDir, "project_*"))
+			if err != nil {
+				t.Fatalf("Failed to list files: %v", err)
+			}
+
+			var exported int
+			for _, f := range files {
+				if strings.HasSuffix(f, checksumSuffix) {
+					continue
+				}
+				exported++
+
+				content, err := os.ReadFile(f)
+				if err != nil {
+					t.Fatalf("Failed to read %s: %v", f, err)
+				}
+				sidecar, err := os.ReadFile(f + checksumSuffix)
+				if err != nil {
+					t.Fatalf("Failed to read sidecar of %s: %v", f, err)
+				}
+
+				sum := sha256.Sum256(content)
+				want := hex.EncodeToString(sum[:]) + "  " + filepath.Base(f) + "\n"
+				if string(sidecar) != want {
+					t.Errorf("Sidecar of %s = %q, want %q", f, sidecar, want)
+				}
+			}
+			if exported != tt.wantFiles || len(files) != 2*tt.wantFiles {
+				t.Errorf("Expected %d files with sidecars, got %d of %d files", tt.wantFiles, exported, len(files))
+			}
+		})
+	}
+}
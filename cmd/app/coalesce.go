@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"sync"
+)
+
+// flightGroup coalesces concurrent identical page requests, so callers
+// requesting an endpoint that is already in flight, such as a child endpoint
+// shared by overlapping hierarchical exports, wait for that request and share
+// its response instead of spending another request of the rate limit. The
+// zero value is ready to use.
+type flightGroup struct {
+	mu    sync.Mutex             // Guards calls
+	calls map[string]*flightCall // Requests in flight by full request URL
+}
+
+// flightCall is a request in flight and, once done is closed, its result.
+type flightCall struct {
+	done chan struct{} // Closed when the request completed
+	data []byte        // Response body; shared, so callers must not modify it
+	err  error         // Error of the request
+}
+
+// do returns the result of fn for key, calling it only if no call for key is
+// in flight and otherwise waiting for that call's result, reporting whether
+// the result was shared. The call is bound to the context of the caller
+// making it; a waiting caller whose ctx is done stops waiting, while the call
+// continues for the others.
+func (g *flightGroup) do(ctx context.Context, key string, fn func() ([]byte, error)) ([]byte, bool, error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*flightCall)
+	}
+	if c, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, true, ctx.Err()
+		case <-c.done:
+			return c.data, true, c.err
+		}
+	}
+
+	c := &flightCall{done: make(chan struct{})}
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	c.data, c.err = fn()
+
+	g.mu.Lock()
+	delete(g.calls, key)
+	g.mu.Unlock()
+	close(c.done)
+
+	return c.data, false, c.err
+}
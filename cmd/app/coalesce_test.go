@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/marintailor/asana-resource-exporter/internal"
+)
+
+func TestAppFetchPageCoalesced(t *testing.T) {
+	const callers = 5
+
+	var requests atomic.Int32
+	arrived := make(chan struct{}, callers)
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		arrived <- struct{}{}
+		<-release
+		_, _ = w.Write([]byte(`{"data": [{"gid": "1", "name": "Task", "resource_type": "task"}]}`))
+	}))
+	defer server.Close()
+
+	client, _ := internal.NewClient("token", 600)
+	app := &app{
+		cfg:    &config{entrypoint: server.URL, rate: 600},
+		log:    slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+		client: client,
+	}
+
+	endpoint := server.URL + "/projects/1/tasks?limit=100"
+	var wg sync.WaitGroup
+	results := make(chan string, callers)
+	fetch := func() {
+		defer wg.Done()
+		data, err := app.fetchPage(context.Background(), endpoint)
+		if err != nil {
+			t.Errorf("fetchPage() error = %v", err)
+		}
+		results <- string(data)
+	}
+
+	// The first request reaches the server before the other callers start,
+	// which then find it in flight for as long as the server holds it.
+	wg.Add(1)
+	go fetch()
+	select {
+	case <-arrived:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the first request")
+	}
+	for range callers - 1 {
+		wg.Add(1)
+		go fetch()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(results)
+
+	if got := requests.Load(); got != 1 {
+		t.Errorf("Expected 1 request, got %d", got)
+	}
+	for data := range results {
+		if data != `{"data": [{"gid": "1", "name": "Task", "resource_type": "task"}]}` {
+			t.Errorf("Expected the shared response, got %s", data)
+		}
+	}
+
+	// Once completed, the endpoint is requested again.
+	if _, err := app.fetchPage(context.Background(), endpoint); err != nil {
+		t.Fatalf("fetchPage() error = %v", err)
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("Expected 2 requests, got %d", got)
+	}
+}
+
+func TestFlightGroupDoCanceled(t *testing.T) {
+	var g flightGroup
+	release := make(chan struct{})
+	started := make(chan struct{})
+	go func() {
+		_, _, _ = g.do(context.Background(), "key", func() ([]byte, error) {
+			close(started)
+			<-release
+			return []byte("data"), nil
+		})
+	}()
+	<-started
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, shared, err := g.do(ctx, "key", func() ([]byte, error) { return nil, nil }); err != context.Canceled || !shared {
+		t.Errorf("do() = shared %v, error %v, want shared context.Canceled", shared, err)
+	}
+	close(release)
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// stdinConfig is the config path that reads the configuration from stdin.
+const stdinConfig = "-"
+
+// applyConfigFile reads a JSON object from the config file at path, or from
+// stdin when path is "-", and applies its entries to flags. Keys are flag
+// names without the leading dash, and values are strings, numbers, or
+// booleans parsed like the corresponding flag value. Flags set on the command
+// line take precedence over the config file, which takes precedence over the
+// defaults. Unknown keys and non-scalar values are rejected.
+func applyConfigFile(flags *flag.FlagSet, path string, stdin io.Reader) error {
+	var data []byte
+	var err error
+	if path == stdinConfig {
+		data, err = io.ReadAll(stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return fmt.Errorf("read config: %w", err)
+	}
+
+	var entries map[string]json.RawMessage
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return fmt.Errorf("unmarshal config: %w", err)
+	}
+
+	set := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if name == "config" || flags.Lookup(name) == nil {
+			return fmt.Errorf("unknown config key %q", name)
+		}
+		if set[name] {
+			continue
+		}
+
+		value, err := configValue(entries[name])
+		if err != nil {
+			return fmt.Errorf("config key %q: %w", name, err)
+		}
+		if err := flags.Set(name, value); err != nil {
+			return fmt.Errorf("config key %q: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// configValue converts a scalar JSON value to its flag string form.
+func configValue(raw json.RawMessage) (string, error) {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s, nil
+	}
+
+	raw = bytes.TrimSpace(raw)
+	switch {
+	case len(raw) == 0, raw[0] == '{', raw[0] == '[', string(raw) == "null":
+		return "", fmt.Errorf("value must be a string, number, or boolean")
+	}
+
+	return string(raw), nil
+}
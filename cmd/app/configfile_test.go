@@ -0,0 +1,107 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestApplyConfigFile(t *testing.T) {
+	tests := []struct {
+		name         string
+		args         []string
+		config       string
+		wantResource string
+		wantRate     int
+		wantStrict   bool
+		wantErr      bool
+	}{
+		{
+			name:         "values applied",
+			config:       `{"resource": "task", "rate": 60, "strict": true}`,
+			wantResource: "task",
+			wantRate:     60,
+			wantStrict:   true,
+		},
+		{
+			name:         "command line takes precedence",
+			args:         []string{"-rate", "30"},
+			config:       `{"resource": "task", "rate": 60}`,
+			wantResource: "task",
+			wantRate:     30,
+		},
+		{
+			name:         "numbers as strings",
+			config:       `{"rate": "45"}`,
+			wantResource: "",
+			wantRate:     45,
+		},
+		{
+			name:    "unknown key",
+			config:  `{"resource": "task", "colour": "red"}`,
+			wantErr: true,
+		},
+		{
+			name:    "nested value",
+			config:  `{"resource": {"type": "task"}}`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid value",
+			config:  `{"rate": "fast"}`,
+			wantErr: true,
+		},
+		{
+			name:    "not an object",
+			config:  `["resource"]`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var resource string
+			var rate int
+			var strict bool
+			flags := flag.NewFlagSet("test", flag.ContinueOnError)
+			flags.SetOutput(io.Discard)
+			flags.StringVar(&resource, "resource", "", "")
+			flags.IntVar(&rate, "rate", defaultRateLimit, "")
+			flags.BoolVar(&strict, "strict", false, "")
+			if err := flags.Parse(tt.args); err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			err := applyConfigFile(flags, stdinConfig, strings.NewReader(tt.config))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("applyConfigFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if resource != tt.wantResource || rate != tt.wantRate || strict != tt.wantStrict {
+				t.Errorf("applyConfigFile() resource = %q, rate = %d, strict = %v, want %q, %d, %v",
+					resource, rate, strict, tt.wantResource, tt.wantRate, tt.wantStrict)
+			}
+		})
+	}
+}
+
+func TestNewOptionsConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"resource": "user", "page-size": 50, "data-dir": "/exports"}`), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	opts, err := newOptions([]string{"cmd", "-config", path, "-data-dir", "custom"})
+	if err != nil {
+		t.Fatalf("newOptions() error = %v", err)
+	}
+	if opts.cfg.resource != "user" || opts.cfg.pageSize != 50 || opts.cfg.dataDir != "custom" {
+		t.Errorf("newOptions() resource = %q, page size = %d, data dir = %q, want %q, %d, %q",
+			opts.cfg.resource, opts.cfg.pageSize, opts.cfg.dataDir, "user", 50, "custom")
+	}
+}
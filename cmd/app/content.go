@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+)
+
+// contentDirName is the directory, in the resource directory of the data
+// directory, holding the unique contents of exported files by hash.
+const contentDirName = "_content"
+
+// storeContent stores rc like storeResource, but writes its encoding only
+// once, to a file in the content directory named after its SHA-256 hash, and
+// links filename to that file. A hard link is used where possible, so the
+// export file is indistinguishable from a regular one, and a relative
+// symbolic link otherwise. The content directory is shared by every run,
+// including runs with -dir-per-run, so unchanged resources never take up
+// space twice.
+func (a *app) storeContent(ctx context.Context, rc Resource, filename string) error {
+	data, err := a.encodeFile(rc)
+	if err != nil {
+		a.recordError(rc.GID, phaseEncode, err)
+		if err := a.degrade(fmt.Errorf("resource %s skipped: %w", rc.GID, err)); err != nil {
+			return err
+		}
+		return nil
+	}
+
+	sum := sha256.Sum256(data)
+	blob, err := a.dataPath(filepath.Join(a.cfg.dataDir, a.cfg.resource, contentDirName, hex.EncodeToString(sum[:])+".json"))
+	if err != nil {
+		return err
+	}
+
+	_, err = os.Stat(blob)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		if err := a.budget.take(len(data)); err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(blob), os.FileMode(permissions)); err != nil {
+			return fmt.Errorf("make dir: %w", err)
+		}
+		if err := a.retryWrite(ctx, blob, func() error { return writeFileAtomic(blob, data) }); err != nil {
+			return err
+		}
+	case err != nil:
+		return fmt.Errorf("stat content: %w", err)
+	default:
+		a.log.Debug("content already stored", slog.String("gid", rc.GID), slog.String("content", blob))
+	}
+
+	cleanPath, err := a.dataPath(filename)
+	if err != nil {
+		return err
+	}
+
+	// Writing through an existing link would change the shared content, so
+	// the file is replaced instead.
+	if err := os.Remove(cleanPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("remove file: %w", err)
+	}
+	if err := os.Link(blob, cleanPath); err != nil {
+		target, rerr := filepath.Rel(filepath.Dir(cleanPath), blob)
+		if rerr != nil {
+			return fmt.Errorf("link content: %w", errors.Join(err, rerr))
+		}
+		if serr := os.Symlink(target, cleanPath); serr != nil {
+			return fmt.Errorf("link content: %w", errors.Join(err, serr))
+		}
+	}
+	if a.cfg.checksums {
+		if err := a.writeChecksum(ctx, cleanPath, sum[:]); err != nil {
+			return fmt.Errorf("write checksum: %w", err)
+		}
+	}
+	a.log.Debug("resource stored", slog.String("content", blob))
+
+	return nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/marintailor/asana-resource-exporter/internal"
+)
+
+func TestAppRunExportDedupContent(t *testing.T) {
+	tests := []struct {
+		name         string
+		outputFormat string
+		wantContent  int
+	}{
+		{"json", formatJSON, 2},
+		{"jsonl", formatJSONL, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"data": [
+					{"gid": "1", "name": "Alpha", "resource_type": "project"},
+					{"gid": "2", "name": "Beta", "resource_type": "project"}
+				], "next_page": null}`))
+			}))
+			defer server.Close()
+
+			dataDir := t.TempDir()
+			client, _ := internal.NewClient("token", 600)
+			app := &app{
+				cfg: &config{
+					entrypoint:   server.URL,
+					resource:     "project",
+					rate:         600,
+					pageSize:     defaultPageSize,
+					dataDir:      dataDir,
+					emptyName:    defaultEmptyName,
+					outputFormat: tt.outputFormat,
+					dirPerRun:    true,
+					dedupContent: true,
+				},
+				log:    slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+				client: client,
+			}
+
+			for i := range 2 {
+				if err := app.runExport(context.Background()); err != nil {
+					t.Fatalf("runExport() error = %v", err)
+				}
+				// Both runs may start within the same second and share a run
+				// directory name.
+				dirs, _ := filepath.Glob(filepath.Join(dataDir, runDirPrefix+"2*"))
+				for _, d := range dirs {
+					if err := os.Rename(d, filepath.Join(dataDir, fmt.Sprintf("%s%d", runDirPrefix, i))); err != nil {
+						t.Fatalf("Failed to rename run directory: %v", err)
+					}
+				}
+			}
+
+			content, err := os.ReadDir(filepath.Join(dataDir, "project", contentDirName))
+			if err != nil && !os.IsNotExist(err) {
+				t.Fatalf("Failed to read content directory: %v", err)
+			}
+			if len(content) != tt.wantContent {
+				t.Fatalf("Expected %d content files, got %d", tt.wantContent, len(content))
+			}
+			if tt.wantContent == 0 {
+				return
+			}
+
+			files, err := filepath.Glob(filepath.Join(dataDir, runDirPrefix+"*", "project", "project_*.json"))
+			if err != nil {
+				t.Fatalf("Failed to list export files: %v", err)
+			}
+			if len(files) != 4 {
+				t.Fatalf("Expected 4 export files across runs, got %d", len(files))
+			}
+
+			for _, f := range files {
+				info, err := os.Stat(f)
+				if err != nil {
+					t.Fatalf("Failed to stat %s: %v", f, err)
+				}
+				var shared bool
+				for _, c := range content {
+					cinfo, err := c.Info()
+					if err != nil {
+						t.Fatalf("Failed to stat content: %v", err)
+					}
+					shared = shared || os.SameFile(info, cinfo)
+				}
+				if !shared {
+					t.Errorf("File %s is not linked to stored content", f)
+				}
+			}
+		})
+	}
+}
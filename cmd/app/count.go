@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// countFields is the opt_fields set requested in count-only mode when no
+// filter expression needs the resource fields, keeping response pages small.
+var countFields = []string{"gid"}
+
+// countPage tallies the resources of a page into sum without writing any files,
+// applying the filter expression if one is configured.
+func (a *app) countPage(ctx context.Context, data []byte, sum *summary) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	resources, err := a.resources(data)
+	if err != nil {
+		return fmt.Errorf("retrieve resources: %w", err)
+	}
+
+	for _, rc := range resources {
+		if a.cfg.namePrefix != "" && !a.matchesPrefix(rc) {
+			sum.filtered++
+			continue
+		}
+		if a.cfg.filter != nil {
+			ok, err := a.cfg.filter.match(rc)
+			if err != nil {
+				return fmt.Errorf("filter resource: %w", err)
+			}
+			if !ok {
+				sum.filtered++
+				continue
+			}
+		}
+		sum.counted++
+	}
+	sum.pages++
+	a.progress.update(sum)
+
+	return nil
+}
+
+// writeCounts writes the resource count of a count-only run to the configured
+// count output file as a JSON object keyed by resource type.
+func (a *app) writeCounts(sum *summary) error {
+	out := struct {
+		Counts    map[string]int `json:"counts"`
+		CountedAt time.Time      `json:"counted_at"`
+	}{
+		Counts:    map[string]int{sum.resource: sum.counted},
+		CountedAt: time.Now().UTC(),
+	}
+
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal counts: %w", err)
+	}
+
+	if err := os.WriteFile(a.cfg.countOutput, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("write file: %w", err)
+	}
+	a.log.Debug("counts stored", slog.String("filename", a.cfg.countOutput))
+
+	return nil
+}
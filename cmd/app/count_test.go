@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/marintailor/asana-resource-exporter/internal"
+)
+
+func TestAppRunCount(t *testing.T) {
+	var optFields []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		optFields = append(optFields, r.URL.Query().Get("opt_fields"))
+		switch r.URL.Query().Get("offset") {
+		case "":
+			_, _ = w.Write([]byte(`{"data": [{"gid": "1"}, {"gid": "2"}], "next_page": {"offset": "next"}}`))
+		default:
+			_, _ = w.Write([]byte(`{"data": [{"gid": "3"}], "next_page": null}`))
+		}
+	}))
+	defer server.Close()
+
+	dataDir := t.TempDir()
+	countOutput := filepath.Join(t.TempDir(), "counts.json")
+	client, _ := internal.NewClient("token", 600)
+	app := &app{
+		cfg: &config{
+			entrypoint:  server.URL,
+			resource:    "project",
+			rate:        600,
+			pageSize:    2,
+			dataDir:     dataDir,
+			optFields:   countFields,
+			countOnly:   true,
+			countOutput: countOutput,
+		},
+		log:    slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+		client: client,
+	}
+
+	if err := app.runExport(context.Background()); err != nil {
+		t.Fatalf("runExport() error = %v", err)
+	}
+
+	for _, f := range optFields {
+		if f != "gid" {
+			t.Errorf("opt_fields = %q, want %q", f, "gid")
+		}
+	}
+
+	entries, err := os.ReadDir(dataDir)
+	if err != nil {
+		t.Fatalf("Failed to read data directory: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("Expected no files in data directory, got %d", len(entries))
+	}
+
+	data, err := os.ReadFile(countOutput)
+	if err != nil {
+		t.Fatalf("Failed to read count output: %v", err)
+	}
+	var out struct {
+		Counts map[string]int `json:"counts"`
+	}
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatalf("Failed to decode count output: %v", err)
+	}
+	if out.Counts["project"] != 3 {
+		t.Errorf("count = %d, want 3", out.Counts["project"])
+	}
+}
+
+func TestAppCountPageFilter(t *testing.T) {
+	filter, err := parseFilter("name^=Q3")
+	if err != nil {
+		t.Fatalf("parseFilter() error = %v", err)
+	}
+
+	app := &app{
+		cfg: &config{resource: "project", filter: filter},
+		log: slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+	}
+
+	sum := &summary{resource: "project"}
+	data := []byte(`{"data": [{"gid": "1", "name": "Q3 plan"}, {"gid": "2", "name": "Q4 plan"}]}`)
+	if err := app.countPage(context.Background(), data, sum); err != nil {
+		t.Fatalf("countPage() error = %v", err)
+	}
+	if sum.counted != 1 || sum.filtered != 1 || sum.pages != 1 {
+		t.Errorf("countPage() counted = %d, filtered = %d, pages = %d, want 1, 1, 1", sum.counted, sum.filtered, sum.pages)
+	}
+}
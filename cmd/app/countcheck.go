@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// countFileName is the name of the file storing the resource count of the
+// last run in each resource directory, for -max-drop-percent.
+const countFileName = ".count.json"
+
+// errCountDrop is returned by count checks of runs whose resource count is
+// suspiciously low.
+var errCountDrop = errors.New("resource count dropped")
+
+// lastCount is the resource count of the last run passing the count checks.
+type lastCount struct {
+	Resource  string    `json:"resource"`   // Resource type exported
+	Count     int       `json:"count"`      // Number of resources
+	UpdatedAt time.Time `json:"updated_at"` // End of the run
+}
+
+// countPath returns the count file path for the configured resource.
+func (a *app) countPath() string {
+	return filepath.Join(a.cfg.dataDir, a.cfg.resource, countFileName)
+}
+
+// runCountOf returns the number of resources a run found: those counted in
+// count-only mode, otherwise those written or skipped as unchanged.
+func runCountOf(sum *summary) int {
+	if sum.counted > 0 {
+		return sum.counted
+	}
+	return sum.written + sum.unchanged
+}
+
+// checkCount compares the resource count of a completed run against
+// min-count and, with max-drop-percent, against the count of the last run
+// passing the checks, which is then replaced by this run's. A count below
+// the minimum or dropping by more than the allowed percentage points to an
+// API or configuration problem rather than deleted resources; it is tolerated
+// with a warning unless strict mode is enabled, and the previous count is
+// kept as the baseline. The run is marked suspect either way, so it does not
+// replace the listings and seen set of previous runs. Truncated runs are not
+// checked.
+func (a *app) checkCount(sum *summary) error {
+	if sum.truncated || (a.cfg.minCount == 0 && a.cfg.maxDropPercent == 0) {
+		return nil
+	}
+
+	count := runCountOf(sum)
+	if count < a.cfg.minCount {
+		sum.suspect = true
+		return a.degrade(fmt.Errorf("%w: %d resources, want at least %d", errCountDrop, count, a.cfg.minCount))
+	}
+	if a.cfg.maxDropPercent == 0 {
+		return nil
+	}
+
+	prev, err := a.loadCount()
+	if err != nil {
+		return fmt.Errorf("load count: %w", err)
+	}
+	if prev != nil && prev.Count > 0 {
+		drop := float64(prev.Count-count) * 100 / float64(prev.Count)
+		if drop > a.cfg.maxDropPercent {
+			a.log.Warn("resource count dropped",
+				slog.Int("count", count),
+				slog.Int("previous_count", prev.Count),
+				slog.Float64("drop_percent", drop),
+				slog.Float64("max_drop_percent", a.cfg.maxDropPercent))
+			sum.suspect = true
+			return a.degrade(fmt.Errorf("%w by %.1f%% from %d to %d, more than %g%%", errCountDrop, drop, prev.Count, count, a.cfg.maxDropPercent))
+		}
+	}
+
+	if err := a.saveCount(count); err != nil {
+		return fmt.Errorf("save count: %w", err)
+	}
+
+	return nil
+}
+
+// loadCount reads the count of the last run for the configured resource. It
+// returns nil without error if no count was saved.
+func (a *app) loadCount() (*lastCount, error) {
+	data, err := os.ReadFile(a.countPath())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read file: %w", err)
+	}
+
+	var c lastCount
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("unmarshal count: %w", err)
+	}
+	if c.Resource != a.cfg.resource {
+		return nil, nil
+	}
+
+	return &c, nil
+}
+
+// saveCount persists count as the count of the last run, replacing the
+// previous one atomically.
+func (a *app) saveCount(count int) error {
+	if err := a.resourceDir(filepath.Dir(a.countPath())); err != nil {
+		return fmt.Errorf("resource directory: %w", err)
+	}
+
+	path, err := a.dataPath(a.countPath())
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(lastCount{Resource: a.cfg.resource, Count: count, UpdatedAt: time.Now().UTC()})
+	if err != nil {
+		return fmt.Errorf("marshal count: %w", err)
+	}
+
+	return writeFileAtomic(path, data)
+}
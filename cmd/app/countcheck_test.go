@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/marintailor/asana-resource-exporter/internal"
+)
+
+func TestAppCheckCount(t *testing.T) {
+	tests := []struct {
+		name           string
+		previous       int // Count of the last run; 0 saves none
+		count          int
+		minCount       int
+		maxDropPercent float64
+		strict         bool
+		truncated      bool
+		wantErr        bool
+		wantSaved      int
+	}{
+		{"first run", 0, 100, 0, 20, false, false, false, 100},
+		{"growth", 100, 150, 0, 20, false, false, false, 150},
+		{"drop within limit", 100, 80, 0, 20, false, false, false, 80},
+		{"drop over limit tolerated", 100, 79, 0, 20, false, false, false, 100},
+		{"drop over limit in strict mode", 100, 79, 0, 20, true, false, true, 100},
+		{"drop to zero in strict mode", 100, 0, 0, 20, true, false, true, 100},
+		{"truncated run not checked", 100, 10, 0, 20, true, true, false, 100},
+		{"below minimum in strict mode", 0, 4, 5, 0, true, false, true, 0},
+		{"at minimum", 0, 5, 5, 0, true, false, false, 0},
+		{"at minimum with drop check", 0, 5, 5, 50, true, false, false, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := &app{
+				cfg: &config{
+					resource:       "project",
+					dataDir:        t.TempDir(),
+					minCount:       tt.minCount,
+					maxDropPercent: tt.maxDropPercent,
+					strict:         tt.strict,
+				},
+				log: slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+			}
+			if tt.previous > 0 {
+				if err := app.saveCount(tt.previous); err != nil {
+					t.Fatalf("saveCount() error = %v", err)
+				}
+			}
+
+			err := app.checkCount(&summary{resource: "project", written: tt.count, truncated: tt.truncated})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("checkCount() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, errCountDrop) {
+				t.Errorf("checkCount() error = %v, want %v", err, errCountDrop)
+			}
+
+			saved, err := app.loadCount()
+			if err != nil {
+				t.Fatalf("loadCount() error = %v", err)
+			}
+			got := 0
+			if saved != nil {
+				got = saved.Count
+			}
+			if got != tt.wantSaved {
+				t.Errorf("Saved count = %d, want %d", got, tt.wantSaved)
+			}
+		})
+	}
+}
+
+func TestRunCountOf(t *testing.T) {
+	if got := runCountOf(&summary{written: 3, unchanged: 2, filtered: 7}); got != 5 {
+		t.Errorf("runCountOf() = %d, want 5 written or unchanged", got)
+	}
+	if got := runCountOf(&summary{counted: 9}); got != 9 {
+		t.Errorf("runCountOf() = %d, want 9 counted", got)
+	}
+}
+
+func TestAppRunExportCountCheckKeepsListings(t *testing.T) {
+	tests := []struct {
+		name   string
+		strict bool
+	}{
+		{"tolerated", false},
+		{"strict mode", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"data": [{"gid": "1", "name": "Alpha", "resource_type": "project"}], "next_page": null}`))
+			}))
+			defer server.Close()
+
+			dataDir := t.TempDir()
+			gidsOut := filepath.Join(t.TempDir(), "gids.txt")
+			if err := os.WriteFile(gidsOut, []byte("1\n2\n3\n"), 0600); err != nil {
+				t.Fatalf("Failed to write gids file: %v", err)
+			}
+
+			client, _ := internal.NewClient("token", 600)
+			app := &app{
+				cfg: &config{
+					entrypoint:   server.URL,
+					resource:     "project",
+					rate:         600,
+					pageSize:     defaultPageSize,
+					dataDir:      dataDir,
+					emptyName:    defaultEmptyName,
+					outputFormat: formatJSON,
+					writeIndex:   true,
+					gidsOut:      gidsOut,
+					dedupe:       true,
+					minCount:     3,
+					strict:       tt.strict,
+				},
+				log:    slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+				client: client,
+				seen:   &seenSet{hashes: make(map[string]string)},
+			}
+
+			if err := app.runExport(context.Background()); (err != nil) != tt.strict {
+				t.Fatalf("runExport() error = %v, want error %v", err, tt.strict)
+			}
+
+			if data, _ := os.ReadFile(gidsOut); string(data) != "1\n2\n3\n" {
+				t.Errorf("Expected the gids file to be kept, got %q", data)
+			}
+			for _, name := range []string{indexFileName, seenFileName} {
+				if _, err := os.Stat(filepath.Join(dataDir, "project", name)); !os.IsNotExist(err) {
+					t.Errorf("Expected no %s after a suspect run, got %v", name, err)
+				}
+			}
+		})
+	}
+}
+
+func TestAppRunTickCountCheckRollsBackSeen(t *testing.T) {
+	var ticks atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ticks.Load() == 1 {
+			_, _ = w.Write([]byte(`{"data": [{"gid": "1", "name": "Alpha", "resource_type": "project"}], "next_page": null}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"data": [{"gid": "1", "name": "Alpha", "resource_type": "project"}, {"gid": "2", "name": "Beta", "resource_type": "project"}], "next_page": null}`))
+	}))
+	defer server.Close()
+
+	summaryFile := filepath.Join(t.TempDir(), "summary.json")
+	client, _ := internal.NewClient("token", 600)
+	app := &app{
+		cfg: &config{
+			entrypoint:    server.URL,
+			resource:      "project",
+			rate:          600,
+			pageSize:      defaultPageSize,
+			dataDir:       t.TempDir(),
+			emptyName:     defaultEmptyName,
+			outputFormat:  formatJSON,
+			dedupe:        true,
+			minCount:      2,
+			summaryFile:   summaryFile,
+			summaryFormat: summaryJSON,
+		},
+		log:    slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+		client: client,
+		seen:   &seenSet{hashes: make(map[string]string)},
+	}
+
+	errCh := make(chan error, 2)
+	for range 2 {
+		ticks.Add(1)
+		app.wg.Add(1)
+		app.runTick(context.Background(), errCh)
+	}
+	close(errCh)
+	for err := range errCh {
+		t.Errorf("runTick() error = %v", err)
+	}
+
+	data, err := os.ReadFile(summaryFile)
+	if err != nil {
+		t.Fatalf("Failed to read summary file: %v", err)
+	}
+	var report summaryReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatalf("Failed to decode summary: %v", err)
+	}
+	// The resource of the suspect first tick must not count as unchanged.
+	if report.Counts.Written != 2 || report.Counts.Unchanged != 0 {
+		t.Errorf("Second tick written = %d, unchanged = %d, want 2 and 0",
+			report.Counts.Written, report.Counts.Unchanged)
+	}
+}
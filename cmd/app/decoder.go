@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// Decoder decodes the resources held by a response page, so APIs compatible
+// with Asana but answering in another envelope or format can be exported by
+// adding a decoder rather than changing the export pipeline.
+type Decoder interface {
+	// Decode returns the resources of the response page data.
+	Decode(data []byte) ([]Resource, error)
+}
+
+// Decoders selectable with -decoder.
+const (
+	decoderJSON = "json" // Asana JSON envelope holding resources in a "data" array
+)
+
+// decoders maps the names of the supported decoders to their implementation.
+var decoders = map[string]Decoder{
+	decoderJSON: jsonDecoder{},
+}
+
+// decoderNames returns the names of the supported decoders in sorted order.
+func decoderNames() []string {
+	return slices.Sorted(maps.Keys(decoders))
+}
+
+// decoder returns the decoder configured with -decoder, the JSON decoder if
+// none is set.
+func (a *app) decoder() Decoder {
+	if d, ok := decoders[a.cfg.decoder]; ok {
+		return d
+	}
+	return jsonDecoder{}
+}
+
+// envelope lists the fields of the Asana JSON envelope known to the
+// exporter. Unknown fields are rejected with strict-json.
+type envelope struct {
+	Data     json.RawMessage `json:"data"`      // Resources of the page
+	NextPage json.RawMessage `json:"next_page"` // Next page, read by nextPage
+	Count    json.RawMessage `json:"count"`     // Declared item count, read by verifyCount
+}
+
+// checkEnvelope returns an error if the JSON envelope d holds a top-level
+// field not listed in envelope, which signals a change of the API response
+// schema. The fields of the resources themselves are not checked, as they
+// are exported as returned, including fields unknown to the exporter.
+func checkEnvelope(d []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(d))
+	decoder.DisallowUnknownFields()
+
+	var e envelope
+	if err := decoder.Decode(&e); err != nil {
+		return fmt.Errorf("strict json: %w", err)
+	}
+
+	return nil
+}
+
+// jsonDecoder decodes the JSON envelope of the Asana API. Collection
+// responses wrap resources in a "data" array, while responses for a single
+// resource, such as a lookup by GID, wrap it in a "data" object. Both are
+// normalized to a list of resources, which keep every field of the response,
+// such as resource_subtype, through Resource's raw object.
+type jsonDecoder struct{}
+
+// Decode returns the resources of the data array of d, or the resource of its
+// data object. It returns an error if d is not a JSON object or its data
+// field is neither an array nor an object.
+func (jsonDecoder) Decode(d []byte) ([]Resource, error) {
+	var output struct {
+		Data json.RawMessage `json:"data"`
+	}
+
+	if err := json.Unmarshal(d, &output); err != nil {
+		return nil, fmt.Errorf("unmarshal data: %w", err)
+	}
+
+	data := bytes.TrimSpace(output.Data)
+	switch {
+	case len(data) == 0 || bytes.Equal(data, []byte("null")):
+		return nil, nil
+	case data[0] == '{':
+		var rc Resource
+		if err := json.Unmarshal(data, &rc); err != nil {
+			return nil, fmt.Errorf("unmarshal data: %w", err)
+		}
+		return []Resource{rc}, nil
+	default:
+		var resources []Resource
+		if err := json.Unmarshal(data, &resources); err != nil {
+			return nil, fmt.Errorf("unmarshal data: %w", err)
+		}
+		return resources, nil
+	}
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/marintailor/asana-resource-exporter/internal"
+)
+
+// lineDecoder decodes pages of gid,name lines, standing in for a decoder of a
+// non-JSON API.
+type lineDecoder struct{}
+
+func (lineDecoder) Decode(d []byte) ([]Resource, error) {
+	var resources []Resource
+	for line := range strings.Lines(string(d)) {
+		gid, name, ok := strings.Cut(strings.TrimSpace(line), ",")
+		if !ok {
+			return nil, errors.New("line without name")
+		}
+		resources = append(resources, Resource{GID: gid, Name: name, ResourceType: "project"})
+	}
+
+	return resources, nil
+}
+
+func TestJSONDecoderDecode(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		wantGIDs []string
+		wantErr  bool
+	}{
+		{"resources", `{"data": [{"gid": "1", "name": "Alpha"}, {"gid": "2", "name": "Beta"}]}`, []string{"1", "2"}, false},
+		{"single resource", `{"data": {"gid": "1", "name": "Alpha", "resource_subtype": "milestone"}}`, []string{"1"}, false},
+		{"empty data", `{"data": []}`, nil, false},
+		{"null data", `{"data": null}`, nil, false},
+		{"no data", `{"errors": []}`, nil, false},
+		{"invalid json", `gid,name`, nil, true},
+		{"scalar data", `{"data": "1"}`, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resources, err := jsonDecoder{}.Decode([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Decode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var gids []string
+			for _, rc := range resources {
+				gids = append(gids, rc.GID)
+			}
+			if strings.Join(gids, ",") != strings.Join(tt.wantGIDs, ",") {
+				t.Errorf("Decode() GIDs = %v, want %v", gids, tt.wantGIDs)
+			}
+		})
+	}
+}
+
+func TestJSONDecoderDecodeKeepsFields(t *testing.T) {
+	for _, data := range []string{
+		`{"data": [{"gid": "1", "name": "Alpha", "resource_subtype": "milestone"}]}`,
+		`{"data": {"gid": "1", "name": "Alpha", "resource_subtype": "milestone"}}`,
+	} {
+		resources, err := jsonDecoder{}.Decode([]byte(data))
+		if err != nil {
+			t.Fatalf("Decode() error = %v", err)
+		}
+		if len(resources) != 1 {
+			t.Fatalf("Decode() returned %d resources, want 1", len(resources))
+		}
+		out, err := json.Marshal(resources[0])
+		if err != nil {
+			t.Fatalf("Marshal() error = %v", err)
+		}
+		if !strings.Contains(string(out), `"resource_subtype":"milestone"`) {
+			t.Errorf("Expected resource_subtype to be kept, got %s", out)
+		}
+	}
+}
+
+func TestAppRunExportDecoder(t *testing.T) {
+	decoders["lines"] = lineDecoder{}
+	t.Cleanup(func() { delete(decoders, "lines") })
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("1,Alpha\n2,Beta\n"))
+	}))
+	defer server.Close()
+
+	dataDir := t.TempDir()
+	cfg, err := newConfig(options{cfg: config{
+		entrypoint: server.URL,
+		resource:   "project",
+		workspace:  "12345",
+		rate:       600,
+		pageSize:   defaultPageSize,
+		dataDir:    dataDir,
+		decoder:    "lines",
+	}})
+	if err != nil {
+		t.Fatalf("newConfig() error = %v", err)
+	}
+
+	client, _ := internal.NewClient("token", 600)
+	app := &app{
+		cfg:    cfg,
+		log:    slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+		client: client,
+	}
+
+	// Without a JSON envelope there is no next page, which is tolerated
+	// unless strict.
+	if err := app.runExport(context.Background()); err != nil {
+		t.Fatalf("runExport() error = %v", err)
+	}
+
+	for _, name := range []string{"Alpha", "Beta"} {
+		files, _ := filepath.Glob(filepath.Join(dataDir, "project", "project_"+name+"_*.json"))
+		if len(files) != 1 {
+			t.Errorf("Expected 1 file of %s, got %d", name, len(files))
+		}
+	}
+}
+
+func TestNewConfigDecoder(t *testing.T) {
+	tests := []struct {
+		name        string
+		decoder     string
+		wantDecoder string
+		wantErr     bool
+	}{
+		{"default", "", decoderJSON, false},
+		{"json", decoderJSON, decoderJSON, false},
+		{"unknown", "xml", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := newConfig(options{cfg: config{
+				entrypoint: defaultEntrypoint,
+				resource:   "project",
+				workspace:  "12345",
+				rate:       60,
+				pageSize:   defaultPageSize,
+				decoder:    tt.decoder,
+			}})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && cfg.decoder != tt.wantDecoder {
+				t.Errorf("decoder = %q, want %q", cfg.decoder, tt.wantDecoder)
+			}
+		})
+	}
+}
+
+func TestCheckEnvelope(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{"known fields", `{"data": [{"gid": "1"}], "next_page": {"offset": "a"}, "count": 1}`, false},
+		{"unknown resource field", `{"data": [{"gid": "1", "new_field": true}]}`, false},
+		{"unexpected key", `{"data": [{"gid": "1"}], "next_page": null, "sync": "token"}`, true},
+		{"invalid json", `gid,name`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkEnvelope([]byte(tt.data)); (err != nil) != tt.wantErr {
+				t.Errorf("checkEnvelope() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAppResourcesStrictJSON(t *testing.T) {
+	data := []byte(`{"data": [{"gid": "1", "name": "Alpha"}], "next_page": null, "sync": "token"}`)
+
+	for _, strict := range []bool{false, true} {
+		app := &app{cfg: &config{decoder: decoderJSON, strictJSON: strict}}
+		resources, err := app.resources(data)
+		if (err != nil) != strict {
+			t.Errorf("resources() with strict json %v error = %v", strict, err)
+		}
+		if !strict && len(resources) != 1 {
+			t.Errorf("resources() returned %d resources, want 1", len(resources))
+		}
+	}
+}
+
+func TestNewConfigStrictJSON(t *testing.T) {
+	decoders["lines"] = lineDecoder{}
+	t.Cleanup(func() { delete(decoders, "lines") })
+
+	for decoder, wantErr := range map[string]bool{decoderJSON: false, "lines": true} {
+		_, err := newConfig(options{cfg: config{
+			entrypoint: defaultEntrypoint,
+			resource:   "project",
+			workspace:  "12345",
+			rate:       60,
+			pageSize:   defaultPageSize,
+			decoder:    decoder,
+			strictJSON: true,
+		}})
+		if (err != nil) != wantErr {
+			t.Errorf("newConfig() with decoder %s error = %v, wantErr %v", decoder, err, wantErr)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// seenFileName is the name of the file, stored in each resource directory,
+// that records the resources exported by previous runs.
+const seenFileName = ".seen.json"
+
+// seenSet records the content hash of every exported resource by GID, so
+// later runs can skip resources that have not changed since.
+type seenSet struct {
+	mu     sync.Mutex        // Guards hashes, shared by overlapping interval runs
+	hashes map[string]string // Content hash of the last exported version by GID
+}
+
+// seenPath returns the seen-set file path for the configured resource.
+func (a *app) seenPath() string {
+	return filepath.Join(a.cfg.dataDir, a.cfg.resource, seenFileName)
+}
+
+// loadSeen reads the seen set persisted by previous runs. It returns an empty
+// set if none exists or if reset-dedupe is enabled, in which case the
+// persisted set is replaced when the first run completes.
+func (a *app) loadSeen() (*seenSet, error) {
+	seen := &seenSet{hashes: make(map[string]string)}
+	if a.cfg.resetDedupe {
+		a.log.Info("ignoring resources exported by previous runs", slog.String("path", a.seenPath()))
+		return seen, nil
+	}
+
+	data, err := os.ReadFile(a.seenPath())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return seen, nil
+		}
+		return nil, fmt.Errorf("read file: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &seen.hashes); err != nil {
+		return nil, fmt.Errorf("unmarshal seen set: %w", err)
+	}
+
+	return seen, nil
+}
+
+// saveSeen persists the seen set, replacing the previous file atomically.
+func (a *app) saveSeen() error {
+	if err := a.resourceDir(filepath.Dir(a.seenPath())); err != nil {
+		return fmt.Errorf("resource directory: %w", err)
+	}
+
+	path, err := a.dataPath(a.seenPath())
+	if err != nil {
+		return err
+	}
+
+	a.seen.mu.Lock()
+	data, err := json.Marshal(a.seen.hashes)
+	a.seen.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("marshal seen set: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("write file: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("rename file: %w", err)
+	}
+
+	return nil
+}
+
+// unchanged reports whether the resource with gid was already exported with
+// the same content hash.
+func (s *seenSet) unchanged(gid, hash string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.hashes[gid] == hash
+}
+
+// mark records that the resource with gid was exported with hash. It returns
+// the hash recorded before, empty if there was none, so the mark can be
+// rolled back.
+func (s *seenSet) mark(gid, hash string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prev := s.hashes[gid]
+	s.hashes[gid] = hash
+	return prev
+}
+
+// rollback restores the hashes recorded before the marks of a run, as
+// returned by mark by GID, forgetting GIDs that had none.
+func (s *seenSet) rollback(prev map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for gid, hash := range prev {
+		if hash == "" {
+			delete(s.hashes, gid)
+			continue
+		}
+		s.hashes[gid] = hash
+	}
+}
+
+// contentHash returns the hex-encoded SHA-256 of the exported form of rc.
+func contentHash(rc Resource) (string, error) {
+	data, err := rc.MarshalJSON()
+	if err != nil {
+		return "", fmt.Errorf("marshal resource: %w", err)
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
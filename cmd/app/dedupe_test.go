@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestAppExportDedupe(t *testing.T) {
+	dataDir := t.TempDir()
+	app := &app{
+		cfg: &config{
+			resource: "project",
+			dataDir:  dataDir,
+			dedupe:   true,
+		},
+		log: slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+	}
+
+	runs := []struct {
+		data          string
+		wantWritten   int
+		wantUnchanged int
+	}{
+		{`{"data": [{"gid": "1", "name": "One"}, {"gid": "2", "name": "Two"}]}`, 2, 0},
+		{`{"data": [{"gid": "1", "name": "One"}, {"gid": "2", "name": "Two"}]}`, 0, 2},
+		{`{"data": [{"gid": "1", "name": "One"}, {"gid": "2", "name": "Two v2"}, {"gid": "3", "name": "Three"}]}`, 2, 1},
+	}
+
+	for i, run := range runs {
+		seen, err := app.loadSeen()
+		if err != nil {
+			t.Fatalf("run %d: loadSeen() error = %v", i+1, err)
+		}
+		app.seen = seen
+
+		sum := &summary{}
+		if err := app.export(context.Background(), []byte(run.data), dataDir, app.newSink(dataDir, time.Now()), sum); err != nil {
+			t.Fatalf("run %d: export() error = %v", i+1, err)
+		}
+		if err := app.saveSeen(); err != nil {
+			t.Fatalf("run %d: saveSeen() error = %v", i+1, err)
+		}
+
+		if sum.written != run.wantWritten || sum.unchanged != run.wantUnchanged {
+			t.Errorf("run %d: written = %d, unchanged = %d, want %d, %d",
+				i+1, sum.written, sum.unchanged, run.wantWritten, run.wantUnchanged)
+		}
+	}
+
+	app.cfg.resetDedupe = true
+	seen, err := app.loadSeen()
+	if err != nil {
+		t.Fatalf("loadSeen() error = %v", err)
+	}
+	if len(seen.hashes) != 0 {
+		t.Errorf("loadSeen() with reset returned %d entries, want 0", len(seen.hashes))
+	}
+}
+
+func TestAppLoadSeenCorrupt(t *testing.T) {
+	dataDir := t.TempDir()
+	app := &app{
+		cfg: &config{resource: "project", dataDir: dataDir},
+		log: slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+	}
+
+	if err := app.resourceDir(dataDir + "/project"); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(app.seenPath(), []byte("not json"), 0o600); err != nil {
+		t.Fatalf("Failed to write seen set: %v", err)
+	}
+
+	if _, err := app.loadSeen(); err == nil {
+		t.Error("loadSeen() error = nil, want error for corrupt file")
+	}
+}
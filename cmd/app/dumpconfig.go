@@ -0,0 +1,249 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// redacted replaces secret values in the dumped configuration.
+const redacted = "[REDACTED]"
+
+// effectiveConfig is the JSON representation of the resolved configuration
+// printed by -dump-config. Secrets are redacted.
+type effectiveConfig struct {
+	Token           string   `json:"token"`
+	Entrypoint      string   `json:"entrypoint"`
+	Interval        string   `json:"interval"`
+	StartupJitter   string   `json:"startup_jitter"`
+	ResumeSchedule  bool     `json:"resume_schedule"`
+	Resource        string   `json:"resource"`
+	Alias           string   `json:"alias"`
+	Workspace       string   `json:"workspace"`
+	AutoWorkspaces  bool     `json:"auto_workspaces"`
+	Owner           string   `json:"owner"`
+	Project         string   `json:"project"`
+	Rate            int      `json:"rate"`
+	Warmup          bool     `json:"warmup"`
+	DataDir         string   `json:"data_dir"`
+	ActiveWindow    string   `json:"active_window"`
+	ActiveWindowTZ  string   `json:"active_window_tz"`
+	Timezone        string   `json:"timezone"`
+	CloseIdleConns  bool     `json:"close_idle_conns"`
+	MaxRedirects    int      `json:"max_redirects"`
+	Accept          string   `json:"accept"`
+	Decoder         string   `json:"decoder"`
+	StrictJSON      bool     `json:"strict_json"`
+	DisableHTTP2    bool     `json:"disable_http2"`
+	Fallback        string   `json:"fallback_entrypoint"`
+	FailoverAfter   int      `json:"failover_after"`
+	ThrottleLatency string   `json:"throttle_latency"`
+	ThrottleRecover string   `json:"throttle_recover_latency"`
+	ThrottleFactor  float64  `json:"throttle_rate_factor"`
+	MaxGoroutines   int      `json:"max_goroutines"`
+	IdleConnTimeout string   `json:"idle_conn_timeout"`
+	MaxIdlePerHost  int      `json:"max_idle_conns_per_host"`
+	DNSCacheTTL     string   `json:"dns_cache_ttl"`
+	UnixSocket      string   `json:"unix_socket"`
+	ClientCert      string   `json:"client_cert"`
+	ClientKey       string   `json:"client_key"`
+	Retention       string   `json:"retention"`
+	Dedupe          bool     `json:"dedupe_across_runs"`
+	ResetDedupe     bool     `json:"reset_dedupe"`
+	OutputDirPerRun bool     `json:"output_dir_per_run"`
+	MaxFilenameLen  int      `json:"max_filename_length"`
+	EmptyName       string   `json:"empty_name_placeholder"`
+	WriteDelay      string   `json:"write_delay"`
+	WriteRetries    int      `json:"write_retries"`
+	OutputFormat    string   `json:"output_format"`
+	NoTimestamp     bool     `json:"no_timestamp"`
+	OnCollision     string   `json:"on_collision"`
+	MaxFileSize     int64    `json:"max_file_size"`
+	MaxTotalBytes   int64    `json:"max_total_bytes"`
+	FlushEachLine   bool     `json:"flush_each_line"`
+	WriteIndex      bool     `json:"write_index"`
+	DedupContent    bool     `json:"dedup_content"`
+	Checksums       bool     `json:"sidecar_checksums"`
+	Newline         bool     `json:"trailing_newline"`
+	Partition       bool     `json:"partition_by_modified"`
+	FlattenDir      bool     `json:"flatten_dir"`
+	ExportGraph     bool     `json:"export_graph"`
+	GIDsOut         string   `json:"gids_out"`
+	ErrorFile       string   `json:"error_file"`
+	NetworkRetries  int      `json:"network_retries"`
+	HTTPRetries     int      `json:"http_retries"`
+	RetryStatus     []int    `json:"retry_status"`
+	RetryOnEmpty    int      `json:"retry_on_empty"`
+	PageSize        int      `json:"page_size"`
+	MaxPages        int      `json:"max_pages"`
+	PreserveRaw     bool     `json:"preserve_raw"`
+	Strict          bool     `json:"strict"`
+	VerboseErrors   bool     `json:"verbose_errors"`
+	Resume          bool     `json:"resume"`
+	TracePagination bool     `json:"trace_pagination"`
+	OptPretty       bool     `json:"opt_pretty"`
+	VerifyCount     bool     `json:"verify_count"`
+	CountOnly       bool     `json:"count_only"`
+	CountOutput     string   `json:"count_output"`
+	MinCount        int      `json:"min_count"`
+	MaxDropPercent  float64  `json:"max_drop_percent"`
+	SummaryFormat   string   `json:"summary_format"`
+	ProgressBar     bool     `json:"progress_bar"`
+	SummaryFile     string   `json:"summary_file"`
+	Fields          []string `json:"fields"`
+	GIDs            []string `json:"gids"`
+	Schema          string   `json:"schema"`
+	FilterExpr      string   `json:"filter_expr"`
+	NamePrefix      string   `json:"name_prefix"`
+	ModifiedSince   string   `json:"modified_since"`
+	CompletedSince  string   `json:"completed_since"`
+	Completed       string   `json:"completed"`
+	Since           string   `json:"since"`
+	PostHook        string   `json:"post_hook"`
+	PostHookTimeout string   `json:"post_hook_timeout"`
+	NotifyURL       string   `json:"notify_url"`
+	NotifyFormat    string   `json:"notify_format"`
+	TokenFile       string   `json:"token_file"`
+	Record          string   `json:"record"`
+	Replay          string   `json:"replay"`
+	NoFetch         bool     `json:"no_fetch"`
+	FromRaw         string   `json:"from_raw"`
+	SigningKey      string   `json:"signing_key"`
+	SigningHeader   string   `json:"signing_header"`
+	Logging         struct {
+		Debug        bool   `json:"debug"`
+		Format       string `json:"format"`
+		Output       string `json:"output"`
+		DedupeErrors bool   `json:"dedupe_errors"`
+	} `json:"logging"`
+}
+
+// dumpConfig writes the effective configuration, after environment variable
+// expansion and merging of the fields file, to w as indented JSON. The API
+// tokens loaded from any source, the signing key, and the notify URL, which
+// commonly embeds a secret, are redacted; an unset secret is left empty.
+func (a *app) dumpConfig(w io.Writer) error {
+	cfg := effectiveConfig{
+		Token:           secret(strings.Join(a.tokens, ",")),
+		Entrypoint:      a.cfg.entrypoint,
+		Interval:        a.cfg.interval,
+		StartupJitter:   a.cfg.startupJitter.String(),
+		ResumeSchedule:  a.cfg.resumeSchedule,
+		Resource:        a.cfg.resource,
+		Alias:           a.cfg.alias,
+		Workspace:       a.cfg.workspace,
+		AutoWorkspaces:  a.cfg.autoWorkspaces,
+		Owner:           a.cfg.owner,
+		Project:         a.cfg.project,
+		Rate:            a.cfg.rate,
+		Warmup:          a.cfg.warmup,
+		DataDir:         a.cfg.dataDir,
+		ActiveWindow:    a.cfg.activeWindow,
+		ActiveWindowTZ:  a.cfg.activeWindowTZ,
+		Timezone:        a.cfg.timezone,
+		CloseIdleConns:  a.cfg.closeIdleConns,
+		MaxRedirects:    a.cfg.maxRedirects,
+		Accept:          a.cfg.accept,
+		Decoder:         a.cfg.decoder,
+		StrictJSON:      a.cfg.strictJSON,
+		DisableHTTP2:    a.cfg.disableHTTP2,
+		Fallback:        a.cfg.fallbackEntrypoint,
+		FailoverAfter:   a.cfg.failoverAfter,
+		ThrottleLatency: a.cfg.throttleLatency.String(),
+		ThrottleRecover: a.cfg.throttleRecover.String(),
+		ThrottleFactor:  a.cfg.throttleFactor,
+		MaxGoroutines:   a.cfg.maxGoroutines,
+		IdleConnTimeout: a.cfg.idleConnTimeout.String(),
+		MaxIdlePerHost:  a.cfg.maxIdleConnsPerHost,
+		DNSCacheTTL:     a.cfg.dnsCacheTTL.String(),
+		UnixSocket:      a.cfg.unixSocket,
+		ClientCert:      a.cfg.clientCert,
+		ClientKey:       a.cfg.clientKey,
+		Retention:       a.cfg.retention,
+		Dedupe:          a.cfg.dedupe,
+		ResetDedupe:     a.cfg.resetDedupe,
+		OutputDirPerRun: a.cfg.dirPerRun,
+		MaxFilenameLen:  a.cfg.maxFilenameLength,
+		EmptyName:       a.cfg.emptyName,
+		WriteDelay:      a.cfg.writeDelay.String(),
+		WriteRetries:    a.cfg.writeRetries,
+		OutputFormat:    a.cfg.outputFormat,
+		NoTimestamp:     a.cfg.noTimestamp,
+		OnCollision:     a.cfg.onCollision,
+		MaxFileSize:     a.cfg.maxFileSize,
+		MaxTotalBytes:   a.cfg.maxTotalBytes,
+		FlushEachLine:   a.cfg.flushEachLine,
+		WriteIndex:      a.cfg.writeIndex,
+		DedupContent:    a.cfg.dedupContent,
+		Checksums:       a.cfg.checksums,
+		Newline:         a.cfg.newline,
+		Partition:       a.cfg.partition,
+		FlattenDir:      a.cfg.flattenDir,
+		ExportGraph:     a.cfg.exportGraph,
+		GIDsOut:         a.cfg.gidsOut,
+		ErrorFile:       a.cfg.errorFile,
+		NetworkRetries:  a.cfg.networkRetries,
+		HTTPRetries:     a.cfg.httpRetries,
+		RetryStatus:     slices.Sorted(maps.Keys(a.cfg.retryStatuses)),
+		RetryOnEmpty:    a.cfg.retryOnEmpty,
+		PageSize:        a.cfg.pageSize,
+		MaxPages:        a.cfg.maxPages,
+		PreserveRaw:     a.cfg.preserveRaw,
+		Strict:          a.cfg.strict,
+		VerboseErrors:   a.cfg.verboseErrs,
+		Resume:          a.cfg.resume,
+		TracePagination: a.cfg.tracePagination,
+		OptPretty:       a.cfg.optPretty,
+		VerifyCount:     a.cfg.verifyCount,
+		CountOnly:       a.cfg.countOnly,
+		CountOutput:     a.cfg.countOutput,
+		MinCount:        a.cfg.minCount,
+		MaxDropPercent:  a.cfg.maxDropPercent,
+		SummaryFormat:   a.cfg.summaryFormat,
+		ProgressBar:     a.cfg.progressBar,
+		SummaryFile:     a.cfg.summaryFile,
+		Fields:          a.cfg.optFields,
+		GIDs:            a.cfg.gidList,
+		Schema:          a.cfg.schemaFile,
+		FilterExpr:      a.cfg.filterExpr,
+		NamePrefix:      a.cfg.namePrefix,
+		ModifiedSince:   a.cfg.modifiedSince,
+		CompletedSince:  a.cfg.completedSince,
+		Completed:       a.cfg.completed,
+		Since:           a.cfg.since,
+		PostHook:        a.cfg.postHook,
+		PostHookTimeout: a.cfg.postHookTimeout.String(),
+		NotifyURL:       secret(a.cfg.notifyURL),
+		NotifyFormat:    a.cfg.notifyFormat,
+		TokenFile:       a.cfg.tokenFile,
+		Record:          a.cfg.record,
+		Replay:          a.cfg.replay,
+		NoFetch:         a.cfg.noFetch,
+		FromRaw:         a.cfg.fromRaw,
+		SigningKey:      secret(a.cfg.signingKey),
+		SigningHeader:   a.cfg.signingHeader,
+	}
+	cfg.Logging.Debug = a.logging.debug
+	cfg.Logging.Format = a.logging.format
+	cfg.Logging.Output = a.logging.output
+	cfg.Logging.DedupeErrors = a.logging.dedupeErrors
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(cfg); err != nil {
+		return fmt.Errorf("encode config: %w", err)
+	}
+
+	return nil
+}
+
+// secret returns the redaction marker for a non-empty secret value.
+func secret(s string) string {
+	if s == "" {
+		return ""
+	}
+	return redacted
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// Phases of the export a resource failed in, recorded in the error file.
+const (
+	phaseFetch  = "fetch"  // The resource could not be fetched
+	phaseEncode = "encode" // The resource could not be encoded for storage
+	phaseStore  = "store"  // The resource could not be written
+)
+
+// errorRecord is a line of the error file, describing a resource that failed
+// to be exported.
+type errorRecord struct {
+	Time         time.Time `json:"time"`          // Time of the failure in UTC
+	ResourceType string    `json:"resource_type"` // Configured resource type
+	GID          string    `json:"gid,omitempty"` // GID of the resource, if known
+	Phase        string    `json:"phase"`         // Phase the resource failed in
+	Error        string    `json:"error"`         // Error message
+}
+
+// recordError appends a record of the resource with gid, which failed in
+// phase with err, to the error file, if one is configured. Every record is a
+// single JSON line written with one append, so records of a run reach the file
+// as they occur, survive a run that fails later, and accumulate across runs.
+// A failure to write the record is logged rather than failing the export.
+func (a *app) recordError(gid, phase string, err error) {
+	if a.cfg.errorFile == "" {
+		return
+	}
+
+	line, merr := json.Marshal(errorRecord{
+		Time:         time.Now().UTC(),
+		ResourceType: a.cfg.resource,
+		GID:          gid,
+		Phase:        phase,
+		Error:        err.Error(),
+	})
+	if merr == nil {
+		merr = appendLine(a.cfg.errorFile, append(line, '\n'))
+	}
+	if merr != nil {
+		a.log.Error("record error", slog.String("filename", a.cfg.errorFile), slog.String("error", merr.Error()))
+	}
+}
+
+// appendLine appends line to the file at path, creating it if needed.
+func appendLine(path string, line []byte) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("open file: %w", err)
+	}
+
+	_, err = file.Write(line)
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+
+	return err
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/marintailor/asana-resource-exporter/internal"
+)
+
+// failingSink fails every write, like a full or read-only file system.
+type failingSink struct {
+	sink
+}
+
+func (s *failingSink) write(ctx context.Context, rc Resource) (string, error) {
+	return "", errors.New("no space left on device")
+}
+
+// readErrorFile returns the records of the error file at path.
+func readErrorFile(t *testing.T, path string) []errorRecord {
+	t.Helper()
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open error file: %v", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	var records []errorRecord
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var rec errorRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			t.Fatalf("Failed to decode error record %q: %v", scanner.Text(), err)
+		}
+		records = append(records, rec)
+	}
+
+	return records
+}
+
+func TestAppRunExportErrorFile(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data": [
+			{"status_code": 200, "body": {"data": {"gid": "1", "name": "Alpha", "resource_type": "project"}}},
+			{"status_code": 404, "body": {"errors": [{"message": "Unknown object: 2"}]}}
+		]}`))
+	}))
+	defer server.Close()
+
+	dataDir := t.TempDir()
+	errorFile := filepath.Join(t.TempDir(), "errors.jsonl")
+	client, _ := internal.NewClient("token", 600)
+	app := &app{
+		cfg: &config{
+			entrypoint:   server.URL,
+			resource:     "project",
+			rate:         600,
+			dataDir:      dataDir,
+			emptyName:    defaultEmptyName,
+			outputFormat: formatJSON,
+			gidList:      []string{"1", "2"},
+			errorFile:    errorFile,
+		},
+		log:    slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+		client: client,
+	}
+
+	// Records of every run are appended.
+	for range 2 {
+		if err := app.runExport(context.Background()); err != nil {
+			t.Fatalf("runExport() error = %v", err)
+		}
+	}
+
+	records := readErrorFile(t, errorFile)
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 error records, got %d", len(records))
+	}
+	for _, rec := range records {
+		if rec.GID != "2" || rec.Phase != phaseFetch || rec.ResourceType != "project" {
+			t.Errorf("Unexpected error record %+v", rec)
+		}
+		if rec.Error != "resource 2: status 404: Unknown object: 2" {
+			t.Errorf("Error = %q, want the batch error", rec.Error)
+		}
+		if rec.Time.IsZero() {
+			t.Error("Expected the record to carry the time of the failure")
+		}
+	}
+}
+
+func TestAppExportErrorFileStoreFailure(t *testing.T) {
+	dataDir := t.TempDir()
+	errorFile := filepath.Join(t.TempDir(), "errors.jsonl")
+	app := &app{
+		cfg: &config{
+			resource:     "project",
+			dataDir:      dataDir,
+			emptyName:    defaultEmptyName,
+			outputFormat: formatJSON,
+			errorFile:    errorFile,
+		},
+		log: slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+	}
+
+	page := []byte(`{"data": [{"gid": "1", "name": "Alpha", "resource_type": "project"}]}`)
+	out := &failingSink{sink: app.newSink(dataDir, time.Now())}
+	if err := app.export(context.Background(), page, dataDir, out, &summary{}); err == nil {
+		t.Fatal("export() error = nil, want the write error")
+	}
+
+	// The record is written even though the export failed.
+	records := readErrorFile(t, errorFile)
+	if len(records) != 1 || records[0].GID != "1" || records[0].Phase != phaseStore {
+		t.Errorf("Unexpected error records %+v", records)
+	}
+}
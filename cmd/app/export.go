@@ -1,19 +1,35 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"syscall"
 	"time"
+	"unicode/utf8"
+
+	"github.com/marintailor/asana-resource-exporter/internal"
 )
 
+var errEmptyResult = errors.New("empty resource list")
+
+// errMaxPages is returned by listings stopped after max-pages pages.
+var errMaxPages = errors.New("max pages reached")
+
+// emptyPage is the response envelope of a page holding no resources.
+const emptyPage = `{"data": [], "next_page": null}`
+
 // Resource represents an Asana resource with core identifying properties.
 // All Asana resources share these common fields which provide the minimal
 // information needed for export and tracking. The GID is guaranteed to be
@@ -22,13 +38,45 @@ type Resource struct {
 	GID          string `json:"gid"`           // Global unique identifier from Asana
 	Name         string `json:"name"`          // Human-readable resource name
 	ResourceType string `json:"resource_type"` // Resource category (project, task, user, etc.)
+
+	raw json.RawMessage // Complete object as returned by the API, including opt_fields
+}
+
+// UnmarshalJSON decodes the core fields and keeps the complete object, so
+// fields requested through opt_fields are preserved on export.
+func (r *Resource) UnmarshalJSON(b []byte) error {
+	type plain Resource
+	var p plain
+	if err := json.Unmarshal(b, &p); err != nil {
+		return err
+	}
+
+	*r = Resource(p)
+	r.raw = append(json.RawMessage(nil), b...)
+
+	return nil
+}
+
+// MarshalJSON encodes the complete object as returned by the API, falling
+// back to the core fields for resources not decoded from an API response.
+func (r Resource) MarshalJSON() ([]byte, error) {
+	if len(r.raw) > 0 {
+		return r.raw, nil
+	}
+
+	type plain Resource
+	return json.Marshal(plain(r))
 }
 
 // export fetches resources from Asana and persists them to the filesystem.
-// It processes each resource sequentially and creates timestamped JSON files.
+// It processes each resource sequentially and writes it to out,
+// skipping resources that do not match the configured filter expression or
+// are unchanged since a previous run with dedupe enabled, and quarantining
+// resources that do not match the configured schema. With write-delay, it
+// pauses between writes to pace slow, e.g. networked, file systems.
 // The operation can be cancelled via context. Returns error if the export fails
 // or is cancelled.
-func (a *app) export(ctx context.Context, data []byte, dir string) error {
+func (a *app) export(ctx context.Context, data []byte, dir string, out sink, sum *summary) error {
 	if ctx.Err() != nil {
 		return ctx.Err()
 	}
@@ -38,7 +86,7 @@ func (a *app) export(ctx context.Context, data []byte, dir string) error {
 		return fmt.Errorf("retrieve resources: %w", err)
 	}
 
-	rcDir := dir + "/" + a.cfg.resource
+	rcDir := a.exportDir(dir)
 	if err := a.resourceDir(rcDir); err != nil {
 		return fmt.Errorf("resource directory: %w", err)
 	}
@@ -48,35 +96,428 @@ func (a *app) export(ctx context.Context, data []byte, dir string) error {
 		case <-ctx.Done():
 			return ctx.Err()
 		default:
-			filename := fmt.Sprintf("%s/%s_%s_%s.json", rcDir, a.cfg.resource, rc.Name, time.Now().Format("20060102150405"))
-			if err := a.storeResource(rc, filename); err != nil {
+			if a.cfg.namePrefix != "" && !a.matchesPrefix(rc) {
+				sum.filtered++
+				continue
+			}
+
+			if a.cfg.filter != nil {
+				ok, err := a.cfg.filter.match(rc)
+				if err != nil {
+					return fmt.Errorf("filter resource: %w", err)
+				}
+				if !ok {
+					sum.filtered++
+					continue
+				}
+			}
+
+			if a.cfg.schema != nil {
+				valid, err := a.validateResource(ctx, rc, rcDir)
+				if err != nil {
+					return err
+				}
+				if !valid {
+					sum.invalid++
+					continue
+				}
+				sum.valid++
+			}
+
+			var hash string
+			if a.seen != nil {
+				if hash, err = contentHash(rc); err != nil {
+					return err
+				}
+				if a.seen.unchanged(rc.GID, hash) {
+					sum.unchanged++
+					continue
+				}
+			}
+
+			if a.cfg.writeDelay > 0 && sum.written > 0 {
+				if err := sleep(ctx, a.cfg.writeDelay); err != nil {
+					return err
+				}
+			}
+
+			done := a.trackWrite()
+			filename, err := out.write(ctx, rc)
+			done()
+			if err != nil {
+				if !errors.Is(err, errBudgetExceeded) {
+					a.recordError(rc.GID, phaseStore, err)
+				}
 				return fmt.Errorf("store resource: %w", err)
 			}
+			sum.written++
+			sum.addFile(filename)
+
+			if a.seen != nil {
+				prev := a.seen.mark(rc.GID, hash)
+				if _, ok := sum.seenPrev[rc.GID]; !ok {
+					if sum.seenPrev == nil {
+						sum.seenPrev = make(map[string]string)
+					}
+					sum.seenPrev[rc.GID] = prev
+				}
+			}
 		}
 	}
 
+	sum.pages++
+	a.progress.update(sum)
 	a.log.Debug("finished iterating resources")
 
 	return nil
 }
 
-// fetchData retrieves resources from the Asana API with rate limit handling.
-// When receiving a 429 response, it automatically retries using the Retry-After
-// header or falls back to default backoff. The operation respects context
-// cancellation.
-func (a *app) fetchData(ctx context.Context) ([]byte, error) {
+// resourceName returns the name component of the file name of rc: its name,
+// or the configured placeholder, with {gid} replaced by the GID, when the
+// name is empty or blank.
+func (a *app) resourceName(rc Resource) string {
+	if strings.TrimSpace(rc.Name) != "" {
+		return rc.Name
+	}
+
+	return strings.ReplaceAll(a.cfg.emptyName, gidPlaceholder, rc.GID)
+}
+
+// resourceFilename returns the file name of an exported resource:
+// {resource_type}_{name}_{timestamp}.json, or {resource_type}_{name}.json
+// with no-timestamp. When the name exceeds the configured maximum length in
+// bytes, the name component is truncated at a UTF-8 boundary so the resource
+// type, timestamp, and extension are kept.
+func (a *app) resourceFilename(name string, now time.Time) string {
+	return a.taggedFilename(name, "", now)
+}
+
+// taggedFilename returns the file name of an exported resource like
+// resourceFilename, with tag, if not empty, appended to the name component:
+// {resource_type}_{name}_{tag}_{timestamp}.json. The tag is kept when the name
+// is truncated.
+func (a *app) taggedFilename(name, tag string, now time.Time) string {
+	prefix := a.cfg.resource + "_"
+	suffix := ".json"
+	if !a.cfg.noTimestamp {
+		suffix = "_" + a.timestamp(now) + suffix
+	}
+	if tag != "" {
+		suffix = "_" + tag + suffix
+	}
+
+	if limit := a.cfg.maxFilenameLength; limit > 0 && len(prefix)+len(name)+len(suffix) > limit {
+		room := max(limit-len(prefix)-len(suffix), 0)
+		for room > 0 && !utf8.RuneStart(name[room]) {
+			room--
+		}
+		name = name[:room]
+	}
+
+	return prefix + name + suffix
+}
+
+// fetch is the fetch phase of an export: it retrieves the configured
+// resources with the fetcher and returns every response page, in the order
+// received and as returned by the API, without decoding them into resources.
+// Pages are verified, stored raw, and checkpointed as they are fetched; see
+// fetchData. On error, the pages fetched so far are returned with it. As all
+// pages are held in memory, runs stream pages into store instead; fetch
+// serves callers needing the complete listing.
+func (a *app) fetch(ctx context.Context, dir string) ([][]byte, error) {
+	var pages [][]byte
+	err := a.fetcher()(ctx, dir, func(data []byte) error {
+		pages = append(pages, data)
+		return nil
+	})
+	return pages, err
+}
+
+// store is the store phase of an export: it exports the resources of pages
+// in order to out, writing under dir and counting them in sum; see export.
+// It stops at the first page failing to be stored and returns its error.
+// The sink is left open, so store can be called once per page as pages are
+// fetched; closing it is up to the caller.
+func (a *app) store(ctx context.Context, pages [][]byte, dir string, out sink, sum *summary) error {
+	for _, data := range pages {
+		if err := a.export(ctx, data, dir, out, sum); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fetchData retrieves all pages of resources from the Asana API, following
+// the next_page offset until the API reports no further pages. Each page is
+// passed to handle as soon as it is fetched, so pages are processed in order
+// without being held in memory. When retry-on-empty is configured, an empty
+// first page is retried with exponential backoff before being accepted. When
+// preserve-raw is enabled, each page is also stored unmodified under the _raw
+// directory. When resume is enabled, progress is checkpointed after each
+// handled page and an existing checkpoint is resumed from. Raw pages are
+// stored under dir, while the checkpoint always lives in the data directory.
+// The operation respects context cancellation. Resources nested in a parent
+// are listed within the configured parent.
+func (a *app) fetchData(ctx context.Context, dir string, handle func(data []byte) error) error {
+	return a.fetchPages(ctx, dir, a.cfg.project, handle)
+}
+
+// fetchPages implements fetchData for the resources within the parent with
+// the given GID, or for all resources if the type has no parent.
+func (a *app) fetchPages(ctx context.Context, dir, parent string, handle func(data []byte) error) error {
 	a.log.Debug("fetch data")
+
+	cp := checkpoint{Resource: a.cfg.resource}
+	if a.cfg.resume {
+		saved, err := a.loadCheckpoint()
+		if err != nil {
+			return fmt.Errorf("load checkpoint: %w", err)
+		}
+		if saved != nil {
+			a.log.Info("resuming export from checkpoint",
+				slog.Int("pages", saved.Pages),
+				slog.Int("count", saved.Count))
+			cp = *saved
+		}
+	}
+
+	runTime := time.Now()
+	filters := a.filters(runTime)
+	emptyRetries := 0
+	failures := 0
+	pages := 0
+	for {
+		data, err := a.fetchPage(ctx, a.pageEndpoint(filters, parent, cp.Offset))
+		if err != nil {
+			retry, ferr := a.failover(ctx, err, &failures)
+			if ferr != nil {
+				return ferr
+			}
+			if retry {
+				continue
+			}
+
+			var apiErr *internal.APIError
+			if pages == 0 && cp.Offset != "" && errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusBadRequest {
+				a.log.Warn("checkpoint offset rejected, restarting export",
+					slog.String("error", err.Error()))
+				cp = checkpoint{Resource: a.cfg.resource}
+				continue
+			}
+			return err
+		}
+		failures = 0
+
+		if pages == 0 && cp.Offset == "" && emptyRetries < a.cfg.retryOnEmpty && a.emptyData(data) {
+			wait := emptyRetryDelay << emptyRetries
+			emptyRetries++
+			a.log.Warn("empty resource list, retrying",
+				slog.Int("attempt", emptyRetries),
+				slog.Int("max_attempts", a.cfg.retryOnEmpty),
+				slog.String("retry_after", wait.String()))
+
+			if err := sleep(ctx, wait); err != nil {
+				return err
+			}
+			continue
+		}
+
+		pages++
+
+		if a.cfg.preserveRaw {
+			if err := a.storeRaw(data, dir, runTime, cp.Pages+1); err != nil {
+				return fmt.Errorf("store raw response: %w", err)
+			}
+		}
+
+		if a.cfg.verifyCount {
+			if err := a.verifyCount(data); err != nil {
+				if err := a.degrade(fmt.Errorf("page %d: %w", cp.Pages+1, err)); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := handle(data); err != nil {
+			return err
+		}
+
+		next, err := a.nextPage(data)
+		if err != nil {
+			if err := a.degrade(fmt.Errorf("incomplete pagination after page %d: %w", cp.Pages+1, err)); err != nil {
+				return err
+			}
+		}
+		if a.cfg.tracePagination {
+			a.tracePage(cp.Pages+1, next)
+		}
+
+		var offset string
+		if next != nil {
+			offset = next.Offset
+		}
+
+		if offset == "" {
+			a.log.Debug("finished fetching pages", slog.Int("pages", cp.Pages+1))
+			if a.cfg.resume {
+				if err := a.removeCheckpoint(); err != nil {
+					return fmt.Errorf("remove checkpoint: %w", err)
+				}
+			}
+			if cp.Pages == 0 && a.emptyData(data) {
+				if err := a.degrade(errEmptyResult); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		cp.advance(offset, a.count(data))
+		if a.cfg.resume {
+			if err := a.saveCheckpoint(cp); err != nil {
+				return fmt.Errorf("save checkpoint: %w", err)
+			}
+		}
+
+		if a.cfg.maxPages > 0 && pages >= a.cfg.maxPages {
+			return errMaxPages
+		}
+	}
+}
+
+// count returns the number of resources in a response page, or zero if the
+// page cannot be decoded.
+func (a *app) count(d []byte) int {
+	resources, err := a.resources(d)
+	if err != nil {
+		return 0
+	}
+	return len(resources)
+}
+
+// filters returns the query filters for a run starting at now. A relative
+// since duration is resolved against now, so each interval run exports a
+// sliding window. completed_since is passed through as configured, as the
+// API resolves "now" itself.
+func (a *app) filters(now time.Time) url.Values {
+	filters := url.Values{}
+
+	switch {
+	case a.cfg.modifiedSince != "":
+		filters.Set("modified_since", a.cfg.modifiedSince)
+	case a.cfg.sinceDuration > 0:
+		filters.Set("modified_since", now.Add(-a.cfg.sinceDuration).UTC().Format(time.RFC3339))
+	}
+	switch {
+	case a.cfg.completedSince != "":
+		filters.Set("completed_since", a.cfg.completedSince)
+	case a.cfg.completed == completedFalse:
+		filters.Set("completed_since", completedSinceNow)
+	}
+
+	return filters
+}
+
+// pageEndpoint builds the collection endpoint for the configured resource,
+// nested in the parent with the given GID if the type has a parent, or in the
+// workspace for types listed per workspace. Its query holds the page size,
+// the workspace parameter if configured for other types without a parent,
+// the owner if the resource type requires one, the requested opt_fields,
+// opt_pretty if enabled, the run filters and, when continuing pagination,
+// the offset token. Every page carries the same parameters besides the
+// offset, and each value is encoded exactly once.
+func (a *app) pageEndpoint(filters url.Values, parent, offset string) string {
+	rt := resourceTypes[a.cfg.resource]
+	path := rt.path
+	switch {
+	case rt.parent != "":
+		path = resourceTypes[rt.parent].path + "/" + url.PathEscape(parent) + "/" + rt.path
+	case rt.inWorkspace:
+		path = resourceTypes["workspace"].path + "/" + url.PathEscape(a.cfg.workspace) + "/" + rt.path
+	}
+
+	query := url.Values{}
+	for key, values := range filters {
+		query[key] = values
+	}
+	query.Set("limit", strconv.Itoa(a.cfg.pageSize))
+	if a.cfg.workspace != "" && rt.parent == "" && !rt.inWorkspace {
+		query.Set("workspace", a.cfg.workspace)
+	}
+	if rt.requiresOwner {
+		query.Set("owner", a.cfg.owner)
+	}
+	if len(a.cfg.optFields) > 0 {
+		query.Set("opt_fields", strings.Join(a.cfg.optFields, ","))
+	}
+	if a.cfg.optPretty {
+		query.Set("opt_pretty", "true")
+	}
+	if offset != "" {
+		query.Set("offset", offset)
+	}
+
+	return a.entrypoint() + "/" + path + "?" + query.Encode()
+}
+
+// fetchPage retrieves a single page from endpoint with rate limit handling.
+// Concurrent requests of the same endpoint share a single request. An empty
+// response body, such as that of a 204 No Content response, is treated as a
+// page holding zero resources.
+func (a *app) fetchPage(ctx context.Context, endpoint string) ([]byte, error) {
+	data, shared, err := a.flights.do(ctx, endpoint, func() ([]byte, error) {
+		return a.call(ctx, http.MethodGet, endpoint, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if shared {
+		a.log.Debug("shared response of in-flight request", slog.String("endpoint", a.client.Redact(endpoint)))
+	}
+
+	if len(bytes.TrimSpace(data)) == 0 {
+		a.log.Debug("empty response body, treating as zero resources")
+		return []byte(emptyPage), nil
+	}
+
+	return data, nil
+}
+
+// call sends a request to endpoint and returns the response body. A non-nil
+// body is sent as JSON with a POST request. When receiving a 429 response, it
+// automatically retries using the Retry-After header or falls back to default
+// backoff. Requests failing without a response, such as on DNS failures or
+// connection resets, are retried up to the configured network retries, unless
+// retrying cannot help, as for an invalid endpoint or too many redirects, and
+// 5xx responses and those with a -retry-status status up to the configured
+// HTTP retries, each with exponential backoff. The operation respects context
+// cancellation.
+func (a *app) call(ctx context.Context, method, endpoint string, body []byte) ([]byte, error) {
+	var networkRetries, httpRetries int
 	for {
 		if err := ctx.Err(); err != nil {
 			return nil, err
 		}
 
-		endpoint := fmt.Sprintf("%s/%ss", a.cfg.entrypoint, a.cfg.resource)
-		resp, err := a.client.Request(ctx, endpoint, nil)
+		var resp *http.Response
+		var err error
+		switch method {
+		case http.MethodPost:
+			resp, err = a.client.PostJSON(ctx, endpoint, bytes.NewReader(body))
+		default:
+			resp, err = a.client.Request(ctx, endpoint, nil)
+		}
 		if err != nil {
 			if ctx.Err() != nil {
 				return nil, ctx.Err()
 			}
+			if networkRetries < a.cfg.networkRetries && !permanentRequestError(err) {
+				networkRetries++
+				if err := a.backoff(ctx, err.Error(), networkRetries, a.cfg.networkRetries); err != nil {
+					return nil, err
+				}
+				continue
+			}
 			return nil, fmt.Errorf("make request: %w", err)
 		}
 
@@ -90,24 +531,47 @@ func (a *app) fetchData(ctx context.Context) ([]byte, error) {
 
 		a.log.Debug("check response status code")
 		if resp.StatusCode == http.StatusTooManyRequests {
-			ra := resp.Header.Get("Retry-After")
-			if ra != "" {
-				wait := a.retryAfter(ra)
-				a.log.Warn("too many requests",
-					slog.String("retry_after", wait.String()),
-					slog.Int("default_wait", defaultRetryAfter))
-
-				timer := time.NewTimer(wait)
-				select {
-				case <-ctx.Done():
-					timer.Stop()
-					return nil, ctx.Err()
-				case <-timer.C:
-					continue
-				}
+			ra, source := resp.Header.Get("Retry-After"), "header"
+			if ra == "" {
+				ra, source = a.retryAfterHint(resp.Body), "body"
+			}
+			if ra == "" {
+				source = "default"
+			}
+
+			wait := a.retryAfter(ra, resp.Header.Get("Date"))
+			a.log.Warn("too many requests",
+				slog.String("retry_after", wait.String()),
+				slog.String("source", source),
+				slog.Int("default_wait", defaultRetryAfter))
+
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return nil, ctx.Err()
+			case <-timer.C:
+				continue
 			}
 		}
 
+		if (resp.StatusCode >= 500 || a.cfg.retryStatuses[resp.StatusCode]) && httpRetries < a.cfg.httpRetries {
+			_ = resp.Body.Close()
+			httpRetries++
+			if err := a.backoff(ctx, http.StatusText(resp.StatusCode), httpRetries, a.cfg.httpRetries); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			apiErr := &internal.APIError{StatusCode: resp.StatusCode, Endpoint: endpoint}
+			if a.cfg.verboseErrs {
+				apiErr.Body = a.errorBody(resp.Body)
+			}
+			return nil, apiErr
+		}
+
 		a.log.Debug("read response body")
 		data, err := io.ReadAll(resp.Body)
 		if err != nil {
@@ -119,6 +583,208 @@ func (a *app) fetchData(ctx context.Context) ([]byte, error) {
 	}
 }
 
+// backoff logs a failed attempt and waits before retry attempt of
+// maxAttempts, doubling the wait with every attempt up to maxRetryDelay.
+func (a *app) backoff(ctx context.Context, reason string, attempt, maxAttempts int) error {
+	wait := backoffDelay(retryDelay, maxRetryDelay, attempt)
+	a.log.Warn("request failed, retrying",
+		slog.String("reason", a.client.Redact(reason)),
+		slog.Int("attempt", attempt),
+		slog.Int("max_attempts", maxAttempts),
+		slog.String("retry_after", wait.String()))
+
+	return sleep(ctx, wait)
+}
+
+// backoffDelay returns the wait before retry attempt: base, doubled for every
+// attempt after the first, and capped at limit. It doubles step by step, so
+// large attempts cannot overflow into a negative duration.
+func backoffDelay(base, limit time.Duration, attempt int) time.Duration {
+	wait := base
+	for i := 1; i < attempt && wait < limit; i++ {
+		wait *= 2
+	}
+	return min(wait, limit)
+}
+
+// permanentRequestError reports whether err is a request failure without a
+// response that retrying cannot resolve, as it stems from the request itself
+// rather than the network: an invalid endpoint or a redirect loop.
+func permanentRequestError(err error) bool {
+	return errors.Is(err, internal.ErrInvalidEndpoint) || errors.Is(err, internal.ErrTooManyRedirects)
+}
+
+// errorBody reads the body of an error response for verbose errors. It is
+// capped at maxErrorBodySize bytes and has the API token redacted.
+func (a *app) errorBody(r io.Reader) string {
+	data, err := io.ReadAll(io.LimitReader(r, int64(maxErrorBodySize)+1))
+	if err != nil {
+		a.log.Debug("read error response body", slog.String("error", err.Error()))
+	}
+
+	body := string(data)
+	if len(body) > maxErrorBodySize {
+		body = strings.ToValidUTF8(body[:maxErrorBodySize], "") + "..."
+	}
+
+	return a.client.Redact(strings.TrimSpace(body))
+}
+
+// nextPage describes the next_page object of a response envelope.
+type nextPage struct {
+	Offset string `json:"offset"` // Offset token of the next page
+	Path   string `json:"path"`   // Path of the next page, relative to the entrypoint
+	URI    string `json:"uri"`    // Absolute URI of the next page
+}
+
+// nextPage returns the next_page object of a response envelope, or nil if
+// there are no further pages. It returns an error if the envelope cannot be
+// decoded or announces a next page without an offset, in which case
+// pagination cannot continue.
+func (a *app) nextPage(d []byte) (*nextPage, error) {
+	var envelope struct {
+		NextPage *nextPage `json:"next_page"`
+	}
+
+	if err := json.Unmarshal(d, &envelope); err != nil {
+		return nil, fmt.Errorf("decode next page: %w", err)
+	}
+	if envelope.NextPage == nil {
+		return nil, nil
+	}
+	if envelope.NextPage.Offset == "" {
+		return nil, errors.New("next page without offset")
+	}
+
+	return envelope.NextPage, nil
+}
+
+// verifyCount checks a response page against the item count it declares in
+// a top-level count field, if any. It returns an error if the page cannot be
+// decoded or holds a different number of resources than declared.
+func (a *app) verifyCount(d []byte) error {
+	var envelope struct {
+		Data  []json.RawMessage `json:"data"`
+		Count *int              `json:"count"`
+	}
+
+	if err := json.Unmarshal(d, &envelope); err != nil {
+		return fmt.Errorf("decode page: %w", err)
+	}
+	if envelope.Count != nil && *envelope.Count != len(envelope.Data) {
+		return fmt.Errorf("response declares %d resources but holds %d", *envelope.Count, len(envelope.Data))
+	}
+
+	return nil
+}
+
+// tracePage logs the next_page values consumed after page, for diagnosing
+// truncated exports. To cap the log volume, only the first maxTracedPages
+// pages are logged, followed by a single notice.
+func (a *app) tracePage(page int, next *nextPage) {
+	switch {
+	case page > maxTracedPages+1:
+		return
+	case page == maxTracedPages+1:
+		a.log.Info("pagination trace limit reached, not logging further pages",
+			slog.Int("max_pages", maxTracedPages))
+		return
+	}
+
+	if next == nil {
+		a.log.Info("pagination", slog.Int("page", page), slog.Bool("last", true))
+		return
+	}
+
+	a.log.Info("pagination",
+		slog.Int("page", page),
+		slog.String("offset", next.Offset),
+		slog.String("path", next.Path),
+		slog.String("uri", next.URI))
+}
+
+// degrade handles a condition that is tolerated by default but is fatal in
+// strict mode. It logs err as a warning and returns nil, or returns err when
+// strict mode is enabled.
+func (a *app) degrade(err error) error {
+	if a.cfg.strict {
+		return fmt.Errorf("strict mode: %w", err)
+	}
+
+	a.log.Warn("tolerated export problem", slog.String("error", err.Error()))
+	return nil
+}
+
+// truncateOnMaxPages marks the run truncated if err stems from a listing
+// stopped after max-pages pages, which is tolerated unless strict mode is
+// enabled.
+func (a *app) truncateOnMaxPages(err error, sum *summary) error {
+	if !errors.Is(err, errMaxPages) {
+		return err
+	}
+
+	sum.truncated = true
+	a.log.Warn("max pages reached, export stopped",
+		slog.Int("max_pages", a.cfg.maxPages),
+		slog.Int("pages", sum.pages))
+
+	return a.degrade(fmt.Errorf("export truncated: %w", err))
+}
+
+// storeRaw persists an unmodified API response page under the resource's
+// _raw directory in dir. Filename format: {resource_type}_{timestamp}_{page}.json,
+// where page is zero-padded so files sort in fetch order.
+func (a *app) storeRaw(data []byte, dir string, runTime time.Time, page int) error {
+	rawDir := filepath.Join(dir, a.cfg.resource, rawDirName)
+	if err := a.resourceDir(rawDir); err != nil {
+		return fmt.Errorf("raw directory: %w", err)
+	}
+
+	filename := fmt.Sprintf("%s/%s_%s_%04d.json", rawDir, a.cfg.resource, a.timestamp(runTime), page)
+	cleanPath, err := a.dataPath(filename)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(cleanPath, data, 0600); err != nil {
+		return fmt.Errorf("write file: %w", err)
+	}
+	a.log.Debug("raw response stored", slog.String("filename", cleanPath))
+
+	return nil
+}
+
+// emptyData reports whether d is a valid response holding no resources.
+// Responses that fail to decode are not considered empty.
+func (a *app) emptyData(d []byte) bool {
+	resources, err := a.resources(d)
+	return err == nil && len(resources) == 0
+}
+
+// sleep pauses for d or until ctx is cancelled, whichever comes first.
+// It returns the context error if cancelled.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// exportDir returns the directory export files of the configured resource
+// type are written to under dir: its subdirectory named after the type, or
+// dir itself with flatten-dir, where the file names tell the types apart.
+func (a *app) exportDir(dir string) string {
+	if a.cfg.flattenDir {
+		return dir
+	}
+	return filepath.Join(dir, a.cfg.resource)
+}
+
 // resourceDir creates or verifies the export directory for a resource type.
 // It ensures proper permissions (0755) and returns error if the path exists
 // but is not a directory or if creation fails.
@@ -140,69 +806,144 @@ func (a *app) resourceDir(dst string) error {
 	}
 
 	if !dir.IsDir() {
-		return fmt.Errorf("%q not directory", dir.Name())
+		return fmt.Errorf("%q exists but is not a directory; remove or rename the conflicting file", dst)
 	}
 
 	return nil
 }
 
-// resources decodes Asana API response data into Resource objects.
-// Asana wraps resources in a "data" field array. Returns error if
-// JSON unmarshaling fails or response format is invalid.
+// resources decodes API response data into Resource objects with the
+// configured decoder. Returns error if the response format is invalid, or,
+// with strict-json, if the envelope holds an unknown field.
 func (a *app) resources(d []byte) ([]Resource, error) {
-	var output struct {
-		Data []Resource `json:"data"`
-	}
-
-	if err := json.Unmarshal(d, &output); err != nil {
-		return nil, fmt.Errorf("unmarshal data: %w", err)
+	if a.cfg.strictJSON {
+		if err := checkEnvelope(d); err != nil {
+			return nil, err
+		}
 	}
-
-	return output.Data, nil
+	return a.decoder().Decode(d)
 }
 
-// storeResource persists a resource as JSON in the data directory.
-// Filename format: {resource_type}_{name}_{timestamp}.json.
-// Returns error if file creation or JSON encoding fails.
-// It prevents directory traversal by validating the provided filename.
-func (a *app) storeResource(rc Resource, filename string) error {
-	a.log.Debug("store resource")
-
+// dataPath cleans filename and verifies it resolves inside the data directory.
+// It prevents directory traversal and returns the cleaned path.
+func (a *app) dataPath(filename string) (string, error) {
 	// Clean the path to handle any . or .. components
 	cleanPath := filepath.Clean(filename)
 
 	// Ensure the path is within the data directory by checking it starts with the expected prefix
 	dataDirAbs, err := filepath.Abs(a.cfg.dataDir)
 	if err != nil {
-		return fmt.Errorf("get absolute data directory path: %w", err)
+		return "", fmt.Errorf("get absolute data directory path: %w", err)
 	}
 
 	fileAbs, err := filepath.Abs(cleanPath)
 	if err != nil {
-		return fmt.Errorf("get absolute file path: %w", err)
+		return "", fmt.Errorf("get absolute file path: %w", err)
 	}
 
 	if !strings.HasPrefix(fileAbs, dataDirAbs) {
-		return fmt.Errorf("invalid file path: attempts to write outside data directory")
+		return "", fmt.Errorf("invalid file path: attempts to write outside data directory")
 	}
 
-	file, err := os.OpenFile(cleanPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	return cleanPath, nil
+}
+
+// storeResource persists a resource as JSON in the data directory.
+// Filename format: {resource_type}_{name}_{timestamp}.json.
+// The file is written to a temporary file and renamed into place, so it is
+// never left incomplete, e.g. when the process is killed during shutdown.
+// Returns error if file creation or JSON encoding fails. Failed writes are
+// retried as configured with write-retries. With sidecar-checksums, the
+// checksum of the written data is stored next to the file.
+// It prevents directory traversal by validating the provided filename.
+func (a *app) storeResource(ctx context.Context, rc Resource, filename string) error {
+	a.log.Debug("store resource")
+
+	cleanPath, err := a.dataPath(filename)
 	if err != nil {
-		a.log.Error("create file", slog.String("error", err.Error()), slog.String("filename", filename))
 		return err
 	}
 
-	defer func() {
-		if err := file.Close(); err != nil {
-			a.log.Error("close file", slog.String("error", err.Error()), slog.String("filename", filename))
+	data, err := a.encodeFile(rc)
+	if err != nil {
+		a.log.Error("encode output", slog.String("error", err.Error()))
+		a.recordError(rc.GID, phaseEncode, err)
+		if err := a.degrade(fmt.Errorf("resource %s skipped: %w", rc.GID, err)); err != nil {
+			return err
 		}
-	}()
+		return nil
+	}
 
-	encoder := json.NewEncoder(file)
-	if err := encoder.Encode(rc); err != nil {
-		a.log.Error("encode outout", slog.String("error", err.Error()))
+	if err := a.budget.take(len(data)); err != nil {
+		return err
+	}
+	if err := a.retryWrite(ctx, filename, func() error { return writeFileAtomic(cleanPath, data) }); err != nil {
+		a.log.Error("write file", slog.String("error", err.Error()), slog.String("filename", filename))
+		return err
+	}
+	if a.cfg.checksums {
+		sum := sha256.Sum256(data)
+		if err := a.writeChecksum(ctx, cleanPath, sum[:]); err != nil {
+			return fmt.Errorf("write checksum: %w", err)
+		}
 	}
 	a.log.Debug("resource stored")
 
 	return nil
 }
+
+// writeFile creates or truncates the file at path and writes data to it.
+func writeFile(path string, data []byte) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("create file: %w", err)
+	}
+	if _, err := file.Write(data); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("write file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("close file: %w", err)
+	}
+
+	return nil
+}
+
+// retryWrite calls write and, while it fails with an error that may be
+// transient, such as EAGAIN or a stale NFS handle, retries it with backoff up
+// to write-retries times. Permanent errors, such as a full disk or a denied
+// permission, are returned at once. Cancelling ctx interrupts the wait.
+func (a *app) retryWrite(ctx context.Context, filename string, write func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := write()
+		if err == nil || attempt > a.cfg.writeRetries || permanentWriteError(err) {
+			return err
+		}
+
+		wait := backoffDelay(writeRetryDelay, maxWriteRetryDelay, attempt)
+		a.log.Warn("write failed, retrying",
+			slog.String("filename", filename),
+			slog.String("error", err.Error()),
+			slog.Int("attempt", attempt),
+			slog.Int("max_attempts", a.cfg.writeRetries),
+			slog.String("retry_after", wait.String()))
+		if err := sleep(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+// permanentWriteError reports whether err is a filesystem error that retrying
+// the write cannot resolve.
+func permanentWriteError(err error) bool {
+	for _, errno := range []syscall.Errno{
+		syscall.ENOSPC, syscall.EDQUOT, syscall.EACCES, syscall.EPERM, syscall.EROFS,
+		syscall.ENOENT, syscall.ENOTDIR, syscall.EISDIR, syscall.ENAMETOOLONG,
+	} {
+		if errors.Is(err, errno) {
+			return true
+		}
+	}
+
+	return false
+}
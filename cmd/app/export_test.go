@@ -4,21 +4,34 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"log/slog"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"syscall"
 	"testing"
 	"time"
+
+	"github.com/marintailor/asana-resource-exporter/internal"
 )
 
 func TestAppExport(t *testing.T) {
+	dataDir := t.TempDir()
+
 	app := &app{
 		cfg: &config{
 			entrypoint: "example.com",
 			resource:   "project",
 			rate:       60,
+			dataDir:    dataDir,
 		},
 		log: slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
 	}
@@ -34,17 +47,53 @@ func TestAppExport(t *testing.T) {
 
 	ctx := context.Background()
 
-	rcDir := filepath.Join(t.TempDir(), "project")
+	rcDir := filepath.Join(dataDir, "project")
 
 	if err := app.resourceDir(rcDir); err != nil {
 		t.Fatalf("Failed to create test directory: %v", err)
 	}
 
-	if err := app.export(ctx, buf.Bytes(), rcDir); err != nil {
+	if err := app.export(ctx, buf.Bytes(), rcDir, app.newSink(rcDir, time.Now()), &summary{}); err != nil {
 		t.Errorf("export() error = %v", err)
 	}
 }
 
+func TestAppExportFilter(t *testing.T) {
+	dataDir := t.TempDir()
+
+	filter, err := parseFilter("name^=Q3")
+	if err != nil {
+		t.Fatalf("parseFilter() error = %v", err)
+	}
+
+	app := &app{
+		cfg: &config{
+			resource: "project",
+			dataDir:  dataDir,
+			filter:   filter,
+		},
+		log: slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+	}
+
+	data := []byte(`{"data": [{"gid": "1", "name": "Q3 Plan"}, {"gid": "2", "name": "Q4 Plan"}, {"gid": "3", "name": "Q3 Review"}]}`)
+
+	sum := &summary{}
+	if err := app.export(context.Background(), data, dataDir, app.newSink(dataDir, time.Now()), sum); err != nil {
+		t.Fatalf("export() error = %v", err)
+	}
+	if sum.written != 2 || sum.filtered != 1 {
+		t.Errorf("export() written = %d, filtered = %d, want 2 and 1", sum.written, sum.filtered)
+	}
+
+	files, err := os.ReadDir(filepath.Join(dataDir, "project"))
+	if err != nil {
+		t.Fatalf("Failed to read directory: %v", err)
+	}
+	if len(files) != 2 {
+		t.Errorf("Expected 2 files, got %d", len(files))
+	}
+}
+
 func TestAppExportCancellation(t *testing.T) {
 	app := &app{
 		cfg: &config{
@@ -55,63 +104,927 @@ func TestAppExportCancellation(t *testing.T) {
 		log: slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
 	}
 
-	ctx, cancel := context.WithCancel(context.Background())
-	cancel()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	buf := new(bytes.Buffer)
+
+	_ = json.NewEncoder(buf).Encode(map[string][]Resource{
+		"data": {
+			{GID: "1", Name: "Test1", ResourceType: "project"},
+			{GID: "2", Name: "Test2", ResourceType: "project"},
+		},
+	})
+
+	rcDir := filepath.Join(t.TempDir(), "project")
+	err := app.export(ctx, buf.Bytes(), rcDir, app.newSink(rcDir, time.Now()), &summary{})
+	if err != context.Canceled {
+		t.Errorf("Expected context.Canceled error, got %v", err)
+	}
+}
+
+func TestAppFetchRateLimit(t *testing.T) {
+	callCount := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		callCount++
+		if callCount == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string][]Resource{
+			"data": {{GID: "1", Name: "Test", ResourceType: "project"}},
+		})
+	}))
+	defer server.Close()
+
+	client, _ := internal.NewClient("token", 600)
+	app := &app{
+		cfg: &config{
+			entrypoint: server.URL,
+			resource:   "project",
+			rate:       600,
+			pageSize:   defaultPageSize,
+		},
+		log:    slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+		client: client,
+	}
+
+	start := time.Now()
+	pages, err := app.fetch(context.Background(), t.TempDir())
+	if err != nil {
+		t.Fatalf("fetch() error = %v", err)
+	}
+	if len(pages) != 1 {
+		t.Fatalf("fetch() returned %d pages, want 1", len(pages))
+	}
+	if resources, _ := app.resources(pages[0]); len(resources) != 1 {
+		t.Errorf("fetch() returned %d resources, want 1", len(resources))
+	}
+	if callCount != 2 {
+		t.Errorf("Expected 2 API calls, got %d", callCount)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("Expected the retry after the Retry-After delay, took %v", elapsed)
+	}
+}
+
+func TestAppFetchPartialOnError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("offset") == "" {
+			_, _ = w.Write([]byte(`{"data": [{"gid": "1", "name": "Alpha", "resource_type": "project"}], "next_page": {"offset": "page2"}}`))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client, _ := internal.NewClient("token", 600)
+	app := &app{
+		cfg: &config{
+			entrypoint: server.URL,
+			resource:   "project",
+			rate:       600,
+			pageSize:   defaultPageSize,
+		},
+		log:    slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+		client: client,
+	}
+
+	pages, err := app.fetch(context.Background(), t.TempDir())
+	var apiErr *internal.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("fetch() error = %v, want APIError 404", err)
+	}
+	if len(pages) != 1 {
+		t.Errorf("fetch() returned %d pages before the error, want 1", len(pages))
+	}
+}
+
+func TestAppStore(t *testing.T) {
+	dataDir := t.TempDir()
+	app := &app{
+		cfg: &config{
+			resource:     "project",
+			dataDir:      dataDir,
+			emptyName:    defaultEmptyName,
+			outputFormat: formatJSON,
+		},
+		log: slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+	}
+
+	pages := [][]byte{
+		[]byte(`{"data": [{"gid": "1", "name": "Alpha", "resource_type": "project"}, {"gid": "2", "name": "Beta", "resource_type": "project"}]}`),
+		[]byte(`{"data": [{"gid": "3", "name": "Gamma", "resource_type": "project"}]}`),
+	}
+	out := app.newSink(dataDir, time.Now())
+	sum := &summary{resource: "project"}
+	if err := app.store(context.Background(), pages, dataDir, out, sum); err != nil {
+		t.Fatalf("store() error = %v", err)
+	}
+
+	// The sink stays open for further pages.
+	if err := app.store(context.Background(), [][]byte{[]byte(emptyPage)}, dataDir, out, sum); err != nil {
+		t.Fatalf("store() error = %v", err)
+	}
+	if err := out.close(true); err != nil {
+		t.Fatalf("close() error = %v", err)
+	}
+
+	if sum.pages != 3 || sum.written != 3 {
+		t.Errorf("store() counted %d pages and %d resources, want 3 and 3", sum.pages, sum.written)
+	}
+	entries, err := os.ReadDir(filepath.Join(dataDir, "project"))
+	if err != nil {
+		t.Fatalf("Failed to read resource directory: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Errorf("Expected 3 export files, got %d", len(entries))
+	}
+
+	err = app.store(context.Background(), [][]byte{[]byte(`not json`), pages[1]}, dataDir, out, sum)
+	if err == nil {
+		t.Fatal("Expected store() to fail on an undecodable page")
+	}
+	if sum.pages != 3 {
+		t.Errorf("Expected store() to stop at the failing page, counted %d pages", sum.pages)
+	}
+}
+
+// collectPages runs the fetch phase in the data directory.
+func collectPages(app *app) ([][]byte, error) {
+	return app.fetch(context.Background(), app.cfg.dataDir)
+}
+
+func TestAppFetchDataRetryOnEmpty(t *testing.T) {
+	tests := []struct {
+		name         string
+		retryOnEmpty int
+		emptyCalls   int
+		wantCalls    int
+		wantCount    int
+	}{
+		{
+			name:         "no retry by default",
+			retryOnEmpty: 0,
+			emptyCalls:   1,
+			wantCalls:    1,
+			wantCount:    0,
+		},
+		{
+			name:         "retry until data",
+			retryOnEmpty: 2,
+			emptyCalls:   1,
+			wantCalls:    2,
+			wantCount:    1,
+		},
+		{
+			name:         "accept empty after retries",
+			retryOnEmpty: 1,
+			emptyCalls:   5,
+			wantCalls:    2,
+			wantCount:    0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			callCount := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				callCount++
+				if callCount <= tt.emptyCalls {
+					_, _ = w.Write([]byte(`{"data": []}`))
+					return
+				}
+				_, _ = w.Write([]byte(`{"data": [{"gid": "1", "name": "Test", "resource_type": "project"}]}`))
+			}))
+			defer server.Close()
+
+			client, _ := internal.NewClient("token", 600)
+			app := &app{
+				cfg: &config{
+					entrypoint:   server.URL,
+					resource:     "project",
+					rate:         600,
+					pageSize:     defaultPageSize,
+					retryOnEmpty: tt.retryOnEmpty,
+				},
+				log:    slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+				client: client,
+			}
+
+			pages, err := collectPages(app)
+			if err != nil {
+				t.Fatalf("fetchData() error = %v", err)
+			}
+			if callCount != tt.wantCalls {
+				t.Errorf("fetchData() made %d calls, want %d", callCount, tt.wantCalls)
+			}
+			if len(pages) != 1 {
+				t.Fatalf("fetchData() returned %d pages, want 1", len(pages))
+			}
+
+			resources, err := app.resources(pages[0])
+			if err != nil {
+				t.Fatalf("resources() error = %v", err)
+			}
+			if len(resources) != tt.wantCount {
+				t.Errorf("fetchData() returned %d resources, want %d", len(resources), tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestAppFetchDataPagination(t *testing.T) {
+	var offsets []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		offsets = append(offsets, r.URL.Query().Get("offset"))
+		if r.URL.Query().Get("limit") != "2" {
+			t.Errorf("limit = %q, want %q", r.URL.Query().Get("limit"), "2")
+		}
+		switch r.URL.Query().Get("offset") {
+		case "":
+			_, _ = w.Write([]byte(`{"data": [{"gid": "1"}, {"gid": "2"}], "next_page": {"offset": "eyJ0eXAi+/=", "path": "/projects?limit=2&offset=eyJ0eXAi%2B%2F%3D"}}`))
+		default:
+			_, _ = w.Write([]byte(`{"data": [{"gid": "3"}], "next_page": null}`))
+		}
+	}))
+	defer server.Close()
+
+	dataDir := t.TempDir()
+	client, _ := internal.NewClient("token", 600)
+	app := &app{
+		cfg: &config{
+			entrypoint:  server.URL,
+			resource:    "project",
+			rate:        600,
+			pageSize:    2,
+			dataDir:     dataDir,
+			preserveRaw: true,
+		},
+		log:    slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+		client: client,
+	}
+
+	pages, err := collectPages(app)
+	if err != nil {
+		t.Fatalf("fetchData() error = %v", err)
+	}
+	if len(pages) != 2 {
+		t.Fatalf("fetchData() returned %d pages, want 2", len(pages))
+	}
+	if len(offsets) != 2 || offsets[1] != "eyJ0eXAi+/=" {
+		t.Errorf("requested offsets = %q, want [\"\" \"eyJ0eXAi+/=\"]", offsets)
+	}
+
+	raw, err := os.ReadDir(filepath.Join(dataDir, "project", rawDirName))
+	if err != nil {
+		t.Fatalf("Failed to read raw directory: %v", err)
+	}
+	if len(raw) != 2 {
+		t.Fatalf("Expected 2 raw files, got %d", len(raw))
+	}
+
+	for i, entry := range raw {
+		content, err := os.ReadFile(filepath.Join(dataDir, "project", rawDirName, entry.Name()))
+		if err != nil {
+			t.Fatalf("Failed to read raw file: %v", err)
+		}
+		if !bytes.Equal(content, pages[i]) {
+			t.Errorf("raw file %s does not match page %d", entry.Name(), i+1)
+		}
+	}
+}
+
+func TestAppFetchDataPaginationQuery(t *testing.T) {
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.RawQuery)
+		switch r.URL.Query().Get("offset") {
+		case "":
+			_, _ = w.Write([]byte(`{"data": [{"gid": "1"}], "next_page": {"offset": "eyJ0eXAi+/="}}`))
+		case "eyJ0eXAi+/=":
+			_, _ = w.Write([]byte(`{"data": [{"gid": "2"}], "next_page": {"offset": "a%2Bb c"}}`))
+		default:
+			_, _ = w.Write([]byte(`{"data": [{"gid": "3"}], "next_page": null}`))
+		}
+	}))
+	defer server.Close()
+
+	client, _ := internal.NewClient("token", 600)
+	app := &app{
+		cfg: &config{
+			entrypoint:    server.URL,
+			resource:      "goal",
+			workspace:     "12345",
+			rate:          600,
+			pageSize:      50,
+			optFields:     []string{"name", "owner.name"},
+			modifiedSince: "2024-06-01T00:00:00+02:00",
+		},
+		log:    slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+		client: client,
+	}
+
+	if _, err := collectPages(app); err != nil {
+		t.Fatalf("fetchData() error = %v", err)
+	}
+
+	// Every page carries the same parameters, and offset tokens are encoded
+	// once, even if they contain characters that are escaped themselves.
+	want := []string{
+		"limit=50&modified_since=2024-06-01T00%3A00%3A00%2B02%3A00&opt_fields=name%2Cowner.name&workspace=12345",
+		"limit=50&modified_since=2024-06-01T00%3A00%3A00%2B02%3A00&offset=eyJ0eXAi%2B%2F%3D&opt_fields=name%2Cowner.name&workspace=12345",
+		"limit=50&modified_since=2024-06-01T00%3A00%3A00%2B02%3A00&offset=a%252Bb+c&opt_fields=name%2Cowner.name&workspace=12345",
+	}
+	if !slices.Equal(queries, want) {
+		t.Errorf("queries = %q, want %q", queries, want)
+	}
+}
+
+func TestAppRunExportMaxPages(t *testing.T) {
+	tests := []struct {
+		name      string
+		maxPages  int
+		strict    bool
+		wantCalls int32
+		wantErr   bool
+	}{
+		{"capped", 3, false, 3, false},
+		{"capped in strict mode", 3, true, 3, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The server never stops announcing a next page.
+			var calls atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := calls.Add(1)
+				if n > 10 {
+					t.Error("Expected pagination to stop")
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+				fmt.Fprintf(w, `{"data": [{"gid": "%d", "name": "P%d", "resource_type": "project"}], "next_page": {"offset": "same"}}`, n, n)
+			}))
+			defer server.Close()
+
+			var logs strings.Builder
+			dataDir := t.TempDir()
+			client, _ := internal.NewClient("token", 600)
+			app := &app{
+				cfg: &config{
+					entrypoint:   server.URL,
+					resource:     "project",
+					rate:         600,
+					pageSize:     defaultPageSize,
+					dataDir:      dataDir,
+					emptyName:    defaultEmptyName,
+					outputFormat: formatJSON,
+					maxPages:     tt.maxPages,
+					strict:       tt.strict,
+				},
+				log:    slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{})),
+				client: client,
+			}
+
+			err := app.runExport(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("runExport() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, errMaxPages) {
+				t.Errorf("runExport() error = %v, want %v", err, errMaxPages)
+			}
+			if calls.Load() != tt.wantCalls {
+				t.Errorf("Expected %d requests, got %d", tt.wantCalls, calls.Load())
+			}
+
+			files, _ := filepath.Glob(filepath.Join(dataDir, "project", "project_P*.json"))
+			if len(files) != int(tt.wantCalls) {
+				t.Errorf("Expected %d files, got %d", tt.wantCalls, len(files))
+			}
+			if !strings.Contains(logs.String(), `"msg":"max pages reached, export stopped"`) || !strings.Contains(logs.String(), `"truncated":true`) {
+				t.Errorf("Expected the run to be reported truncated, got %s", logs.String())
+			}
+		})
+	}
+}
+
+func TestAppTracePage(t *testing.T) {
+	buf := new(bytes.Buffer)
+	app := &app{
+		cfg: &config{resource: "project"},
+		log: slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{})),
+	}
+
+	next := &nextPage{Offset: "eyJ0eXAi", Path: "/projects?offset=eyJ0eXAi", URI: "https://app.asana.com/api/1.0/projects?offset=eyJ0eXAi"}
+	for page := 1; page <= maxTracedPages+5; page++ {
+		app.tracePage(page, next)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != maxTracedPages+1 {
+		t.Fatalf("logged %d entries, want %d", len(lines), maxTracedPages+1)
+	}
+
+	var entry struct {
+		Offset string `json:"offset"`
+		URI    string `json:"uri"`
+	}
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatalf("Failed to decode log entry: %v", err)
+	}
+	if entry.Offset != next.Offset || entry.URI != next.URI {
+		t.Errorf("logged offset = %q, uri = %q, want %q, %q", entry.Offset, entry.URI, next.Offset, next.URI)
+	}
+	if !strings.Contains(lines[len(lines)-1], "pagination trace limit reached") {
+		t.Errorf("last entry = %s, want limit notice", lines[len(lines)-1])
+	}
+}
+
+func TestAppFetchDataStrict(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		strict  bool
+		wantErr bool
+	}{
+		{
+			name:    "empty list tolerated",
+			body:    `{"data": []}`,
+			strict:  false,
+			wantErr: false,
+		},
+		{
+			name:    "empty list strict",
+			body:    `{"data": []}`,
+			strict:  true,
+			wantErr: true,
+		},
+		{
+			name:    "incomplete pagination tolerated",
+			body:    `{"data": [{"gid": "1"}], "next_page": {"path": "/projects"}}`,
+			strict:  false,
+			wantErr: false,
+		},
+		{
+			name:    "incomplete pagination strict",
+			body:    `{"data": [{"gid": "1"}], "next_page": {"path": "/projects"}}`,
+			strict:  true,
+			wantErr: true,
+		},
+		{
+			name:    "complete result strict",
+			body:    `{"data": [{"gid": "1"}], "next_page": null}`,
+			strict:  true,
+			wantErr: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			client, _ := internal.NewClient("token", 600)
+			app := &app{
+				cfg: &config{
+					entrypoint: server.URL,
+					resource:   "project",
+					rate:       600,
+					pageSize:   defaultPageSize,
+					strict:     tt.strict,
+				},
+				log:    slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+				client: client,
+			}
+
+			_, err := collectPages(app)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("fetchData() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAppFetchDataNoContent(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+	}{
+		{"no content", http.StatusNoContent},
+		{"empty body", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			dataDir := t.TempDir()
+			client, _ := internal.NewClient("token", 600)
+			app := &app{
+				cfg: &config{
+					entrypoint: server.URL,
+					resource:   "project",
+					rate:       600,
+					pageSize:   defaultPageSize,
+					dataDir:    dataDir,
+				},
+				log:    slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+				client: client,
+			}
+
+			if err := app.runExport(context.Background()); err != nil {
+				t.Fatalf("runExport() error = %v", err)
+			}
+
+			var files int
+			err := filepath.WalkDir(dataDir, func(path string, d fs.DirEntry, err error) error {
+				if err == nil && !d.IsDir() {
+					files++
+				}
+				return err
+			})
+			if err != nil {
+				t.Fatalf("Failed to walk data directory: %v", err)
+			}
+			if files != 0 {
+				t.Errorf("Expected no files written, got %d", files)
+			}
+		})
+	}
+}
+
+func TestAppFetchDataStatusError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"errors": [{"message": "forbidden"}]}`))
+	}))
+	defer server.Close()
+
+	client, _ := internal.NewClient("token", 600)
+	app := &app{
+		cfg: &config{
+			entrypoint: server.URL,
+			resource:   "project",
+			rate:       600,
+			pageSize:   defaultPageSize,
+		},
+		log:    slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+		client: client,
+	}
+
+	_, err := collectPages(app)
+	var apiErr *internal.APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("fetchData() error = %v, want APIError", err)
+	}
+	if apiErr.StatusCode != http.StatusForbidden {
+		t.Errorf("APIError status = %d, want %d", apiErr.StatusCode, http.StatusForbidden)
+	}
+}
+
+func TestAppFetchDataRetries(t *testing.T) {
+	tests := []struct {
+		name           string
+		networkErrors  int
+		status         int
+		networkRetries int
+		httpRetries    int
+		wantErr        bool
+		wantAttempts   int
+	}{
+		{"network error retried", 1, http.StatusOK, 1, 0, false, 2},
+		{"network error not retried", 1, http.StatusOK, 0, 3, true, 1},
+		{"server error retried", 0, http.StatusServiceUnavailable, 0, 1, false, 2},
+		{"server error not retried", 0, http.StatusServiceUnavailable, 3, 0, true, 1},
+		{"client error not retried", 0, http.StatusNotFound, 3, 3, true, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempt := attempts.Add(1)
+				if int(attempt) <= tt.networkErrors {
+					conn, _, err := w.(http.Hijacker).Hijack()
+					if err != nil {
+						t.Errorf("Failed to hijack connection: %v", err)
+						return
+					}
+					_ = conn.Close()
+					return
+				}
+				if attempt == 1 && tt.status != http.StatusOK {
+					w.WriteHeader(tt.status)
+					return
+				}
+				_, _ = w.Write([]byte(`{"data": [{"gid": "1", "name": "Project", "resource_type": "project"}], "next_page": null}`))
+			}))
+			defer server.Close()
+
+			client, _ := internal.NewClient("token", 600)
+			app := &app{
+				cfg: &config{
+					entrypoint:     server.URL,
+					resource:       "project",
+					rate:           600,
+					pageSize:       defaultPageSize,
+					networkRetries: tt.networkRetries,
+					httpRetries:    tt.httpRetries,
+				},
+				log:    slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+				client: client,
+			}
+
+			_, err := collectPages(app)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("fetchData() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := int(attempts.Load()); got != tt.wantAttempts {
+				t.Errorf("Expected %d attempts, got %d", tt.wantAttempts, got)
+			}
+		})
+	}
+}
+
+func TestAppFetchDataRedirectLoopNotRetried(t *testing.T) {
+	var requests atomic.Int32
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		http.Redirect(w, r, server.URL+r.URL.Path, http.StatusFound)
+	}))
+	defer server.Close()
+
+	client, _ := internal.NewClient("token", 6000)
+	app := &app{
+		cfg: &config{
+			entrypoint:     server.URL,
+			resource:       "project",
+			rate:           6000,
+			pageSize:       defaultPageSize,
+			networkRetries: 3,
+		},
+		log:    slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+		client: client,
+	}
+
+	_, err := collectPages(app)
+	if !errors.Is(err, internal.ErrTooManyRedirects) {
+		t.Errorf("fetchData() error = %v, want %v", err, internal.ErrTooManyRedirects)
+	}
+	// The request and its redirects are followed once, without retries.
+	if got := int(requests.Load()); got != internal.DefaultMaxRedirects+1 {
+		t.Errorf("Expected %d requests, got %d", internal.DefaultMaxRedirects+1, got)
+	}
+}
+
+func TestBackoffDelay(t *testing.T) {
+	tests := []struct {
+		name    string
+		attempt int
+		want    time.Duration
+	}{
+		{"first", 1, time.Second},
+		{"third", 3, 4 * time.Second},
+		{"capped", 7, time.Minute},
+		{"no overflow", 100, time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := backoffDelay(time.Second, time.Minute, tt.attempt); got != tt.want {
+				t.Errorf("backoffDelay() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAppFetchDataRetryStatus(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		retryStatus  map[int]bool
+		wantErr      bool
+		wantAttempts int
+	}{
+		{"custom status retried", 420, map[int]bool{420: true, 598: true}, false, 2},
+		{"custom status not configured", 420, nil, true, 1},
+		{"server error still retried", http.StatusBadGateway, map[int]bool{420: true}, false, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if attempts.Add(1) == 1 {
+					w.WriteHeader(tt.status)
+					return
+				}
+				_, _ = w.Write([]byte(`{"data": [{"gid": "1", "name": "Project", "resource_type": "project"}], "next_page": null}`))
+			}))
+			defer server.Close()
+
+			client, _ := internal.NewClient("token", 600)
+			app := &app{
+				cfg: &config{
+					entrypoint:    server.URL,
+					resource:      "project",
+					rate:          600,
+					pageSize:      defaultPageSize,
+					httpRetries:   1,
+					retryStatuses: tt.retryStatus,
+				},
+				log:    slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+				client: client,
+			}
+
+			_, err := collectPages(app)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("fetchData() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := int(attempts.Load()); got != tt.wantAttempts {
+				t.Errorf("Expected %d attempts, got %d", tt.wantAttempts, got)
+			}
+		})
+	}
+}
+
+func TestAppFetchDataRetryAfterBody(t *testing.T) {
+	var attempts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = w.Write([]byte(`{"errors": [{"message": "rate limited"}], "retry_after": 0.01}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"data": [{"gid": "1", "name": "Project", "resource_type": "project"}], "next_page": null}`))
+	}))
+	defer server.Close()
+
+	client, _ := internal.NewClient("token", 600)
+	app := &app{
+		cfg: &config{
+			entrypoint: server.URL,
+			resource:   "project",
+			rate:       600,
+			pageSize:   defaultPageSize,
+		},
+		log:    slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+		client: client,
+	}
+
+	start := time.Now()
+	if _, err := collectPages(app); err != nil {
+		t.Fatalf("fetchData() error = %v", err)
+	}
+	if attempts != 2 {
+		t.Errorf("Expected 2 attempts, got %d", attempts)
+	}
+	if elapsed := time.Since(start); elapsed >= time.Duration(defaultRetryAfter)*time.Second {
+		t.Errorf("Expected the body hint to be used instead of the default wait, took %v", elapsed)
+	}
+}
+
+func TestAppRunExportWorkspaceResources(t *testing.T) {
+	tests := []struct {
+		resource string
+		path     string
+		query    string
+	}{
+		{"goal", "/goals", "limit=100&workspace=12345"},
+		{"portfolio", "/portfolios", "limit=100&owner=me&workspace=12345"},
+		{"tag", "/tags", "limit=100&workspace=12345"},
+		{"custom_field", "/workspaces/12345/custom_fields", "limit=100"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.resource, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != tt.path {
+					t.Errorf("Expected path %s, got %s", tt.path, r.URL.Path)
+				}
+				if r.URL.RawQuery != tt.query {
+					t.Errorf("Expected query %s, got %s", tt.query, r.URL.RawQuery)
+				}
+				fmt.Fprintf(w, `{"data": [{"gid": "1", "name": "Q3 Plan", "resource_type": %q}], "next_page": null}`, tt.resource)
+			}))
+			defer server.Close()
+
+			dataDir := t.TempDir()
+			client, _ := internal.NewClient("token", 600)
+			app := &app{
+				cfg: &config{
+					entrypoint: server.URL,
+					resource:   tt.resource,
+					workspace:  "12345",
+					owner:      defaultOwner,
+					rate:       600,
+					pageSize:   defaultPageSize,
+					dataDir:    dataDir,
+				},
+				log:    slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+				client: client,
+			}
+
+			if err := app.runExport(context.Background()); err != nil {
+				t.Fatalf("runExport() error = %v", err)
+			}
+
+			files, err := os.ReadDir(filepath.Join(dataDir, tt.resource))
+			if err != nil {
+				t.Fatalf("Failed to read resource directory: %v", err)
+			}
+			if len(files) != 1 || !strings.HasPrefix(files[0].Name(), tt.resource+"_Q3 Plan_") {
+				t.Errorf("Expected one %s file, got %v", tt.resource, files)
+			}
+		})
+	}
+}
+
+func TestAppFetchDataVerboseErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		verbose  bool
+		body     string
+		want     string
+		wantSize int
+	}{
+		{
+			name: "terse",
+			body: `{"errors": [{"message": "nmae: Unknown field"}]}`,
+			want: "",
+		},
+		{
+			name:    "verbose",
+			verbose: true,
+			body:    `{"errors": [{"message": "nmae: Unknown field"}]}` + "\n",
+			want:    `{"errors": [{"message": "nmae: Unknown field"}]}`,
+		},
+		{
+			name:    "token redacted",
+			verbose: true,
+			body:    `{"errors": [{"message": "bad token secret-token"}]}`,
+			want:    `{"errors": [{"message": "bad token [REDACTED]"}]}`,
+		},
+		{
+			name:     "size capped",
+			verbose:  true,
+			body:     strings.Repeat("x", 2*maxErrorBodySize),
+			wantSize: maxErrorBodySize + len("..."),
+		},
+	}
 
-	buf := new(bytes.Buffer)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
 
-	_ = json.NewEncoder(buf).Encode(map[string][]Resource{
-		"data": {
-			{GID: "1", Name: "Test1", ResourceType: "project"},
-			{GID: "2", Name: "Test2", ResourceType: "project"},
-		},
-	})
+			client, _ := internal.NewClient("secret-token", 600)
+			app := &app{
+				cfg: &config{
+					entrypoint:  server.URL,
+					resource:    "project",
+					rate:        600,
+					pageSize:    defaultPageSize,
+					verboseErrs: tt.verbose,
+				},
+				log:    slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+				client: client,
+			}
 
-	rcDir := filepath.Join(t.TempDir(), "project")
-	err := app.export(ctx, buf.Bytes(), rcDir)
-	if err != context.Canceled {
-		t.Errorf("Expected context.Canceled error, got %v", err)
+			_, err := collectPages(app)
+			var apiErr *internal.APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("fetchData() error = %v, want APIError", err)
+			}
+			if tt.wantSize > 0 {
+				if len(apiErr.Body) != tt.wantSize {
+					t.Errorf("APIError body size = %d, want %d", len(apiErr.Body), tt.wantSize)
+				}
+				return
+			}
+			if apiErr.Body != tt.want {
+				t.Errorf("APIError body = %q, want %q", apiErr.Body, tt.want)
+			}
+			if tt.want != "" && !strings.HasSuffix(err.Error(), ": "+tt.want) {
+				t.Errorf("error = %q, want body in message", err.Error())
+			}
+		})
 	}
 }
 
-// func TestAppFetchDataRateLimit(t *testing.T) {
-// 	callCount := 0
-// 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-// 		callCount++
-// 		if callCount == 1 {
-// 			w.Header().Set("Retry-After", "1")
-// 			w.WriteHeader(http.StatusTooManyRequests)
-// 			return
-// 		}
-// 		w.Header().Set("Content-Type", "application/json")
-// 		json.NewEncoder(w).Encode(map[string][]Resource{
-// 			"data": {{GID: "1", Name: "Test", ResourceType: "project"}},
-// 		})
-// 	}))
-// 	defer server.Close()
-
-// 	app := &app{
-// 		cfg: &config{
-// 			entrypoint: server.URL,
-// 			resource:   "project",
-// 			rate:       60,
-// 		},
-// 		log: slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
-// 	}
-
-// 	ctx := context.Background()
-// 	data, err := app.fetchData(ctx)
-// 	if err != nil {
-// 		t.Errorf("fetchData() error = %v", err)
-// 	}
-// 	if len(data) == 0 {
-// 		t.Error("fetchData() returned empty data")
-// 	}
-// 	if callCount != 2 {
-// 		t.Errorf("Expected 2 API calls, got %d", callCount)
-// 	}
-// }
-
 func TestAppResources(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -162,6 +1075,229 @@ func TestAppResources(t *testing.T) {
 	}
 }
 
+func TestResourcePreservesFields(t *testing.T) {
+	input := `{"gid":"1","name":"Test","resource_type":"project","notes":"keep me","owner":{"gid":"2"}}`
+
+	var rc Resource
+	if err := json.Unmarshal([]byte(input), &rc); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if rc.GID != "1" || rc.Name != "Test" || rc.ResourceType != "project" {
+		t.Errorf("Unmarshal() core fields = %+v", rc)
+	}
+
+	got, err := json.Marshal(rc)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if string(got) != input {
+		t.Errorf("Marshal() = %s, want %s", got, input)
+	}
+}
+
+func TestAppRunExportCompletedSince(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("completed_since"); got != "2024-06-01T00:00:00Z" {
+			t.Errorf("Expected completed_since 2024-06-01T00:00:00Z, got %q", got)
+		}
+		_, _ = w.Write([]byte(`{"data": [{"gid": "1", "name": "Open task", "resource_type": "task"}], "next_page": null}`))
+	}))
+	defer server.Close()
+
+	client, _ := internal.NewClient("token", 600)
+	app := &app{
+		cfg: &config{
+			entrypoint:     server.URL,
+			resource:       "task",
+			rate:           600,
+			pageSize:       defaultPageSize,
+			dataDir:        t.TempDir(),
+			completedSince: "2024-06-01T00:00:00Z",
+		},
+		log:    slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+		client: client,
+	}
+
+	if err := app.runExport(context.Background()); err != nil {
+		t.Fatalf("runExport() error = %v", err)
+	}
+}
+
+func TestAppPageEndpoint(t *testing.T) {
+	tests := []struct {
+		name      string
+		resource  string
+		workspace string
+		owner     string
+		parent    string
+		optFields []string
+		optPretty bool
+		offset    string
+		want      string
+	}{
+		{
+			name: "first page",
+			want: "https://example.com/projects?limit=100",
+		},
+		{
+			name:      "with fields",
+			optFields: []string{"name", "notes", "owner.name"},
+			want:      "https://example.com/projects?limit=100&opt_fields=name%2Cnotes%2Cowner.name",
+		},
+		{
+			name:   "with offset",
+			offset: "abc+/=",
+			want:   "https://example.com/projects?limit=100&offset=abc%2B%2F%3D",
+		},
+		{
+			name:      "with opt_pretty",
+			optPretty: true,
+			want:      "https://example.com/projects?limit=100&opt_pretty=true",
+		},
+		{
+			name:      "with workspace",
+			resource:  "goal",
+			workspace: "12345",
+			want:      "https://example.com/goals?limit=100&workspace=12345",
+		},
+		{
+			name:      "with owner",
+			resource:  "portfolio",
+			workspace: "12345",
+			owner:     "me",
+			want:      "https://example.com/portfolios?limit=100&owner=me&workspace=12345",
+		},
+		{
+			name:      "with parent",
+			resource:  "section",
+			workspace: "12345",
+			parent:    "678",
+			want:      "https://example.com/projects/678/sections?limit=100",
+		},
+		{
+			name:      "in workspace",
+			resource:  "custom_field",
+			workspace: "12345",
+			want:      "https://example.com/workspaces/12345/custom_fields?limit=100",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resource := tt.resource
+			if resource == "" {
+				resource = "project"
+			}
+			app := &app{
+				cfg: &config{
+					entrypoint: "https://example.com",
+					resource:   resource,
+					workspace:  tt.workspace,
+					owner:      tt.owner,
+					pageSize:   defaultPageSize,
+					optFields:  tt.optFields,
+					optPretty:  tt.optPretty,
+				},
+			}
+
+			if got := app.pageEndpoint(nil, tt.parent, tt.offset); got != tt.want {
+				t.Errorf("pageEndpoint() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAppVerifyCount(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{"no declared count", `{"data": [{"gid": "1"}]}`, false},
+		{"matching count", `{"data": [{"gid": "1"}, {"gid": "2"}], "count": 2}`, false},
+		{"truncated page", `{"data": [{"gid": "1"}], "count": 2}`, true},
+		{"malformed page", `{"data": [{"gid": "1"}`, true},
+	}
+
+	app := &app{cfg: &config{resource: "project"}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := app.verifyCount([]byte(tt.data)); (err != nil) != tt.wantErr {
+				t.Errorf("verifyCount() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAppFilters(t *testing.T) {
+	now := time.Date(2024, 6, 8, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		cfg  config
+		want string
+	}{
+		{
+			name: "no filters",
+			cfg:  config{},
+			want: "",
+		},
+		{
+			name: "absolute modified since",
+			cfg:  config{modifiedSince: "2024-06-01T00:00:00Z"},
+			want: "modified_since=2024-06-01T00%3A00%3A00Z",
+		},
+		{
+			name: "relative since",
+			cfg:  config{sinceDuration: 7 * 24 * time.Hour},
+			want: "modified_since=2024-06-01T12%3A00%3A00Z",
+		},
+		{
+			name: "completed since with modified since",
+			cfg:  config{modifiedSince: "2024-06-01T00:00:00Z", completedSince: completedSinceNow},
+			want: "completed_since=now&modified_since=2024-06-01T00%3A00%3A00Z",
+		},
+		{
+			name: "incomplete tasks",
+			cfg:  config{completed: completedFalse},
+			want: "completed_since=now",
+		},
+		{
+			name: "completed tasks filtered client-side",
+			cfg:  config{completed: completedTrue},
+			want: "",
+		},
+		{
+			name: "all tasks",
+			cfg:  config{completed: completedAll},
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := &app{cfg: &tt.cfg}
+			if got := app.filters(now).Encode(); got != tt.want {
+				t.Errorf("filters() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAppFiltersSlidingWindow(t *testing.T) {
+	app := &app{cfg: &config{sinceDuration: time.Hour}}
+	start := time.Date(2024, 6, 8, 12, 0, 0, 0, time.UTC)
+
+	first := app.filters(start).Get("modified_since")
+	next := app.filters(start.Add(10 * time.Minute)).Get("modified_since")
+	if first == next {
+		t.Errorf("filters() window did not slide between runs: %v", first)
+	}
+	if next != "2024-06-08T11:10:00Z" {
+		t.Errorf("filters() = %v, want %v", next, "2024-06-08T11:10:00Z")
+	}
+}
+
 func TestAppResourceDir(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -220,8 +1356,225 @@ func TestAppResourceDir(t *testing.T) {
 	}
 }
 
+func TestAppResourceDirFileCollision(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "export", "task")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	if err := os.WriteFile(path, []byte("test"), 0600); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	app := &app{
+		cfg: &config{resource: "task"},
+		log: slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+	}
+
+	err := app.resourceDir(path)
+	if err == nil {
+		t.Fatal("resourceDir() expected error for file collision")
+	}
+	if !strings.Contains(err.Error(), path) {
+		t.Errorf("resourceDir() error = %q, want it to contain full path %q", err, path)
+	}
+	if !strings.Contains(err.Error(), "remove or rename") {
+		t.Errorf("resourceDir() error = %q, want a suggestion to remove or rename the file", err)
+	}
+}
+
+func TestAppResourceFilename(t *testing.T) {
+	now := time.Date(2024, 2, 5, 14, 30, 22, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		rcName    string
+		maxLength int
+		want      string
+	}{
+		{
+			name:      "short name",
+			rcName:    "MyProject",
+			maxLength: defaultMaxFilenameLength,
+			want:      "project_MyProject_20240205143022.json",
+		},
+		{
+			name:      "no limit",
+			rcName:    strings.Repeat("a", 300),
+			maxLength: 0,
+			want:      "project_" + strings.Repeat("a", 300) + "_20240205143022.json",
+		},
+		{
+			name:      "truncated at rune boundary",
+			rcName:    "Projekt_äöü",
+			maxLength: len("project_Projekt_ä_20240205143022.json") + 1,
+			want:      "project_Projekt_ä_20240205143022.json",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := &app{cfg: &config{resource: "project", maxFilenameLength: tt.maxLength}}
+			if got := app.resourceFilename(tt.rcName, now); got != tt.want {
+				t.Errorf("resourceFilename() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAppTaggedFilename(t *testing.T) {
+	now := time.Date(2024, 2, 5, 14, 30, 22, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		noTimestamp bool
+		maxLength   int
+		want        string
+	}{
+		{"timestamped", false, 0, "project_Same_42_20240205143022.json"},
+		{"no timestamp", true, 0, "project_Same_42.json"},
+		{"tag kept when truncated", true, len("project_Sa_42.json"), "project_Sa_42.json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := &app{cfg: &config{resource: "project", noTimestamp: tt.noTimestamp, maxFilenameLength: tt.maxLength}}
+			if got := app.taggedFilename("Same", "42", now); got != tt.want {
+				t.Errorf("taggedFilename() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAppExportLongName(t *testing.T) {
+	dataDir := t.TempDir()
+	app := &app{
+		cfg: &config{
+			resource:          "project",
+			dataDir:           dataDir,
+			maxFilenameLength: defaultMaxFilenameLength,
+		},
+		log: slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+	}
+
+	data, _ := json.Marshal(map[string][]Resource{
+		"data": {{GID: "1", Name: strings.Repeat("n", 1000), ResourceType: "project"}},
+	})
+
+	if err := app.export(context.Background(), data, dataDir, app.newSink(dataDir, time.Now()), &summary{}); err != nil {
+		t.Fatalf("export() error = %v", err)
+	}
+
+	files, err := os.ReadDir(filepath.Join(dataDir, "project"))
+	if err != nil {
+		t.Fatalf("Failed to read directory: %v", err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("Expected 1 file, got %d", len(files))
+	}
+	name := files[0].Name()
+	if len(name) != defaultMaxFilenameLength {
+		t.Errorf("file name length = %d, want %d", len(name), defaultMaxFilenameLength)
+	}
+	if !strings.HasPrefix(name, "project_") || !exportFilePattern("project").MatchString(name) {
+		t.Errorf("file name %q does not keep the resource type, timestamp, and extension", name)
+	}
+}
+
+func TestAppExportEmptyName(t *testing.T) {
+	tests := []struct {
+		name        string
+		placeholder string
+		rcName      string
+		wantPrefix  string
+	}{
+		{"default placeholder", defaultEmptyName, "", "project_unnamed_"},
+		{"blank name", defaultEmptyName, "  ", "project_unnamed_"},
+		{"gid", gidPlaceholder, "", "project_42_"},
+		{"gid in placeholder", "untitled-" + gidPlaceholder, "", "project_untitled-42_"},
+		{"named resource", defaultEmptyName, "Roadmap", "project_Roadmap_"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dataDir := t.TempDir()
+			app := &app{
+				cfg: &config{resource: "project", dataDir: dataDir, emptyName: tt.placeholder},
+				log: slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+			}
+
+			data, _ := json.Marshal(map[string][]Resource{
+				"data": {{GID: "42", Name: tt.rcName, ResourceType: "project"}},
+			})
+			if err := app.export(context.Background(), data, dataDir, app.newSink(dataDir, time.Now()), &summary{}); err != nil {
+				t.Fatalf("export() error = %v", err)
+			}
+
+			files, err := os.ReadDir(filepath.Join(dataDir, "project"))
+			if err != nil {
+				t.Fatalf("Failed to read directory: %v", err)
+			}
+			if len(files) != 1 {
+				t.Fatalf("Expected 1 file, got %d", len(files))
+			}
+			if name := files[0].Name(); !strings.HasPrefix(name, tt.wantPrefix) {
+				t.Errorf("file name = %q, want prefix %q", name, tt.wantPrefix)
+			}
+		})
+	}
+}
+
+func TestAppExportWriteDelay(t *testing.T) {
+	data, _ := json.Marshal(map[string][]Resource{
+		"data": {
+			{GID: "1", Name: "First", ResourceType: "project"},
+			{GID: "2", Name: "Second", ResourceType: "project"},
+			{GID: "3", Name: "Third", ResourceType: "project"},
+		},
+	})
+
+	t.Run("paced", func(t *testing.T) {
+		dataDir := t.TempDir()
+		app := &app{
+			cfg: &config{resource: "project", dataDir: dataDir, writeDelay: 20 * time.Millisecond},
+			log: slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+		}
+
+		sum := &summary{}
+		start := time.Now()
+		if err := app.export(context.Background(), data, dataDir, app.newSink(dataDir, time.Now()), sum); err != nil {
+			t.Fatalf("export() error = %v", err)
+		}
+		if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+			t.Errorf("export() took %v, want at least two delays of 20ms", elapsed)
+		}
+		if sum.written != 3 {
+			t.Errorf("written = %d, want 3", sum.written)
+		}
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		dataDir := t.TempDir()
+		app := &app{
+			cfg: &config{resource: "project", dataDir: dataDir, writeDelay: time.Hour},
+			log: slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		sum := &summary{}
+		if err := app.export(ctx, data, dataDir, app.newSink(dataDir, time.Now()), sum); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("export() error = %v, want %v", err, context.DeadlineExceeded)
+		}
+		if sum.written != 1 {
+			t.Errorf("written = %d, want 1", sum.written)
+		}
+	})
+}
+
 func TestAppStoreResource(t *testing.T) {
-	rcDir := filepath.Join(t.TempDir(), "project")
+	dataDir := t.TempDir()
+	rcDir := filepath.Join(dataDir, "project")
 
 	resource := Resource{
 		GID:          "1",
@@ -234,6 +1587,7 @@ func TestAppStoreResource(t *testing.T) {
 			entrypoint: "example.com",
 			resource:   "project",
 			rate:       60,
+			dataDir:    dataDir,
 		},
 		log: slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
 	}
@@ -244,7 +1598,7 @@ func TestAppStoreResource(t *testing.T) {
 
 	filename := rcDir + "/" + fmt.Sprintf("%s_%s.json", resource.Name, time.Now().Format("20060102150405"))
 
-	if err := app.storeResource(resource, filename); err != nil {
+	if err := app.storeResource(context.Background(), resource, filename); err != nil {
 		t.Fatalf("Failed to store resource: %v", err)
 	}
 
@@ -271,3 +1625,66 @@ func TestAppStoreResource(t *testing.T) {
 		t.Error("Stored resource does not match original")
 	}
 }
+
+func TestAppRetryWrite(t *testing.T) {
+	tests := []struct {
+		name      string
+		retries   int
+		failures  int
+		err       error
+		wantCalls int
+		wantErr   bool
+	}{
+		{"transient error retried", 3, 2, syscall.EAGAIN, 3, false},
+		{"stale handle retried", 1, 1, syscall.ESTALE, 2, false},
+		{"retries exhausted", 1, 5, syscall.EAGAIN, 2, true},
+		{"no retries", 0, 1, syscall.EAGAIN, 1, true},
+		{"disk full not retried", 3, 1, syscall.ENOSPC, 1, true},
+		{"permission denied not retried", 3, 1, &os.PathError{Op: "open", Path: "f", Err: syscall.EACCES}, 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := &app{
+				cfg: &config{writeRetries: tt.retries},
+				log: slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+			}
+
+			var calls int
+			err := app.retryWrite(context.Background(), "file.json", func() error {
+				calls++
+				if calls <= tt.failures {
+					return fmt.Errorf("write file: %w", tt.err)
+				}
+				return nil
+			})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("retryWrite() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("Expected %d calls, got %d", tt.wantCalls, calls)
+			}
+		})
+	}
+}
+
+func TestAppRetryWriteCanceled(t *testing.T) {
+	app := &app{
+		cfg: &config{writeRetries: maxRetries},
+		log: slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var calls int
+	err := app.retryWrite(ctx, "file.json", func() error {
+		calls++
+		cancel()
+		return fmt.Errorf("write file: %w", syscall.EAGAIN)
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("retryWrite() error = %v, want %v", err, context.Canceled)
+	}
+	if calls != 1 {
+		t.Errorf("Expected 1 call, got %d", calls)
+	}
+}
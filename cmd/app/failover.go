@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/marintailor/asana-resource-exporter/internal"
+)
+
+// entrypoint returns the entrypoint requests of the current run are sent to:
+// the fallback entrypoint once the run failed over, the configured one
+// otherwise.
+func (a *app) entrypoint() string {
+	if a.failedOver {
+		return a.cfg.fallbackEntrypoint
+	}
+	return a.cfg.entrypoint
+}
+
+// failover handles err, returned by a page request, and reports whether the
+// page should be requested again. With a fallback entrypoint, a page failing
+// on the primary entrypoint without a response or with a 5xx status, after
+// the configured request retries, is retried with backoff until it has failed
+// failover-after times in a row, counted in failures, at which point the rest
+// of the run is sent to the fallback entrypoint. The next run starts on the
+// primary entrypoint again.
+func (a *app) failover(ctx context.Context, err error, failures *int) (bool, error) {
+	if a.cfg.fallbackEntrypoint == "" || a.failedOver || !failoverError(err) {
+		return false, nil
+	}
+
+	*failures++
+	if *failures < a.cfg.failoverAfter {
+		return true, a.backoff(ctx, err.Error(), *failures, a.cfg.failoverAfter)
+	}
+
+	a.log.Warn("failing over to fallback entrypoint",
+		slog.String("from", a.client.Redact(a.cfg.entrypoint)),
+		slog.String("to", a.client.Redact(a.cfg.fallbackEntrypoint)),
+		slog.Int("failures", *failures),
+		slog.String("error", a.client.Redact(err.Error())))
+	a.failedOver = true
+
+	return true, nil
+}
+
+// failoverError reports whether err indicates an unavailable entrypoint: a
+// request that failed without a response or was answered with a 5xx status.
+// Other responses show the entrypoint is up, and cancellation is no failure.
+func failoverError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var apiErr *internal.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode >= 500
+	}
+
+	return true
+}
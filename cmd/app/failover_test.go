@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/marintailor/asana-resource-exporter/internal"
+)
+
+func TestAppRunExportFailover(t *testing.T) {
+	var primaryDown atomic.Bool
+	var primaryCalls, fallbackCalls atomic.Int32
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		primaryCalls.Add(1)
+		if primaryDown.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data": [{"gid": "1", "name": "Primary", "resource_type": "project"}], "next_page": null}`))
+	}))
+	defer primary.Close()
+
+	fallback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fallbackCalls.Add(1)
+		_, _ = w.Write([]byte(`{"data": [{"gid": "2", "name": "Fallback", "resource_type": "project"}], "next_page": null}`))
+	}))
+	defer fallback.Close()
+
+	var logs strings.Builder
+	dataDir := t.TempDir()
+	client, _ := internal.NewClient("token", 600)
+	app := &app{
+		cfg: &config{
+			entrypoint:         primary.URL,
+			fallbackEntrypoint: fallback.URL,
+			failoverAfter:      1,
+			resource:           "project",
+			rate:               600,
+			pageSize:           defaultPageSize,
+			dataDir:            dataDir,
+			emptyName:          defaultEmptyName,
+			outputFormat:       formatJSON,
+		},
+		log:    slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{})),
+		client: client,
+	}
+
+	primaryDown.Store(true)
+	if err := app.runExport(context.Background()); err != nil {
+		t.Fatalf("runExport() error = %v", err)
+	}
+	if primaryCalls.Load() != 1 || fallbackCalls.Load() != 1 {
+		t.Errorf("Expected 1 primary and 1 fallback call, got %d and %d", primaryCalls.Load(), fallbackCalls.Load())
+	}
+	if !strings.Contains(logs.String(), `"msg":"failing over to fallback entrypoint"`) {
+		t.Errorf("Expected the failover to be logged, got %s", logs.String())
+	}
+
+	// The next run starts on the primary entrypoint again.
+	primaryDown.Store(false)
+	if err := app.runExport(context.Background()); err != nil {
+		t.Fatalf("runExport() error = %v", err)
+	}
+	if primaryCalls.Load() != 2 || fallbackCalls.Load() != 1 {
+		t.Errorf("Expected 2 primary and 1 fallback calls, got %d and %d", primaryCalls.Load(), fallbackCalls.Load())
+	}
+
+	for _, name := range []string{"Fallback", "Primary"} {
+		files, _ := filepath.Glob(filepath.Join(dataDir, "project", "project_"+name+"_*.json"))
+		if len(files) != 1 {
+			t.Errorf("Expected 1 file of %s, got %d", name, len(files))
+		}
+	}
+}
+
+func TestAppRunExportFailoverClientError(t *testing.T) {
+	var fallbackCalls atomic.Int32
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer primary.Close()
+
+	fallback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fallbackCalls.Add(1)
+	}))
+	defer fallback.Close()
+
+	client, _ := internal.NewClient("token", 600)
+	app := &app{
+		cfg: &config{
+			entrypoint:         primary.URL,
+			fallbackEntrypoint: fallback.URL,
+			failoverAfter:      1,
+			resource:           "project",
+			rate:               600,
+			pageSize:           defaultPageSize,
+			dataDir:            t.TempDir(),
+			outputFormat:       formatJSON,
+		},
+		log:    slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+		client: client,
+	}
+
+	var apiErr *internal.APIError
+	if err := app.runExport(context.Background()); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("runExport() error = %v, want 404 API error", err)
+	}
+	if fallbackCalls.Load() != 0 {
+		t.Errorf("Expected no failover on a client error, got %d fallback calls", fallbackCalls.Load())
+	}
+}
+
+func TestFailoverError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"network error", fmt.Errorf("make request: %w", os.ErrDeadlineExceeded), true},
+		{"server error", &internal.APIError{StatusCode: http.StatusServiceUnavailable}, true},
+		{"client error", &internal.APIError{StatusCode: http.StatusForbidden}, false},
+		{"canceled", context.Canceled, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := failoverError(tt.err); got != tt.want {
+				t.Errorf("failoverError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewConfigFallbackEntrypoint(t *testing.T) {
+	tests := []struct {
+		name          string
+		entrypoint    string
+		fallback      string
+		failoverAfter int
+		wantErr       bool
+	}{
+		{"valid", defaultEntrypoint, "https://gateway.example.com/api/1.0", 3, false},
+		{"invalid fallback", defaultEntrypoint, "gateway.example.com", 3, true},
+		{"invalid primary", "app.asana.com/api/1.0", "https://gateway.example.com/api/1.0", 3, true},
+		{"same as primary", defaultEntrypoint, defaultEntrypoint, 3, true},
+		{"unset variable in fallback", defaultEntrypoint, "https://${ASANA_TEST_UNSET_GATEWAY}/api/1.0", 3, true},
+		{"fallback expanding to primary", defaultEntrypoint, "${ASANA_TEST_FALLBACK}", 3, true},
+		{"failover after zero", defaultEntrypoint, "https://gateway.example.com/api/1.0", 0, true},
+	}
+
+	t.Setenv("ASANA_TEST_FALLBACK", defaultEntrypoint)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newConfig(options{cfg: config{
+				entrypoint:         tt.entrypoint,
+				fallbackEntrypoint: tt.fallback,
+				failoverAfter:      tt.failoverAfter,
+				resource:           "project",
+				workspace:          "12345",
+				rate:               60,
+				pageSize:           defaultPageSize,
+			}})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("newConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// filterOperators lists the supported comparison operators.
+var filterOperators = []string{"==", "!=", "^=", "$=", "*="}
+
+// filterExpr is a parsed -filter-expr predicate: a disjunction (||) of
+// conjunctions (&&) of conditions. && binds tighter than ||.
+type filterExpr [][]condition
+
+// condition compares a resource field, addressed by a dotted path such as
+// "owner.name", against a literal value.
+type condition struct {
+	path  []string // Field path within the resource object
+	op    string   // Comparison operator, one of filterOperators
+	value string   // Literal value compared against the field
+}
+
+// parseFilter parses a predicate such as `resource_type==project && name^=Q3`.
+// Supported operators are == (equal), != (not equal), ^= (prefix),
+// $= (suffix) and *= (contains). Values are compared as strings and may be
+// wrapped in double quotes to keep surrounding whitespace or to contain && and
+// ||, which only separate conditions outside quotes.
+func parseFilter(s string) (filterExpr, error) {
+	ors, err := splitUnquoted(s, "||")
+	if err != nil {
+		return nil, err
+	}
+
+	var expr filterExpr
+	for _, or := range ors {
+		ands, err := splitUnquoted(or, "&&")
+		if err != nil {
+			return nil, err
+		}
+
+		var conds []condition
+		for _, and := range ands {
+			cond, err := parseCondition(strings.TrimSpace(and))
+			if err != nil {
+				return nil, err
+			}
+			conds = append(conds, cond)
+		}
+		expr = append(expr, conds)
+	}
+
+	return expr, nil
+}
+
+// splitUnquoted splits s around each occurrence of sep that is not within a
+// double-quoted string.
+func splitUnquoted(s, sep string) ([]string, error) {
+	var parts []string
+	start, quoted := 0, false
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '"':
+			quoted = !quoted
+		case !quoted && strings.HasPrefix(s[i:], sep):
+			parts = append(parts, s[start:i])
+			start = i + len(sep)
+			i += len(sep) - 1
+		}
+	}
+	if quoted {
+		return nil, fmt.Errorf("expression %q: unterminated quote", s)
+	}
+
+	return append(parts, s[start:]), nil
+}
+
+// parseCondition parses a single `field<op>value` comparison.
+func parseCondition(s string) (condition, error) {
+	if s == "" {
+		return condition{}, errors.New("empty condition")
+	}
+
+	idx, op := -1, ""
+	for _, candidate := range filterOperators {
+		if i := strings.Index(s, candidate); i >= 0 && (idx < 0 || i < idx) {
+			idx, op = i, candidate
+		}
+	}
+	if idx < 0 {
+		return condition{}, fmt.Errorf("condition %q: missing operator", s)
+	}
+
+	field := strings.TrimSpace(s[:idx])
+	if field == "" {
+		return condition{}, fmt.Errorf("condition %q: missing field", s)
+	}
+
+	value := strings.TrimSpace(s[idx+len(op):])
+	if len(value) >= 2 && strings.HasPrefix(value, `"`) && strings.HasSuffix(value, `"`) {
+		value = value[1 : len(value)-1]
+	}
+
+	return condition{path: strings.Split(field, "."), op: op, value: value}, nil
+}
+
+// and returns the expression with c required in addition, or an expression
+// of c alone if f is nil.
+func (f filterExpr) and(c condition) filterExpr {
+	if f == nil {
+		return filterExpr{{c}}
+	}
+
+	expr := make(filterExpr, len(f))
+	for i, conds := range f {
+		expr[i] = append(slices.Clone(conds), c)
+	}
+
+	return expr
+}
+
+// match reports whether the resource satisfies the expression.
+func (f filterExpr) match(rc Resource) (bool, error) {
+	data, err := json.Marshal(rc)
+	if err != nil {
+		return false, fmt.Errorf("marshal resource: %w", err)
+	}
+
+	var obj map[string]any
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return false, fmt.Errorf("unmarshal resource: %w", err)
+	}
+
+	for _, conds := range f {
+		ok := true
+		for _, c := range conds {
+			if !c.match(obj) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// match evaluates the condition against a decoded resource object. Missing
+// fields and null values compare as the empty string.
+func (c condition) match(obj map[string]any) bool {
+	var v any = obj
+	for _, key := range c.path {
+		m, ok := v.(map[string]any)
+		if !ok {
+			v = nil
+			break
+		}
+		v = m[key]
+	}
+
+	var field string
+	switch val := v.(type) {
+	case nil:
+	case string:
+		field = val
+	default:
+		b, _ := json.Marshal(val)
+		field = string(b)
+	}
+
+	switch c.op {
+	case "==":
+		return field == c.value
+	case "!=":
+		return field != c.value
+	case "^=":
+		return strings.HasPrefix(field, c.value)
+	case "$=":
+		return strings.HasSuffix(field, c.value)
+	default:
+		return strings.Contains(field, c.value)
+	}
+}
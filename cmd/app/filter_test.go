@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func TestParseFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		wantErr bool
+	}{
+		{"single condition", "name==Test", false},
+		{"conjunction", "resource_type==project && name^=Q3", false},
+		{"disjunction", "name^=Q3 || name^=Q4", false},
+		{"nested field", "owner.name*=Smith", false},
+		{"quoted value", `name=="Q3 Plan "`, false},
+		{"missing operator", "name", true},
+		{"missing field", "==project", true},
+		{"empty condition", "name==Test &&", true},
+		{"quoted separators", `name=="R&&D" || name=="A||B"`, false},
+		{"unterminated quote", `name=="R&&D`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseFilter(tt.expr)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseFilter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseFilterQuotedSeparators(t *testing.T) {
+	expr, err := parseFilter(`name=="R&&D" || owner.name^="A||B" && name!=x`)
+	if err != nil {
+		t.Fatalf("parseFilter() error = %v", err)
+	}
+
+	want := filterExpr{
+		{{path: []string{"name"}, op: "==", value: "R&&D"}},
+		{{path: []string{"owner", "name"}, op: "^=", value: "A||B"}, {path: []string{"name"}, op: "!=", value: "x"}},
+	}
+	if len(expr) != len(want) {
+		t.Fatalf("parseFilter() = %v, want %v", expr, want)
+	}
+	for i := range want {
+		if !slices.EqualFunc(expr[i], want[i], func(a, b condition) bool {
+			return slices.Equal(a.path, b.path) && a.op == b.op && a.value == b.value
+		}) {
+			t.Errorf("parseFilter() disjunct %d = %v, want %v", i, expr[i], want[i])
+		}
+	}
+}
+
+func TestFilterExprMatch(t *testing.T) {
+	var rc Resource
+	input := `{"gid":"1","name":"Q3 Roadmap","resource_type":"project","archived":false,"owner":{"name":"Jane Smith"},"color":null}`
+	if err := json.Unmarshal([]byte(input), &rc); err != nil {
+		t.Fatalf("Failed to unmarshal resource: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		expr string
+		want bool
+	}{
+		{"equal", "resource_type==project", true},
+		{"not equal", "resource_type!=project", false},
+		{"prefix", "name^=Q3", true},
+		{"suffix", "name$=map", true},
+		{"contains", "name*=Road", true},
+		{"conjunction match", "resource_type==project && name^=Q3", true},
+		{"conjunction mismatch", "resource_type==project && name^=Q4", false},
+		{"disjunction", "name^=Q4 || name^=Q3", true},
+		{"boolean field", "archived==false", true},
+		{"nested field", "owner.name*=Smith", true},
+		{"null field", "color==", true},
+		{"missing field", "notes==", true},
+		{"missing nested field", "owner.email^=jane", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := parseFilter(tt.expr)
+			if err != nil {
+				t.Fatalf("parseFilter() error = %v", err)
+			}
+
+			got, err := expr.match(rc)
+			if err != nil {
+				t.Fatalf("match() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewConfigCompletedTrue(t *testing.T) {
+	tests := []struct {
+		name       string
+		filterExpr string
+		input      string
+		want       bool
+	}{
+		{"completed task", "", `{"gid":"1","name":"Done","completed":true}`, true},
+		{"incomplete task", "", `{"gid":"2","name":"Open","completed":false}`, false},
+		{"combined with filter expression", "name^=Q3 || name^=Q4", `{"gid":"3","name":"Q4 Plan","completed":true}`, true},
+		{"filter expression mismatch", "name^=Q3 || name^=Q4", `{"gid":"4","name":"Q1 Plan","completed":true}`, false},
+		{"incomplete task matching filter expression", "name^=Q3 || name^=Q4", `{"gid":"5","name":"Q3 Plan","completed":false}`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := newConfig(options{cfg: config{
+				entrypoint: defaultEntrypoint,
+				resource:   "task",
+				rate:       60,
+				pageSize:   defaultPageSize,
+				fields:     "name",
+				filterExpr: tt.filterExpr,
+				completed:  completedTrue,
+			}})
+			if err != nil {
+				t.Fatalf("newConfig() error = %v", err)
+			}
+			if !slices.Contains(cfg.optFields, "completed") {
+				t.Errorf("newConfig() fields = %v, want completed included", cfg.optFields)
+			}
+
+			var rc Resource
+			if err := json.Unmarshal([]byte(tt.input), &rc); err != nil {
+				t.Fatalf("Failed to unmarshal resource: %v", err)
+			}
+			got, err := cfg.filter.match(rc)
+			if err != nil {
+				t.Fatalf("match() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+)
+
+// rawFilePattern returns a pattern matching the names of raw response pages
+// stored for resource with preserve-raw: {resource}_{timestamp}_{page}.json.
+func rawFilePattern(resource string) *regexp.Regexp {
+	return regexp.MustCompile(`^` + regexp.QuoteMeta(resource) + `_` + timestampPattern + `_\d{4}\.json$`)
+}
+
+// fetchRaw replays raw response pages previously stored with preserve-raw
+// from the configured raw directory instead of fetching them from the API.
+// Pages are passed to handle in file name order, which is the order they
+// were fetched in. Stored batch responses are unwrapped into the standard
+// data envelope as during a GID export.
+func (a *app) fetchRaw(ctx context.Context, _ string, handle func(data []byte) error) error {
+	a.log.Debug("read raw pages", slog.String("from_raw", a.cfg.fromRaw))
+
+	entries, err := os.ReadDir(a.cfg.fromRaw)
+	if err != nil {
+		return fmt.Errorf("read raw directory: %w", err)
+	}
+
+	pattern := rawFilePattern(a.cfg.resource)
+	var names []string
+	for _, e := range entries {
+		if e.Type().IsRegular() && pattern.MatchString(e.Name()) {
+			names = append(names, e.Name())
+		}
+	}
+	slices.Sort(names)
+
+	if len(names) == 0 {
+		return a.degrade(fmt.Errorf("no raw pages for %s in %s: %w", a.cfg.resource, a.cfg.fromRaw, errEmptyResult))
+	}
+
+	for _, name := range names {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		data, err := os.ReadFile(filepath.Join(a.cfg.fromRaw, name))
+		if err != nil {
+			return fmt.Errorf("read raw page: %w", err)
+		}
+
+		if results, ok := batchResults(data); ok {
+			if data, err = a.batchPage(results, nil); err != nil {
+				return err
+			}
+		}
+
+		a.log.Debug("replay raw page", slog.String("filename", name))
+		if err := handle(data); err != nil {
+			return fmt.Errorf("raw page %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// batchResults decodes data as a stored batch response. It reports false for
+// regular pages, whose items are resources rather than batch results.
+func batchResults(data []byte) ([]batchResult, bool) {
+	var resp struct {
+		Data []json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil || len(resp.Data) == 0 {
+		return nil, false
+	}
+
+	var probe struct {
+		StatusCode int             `json:"status_code"`
+		Body       json.RawMessage `json:"body"`
+	}
+	if err := json.Unmarshal(resp.Data[0], &probe); err != nil || probe.StatusCode == 0 || probe.Body == nil {
+		return nil, false
+	}
+
+	results := make([]batchResult, len(resp.Data))
+	for i, item := range resp.Data {
+		if err := json.Unmarshal(item, &results[i]); err != nil {
+			return nil, false
+		}
+	}
+
+	return results, true
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAppRunExportNoFetch(t *testing.T) {
+	tests := []struct {
+		name      string
+		files     map[string]string
+		strict    bool
+		wantCount int
+		wantErr   bool
+	}{
+		{
+			name: "pages in fetch order",
+			files: map[string]string{
+				"project_20240101000000_0002.json": `{"data": [{"gid": "2", "name": "Second", "resource_type": "project"}], "next_page": null}`,
+				"project_20240101000000_0001.json": `{"data": [{"gid": "1", "name": "First", "resource_type": "project"}], "next_page": {"offset": "a"}}`,
+				"task_20240101000000_0001.json":    `{"data": [{"gid": "3", "name": "Task", "resource_type": "task"}]}`,
+				"notes.txt":                        `ignored`,
+			},
+			wantCount: 2,
+		},
+		{
+			name: "zoned pages",
+			files: map[string]string{
+				"project_20241027023000+0200_0001.json": `{"data": [{"gid": "1", "name": "First", "resource_type": "project"}], "next_page": {"offset": "a"}}`,
+				"project_20241027023000+0200_0002.json": `{"data": [{"gid": "2", "name": "Second", "resource_type": "project"}], "next_page": null}`,
+			},
+			wantCount: 2,
+		},
+		{
+			name: "batch response",
+			files: map[string]string{
+				"project_20240101000000_0001.json": `{"data": [
+					{"status_code": 200, "body": {"data": {"gid": "1", "name": "First", "resource_type": "project"}}},
+					{"status_code": 404, "body": {"errors": [{"message": "Not Found"}]}}
+				]}`,
+			},
+			wantCount: 1,
+		},
+		{
+			name:      "no raw pages",
+			files:     map[string]string{},
+			wantCount: 0,
+		},
+		{
+			name:    "no raw pages in strict mode",
+			files:   map[string]string{},
+			strict:  true,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dataDir := t.TempDir()
+			rawDir := t.TempDir()
+			for name, content := range tt.files {
+				if err := os.WriteFile(filepath.Join(rawDir, name), []byte(content), 0o600); err != nil {
+					t.Fatalf("Failed to write raw page: %v", err)
+				}
+			}
+
+			app := &app{
+				cfg: &config{
+					resource: "project",
+					dataDir:  dataDir,
+					noFetch:  true,
+					fromRaw:  rawDir,
+					strict:   tt.strict,
+				},
+				log: slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+			}
+
+			err := app.runExport(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("runExport() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			files, _ := os.ReadDir(filepath.Join(dataDir, "project"))
+			if len(files) != tt.wantCount {
+				t.Errorf("Expected %d exported files, got %d", tt.wantCount, len(files))
+			}
+		})
+	}
+}
+
+func TestNewConfigNoFetch(t *testing.T) {
+	dataDir := t.TempDir()
+	rawDir := filepath.Join(dataDir, "project", rawDirName)
+	if err := os.MkdirAll(rawDir, 0o755); err != nil {
+		t.Fatalf("Failed to create raw directory: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		cfg     config
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "default raw directory",
+			cfg:  config{noFetch: true},
+			want: rawDir,
+		},
+		{
+			name: "explicit raw directory",
+			cfg:  config{noFetch: true, fromRaw: dataDir},
+			want: dataDir,
+		},
+		{
+			name:    "missing raw directory",
+			cfg:     config{noFetch: true, fromRaw: filepath.Join(dataDir, "missing")},
+			wantErr: true,
+		},
+		{
+			name:    "from raw without no fetch",
+			cfg:     config{fromRaw: rawDir},
+			wantErr: true,
+		},
+		{
+			name:    "combined with preserve raw",
+			cfg:     config{noFetch: true, preserveRaw: true},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.entrypoint = defaultEntrypoint
+			tt.cfg.resource = "project"
+			tt.cfg.rate = 60
+			tt.cfg.pageSize = defaultPageSize
+			tt.cfg.dataDir = dataDir
+
+			cfg, err := newConfig(options{cfg: tt.cfg})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && cfg.fromRaw != tt.want {
+				t.Errorf("newConfig() fromRaw = %q, want %q", cfg.fromRaw, tt.want)
+			}
+		})
+	}
+}
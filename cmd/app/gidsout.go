@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// gidsSink records the GID of every resource written through the wrapped
+// sink and writes them to the configured GIDs file, one per line in export
+// order, when closed. Resources dropped by the filter, schema validation, or
+// deduplication never reach the sink and are therefore not listed.
+type gidsSink struct {
+	sink
+	a    *app            // App providing the GIDs file path and logging
+	gids []string        // GIDs written during this run, in order
+	seen map[string]bool // GIDs already in gids
+}
+
+// write stores rc with the wrapped sink and records its GID.
+func (s *gidsSink) write(ctx context.Context, rc Resource) (string, error) {
+	filename, err := s.sink.write(ctx, rc)
+	if err != nil || filename == "" {
+		return filename, err
+	}
+
+	if !s.seen[rc.GID] {
+		s.seen[rc.GID] = true
+		s.gids = append(s.gids, rc.GID)
+	}
+
+	return filename, nil
+}
+
+// close closes the wrapped sink and replaces the GIDs file atomically, so
+// chained runs never read a partial list. Unless keep is set, as after a
+// failed or suspect run, the previous GIDs file is left in place.
+func (s *gidsSink) close(keep bool) error {
+	err := s.sink.close(keep)
+	if !keep {
+		s.a.log.Warn("gids file not updated, the run failed or its results are suspect", slog.String("filename", s.a.cfg.gidsOut))
+		return err
+	}
+
+	var data strings.Builder
+	for _, gid := range s.gids {
+		data.WriteString(gid + "\n")
+	}
+	if gerr := writeFileAtomic(s.a.cfg.gidsOut, []byte(data.String())); gerr != nil {
+		return errors.Join(err, fmt.Errorf("write gids: %w", gerr))
+	}
+	s.a.log.Debug("gids stored", slog.String("filename", s.a.cfg.gidsOut), slog.Int("count", len(s.gids)))
+
+	return err
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/marintailor/asana-resource-exporter/internal"
+)
+
+func TestAppExportGIDsOut(t *testing.T) {
+	dataDir := t.TempDir()
+	gidsOut := filepath.Join(t.TempDir(), "gids.txt")
+
+	filter, err := parseFilter("name^=Q3")
+	if err != nil {
+		t.Fatalf("parseFilter() error = %v", err)
+	}
+
+	app := &app{
+		cfg: &config{
+			resource: "project",
+			dataDir:  dataDir,
+			filter:   filter,
+			gidsOut:  gidsOut,
+		},
+		log:  slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+		seen: &seenSet{hashes: make(map[string]string)},
+	}
+
+	// Resource 3 is unchanged since a previous run and skipped by dedupe.
+	var unchanged Resource
+	if err := json.Unmarshal([]byte(`{"gid": "3", "name": "Q3 Review"}`), &unchanged); err != nil {
+		t.Fatalf("Failed to unmarshal resource: %v", err)
+	}
+	hash, err := contentHash(unchanged)
+	if err != nil {
+		t.Fatalf("contentHash() error = %v", err)
+	}
+	app.seen.mark("3", hash)
+
+	data := []byte(`{"data": [{"gid": "1", "name": "Q3 Plan"}, {"gid": "2", "name": "Q4 Plan"}, {"gid": "3", "name": "Q3 Review"}, {"gid": "4", "name": "Q3 Budget"}]}`)
+
+	out := app.newSink(dataDir, time.Now())
+	if err := app.export(context.Background(), data, dataDir, out, &summary{}); err != nil {
+		t.Fatalf("export() error = %v", err)
+	}
+	if _, err := os.Stat(gidsOut); err == nil {
+		t.Error("Expected GIDs file to be written when the sink is closed")
+	}
+	if err := out.close(true); err != nil {
+		t.Fatalf("close() error = %v", err)
+	}
+
+	got, err := os.ReadFile(gidsOut)
+	if err != nil {
+		t.Fatalf("Failed to read GIDs file: %v", err)
+	}
+	if string(got) != "1\n4\n" {
+		t.Errorf("GIDs file = %q, want %q", got, "1\n4\n")
+	}
+
+	tmp, _ := filepath.Glob(gidsOut + ".*.tmp")
+	if len(tmp) != 0 {
+		t.Errorf("Expected no temporary files left, got %v", tmp)
+	}
+}
+
+func TestAppRunExportGIDsOutFailedRun(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("offset") == "" {
+			_, _ = w.Write([]byte(`{"data": [{"gid": "1", "name": "Alpha"}], "next_page": {"offset": "page-2"}}`))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	gidsOut := filepath.Join(t.TempDir(), "gids.txt")
+	if err := os.WriteFile(gidsOut, []byte("1\n2\n3\n"), 0600); err != nil {
+		t.Fatalf("Failed to write gids file: %v", err)
+	}
+
+	client, _ := internal.NewClient("token", 600)
+	app := &app{
+		cfg: &config{
+			entrypoint:   server.URL,
+			resource:     "project",
+			rate:         600,
+			pageSize:     defaultPageSize,
+			dataDir:      t.TempDir(),
+			emptyName:    defaultEmptyName,
+			outputFormat: formatJSON,
+			gidsOut:      gidsOut,
+		},
+		log:    slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+		client: client,
+	}
+
+	if err := app.runExport(context.Background()); err == nil {
+		t.Fatal("runExport() error = nil, want error")
+	}
+
+	if data, _ := os.ReadFile(gidsOut); string(data) != "1\n2\n3\n" {
+		t.Errorf("Expected the gids file of the last good run to be kept, got %q", data)
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+)
+
+// governor is a weighted semaphore bounding the interval runs exporting at
+// once, the only operations of the app that run concurrently, as fan-outs
+// over workspaces and GID batches run sequentially. A nil governor imposes no
+// limit.
+type governor struct {
+	size int64        // Maximum total weight held at once
+	log  *slog.Logger // Logger notified when the cap is saturated
+
+	mu      sync.Mutex    // Guards cur and changed
+	cur     int64         // Weight currently held
+	changed chan struct{} // Closed and replaced on every release
+}
+
+// newGovernor returns a governor capping total weight at size, or nil when
+// size is zero, which disables the cap.
+func newGovernor(size int, log *slog.Logger) *governor {
+	if size == 0 {
+		return nil
+	}
+
+	return &governor{
+		size:    int64(size),
+		log:     log,
+		changed: make(chan struct{}),
+	}
+}
+
+// acquire blocks until n units are available or ctx is done. Saturation of
+// the cap is logged once per blocked call.
+func (g *governor) acquire(ctx context.Context, n int64) error {
+	if g == nil {
+		return nil
+	}
+	if n > g.size {
+		return fmt.Errorf("acquire %d exceeds max goroutines %d", n, g.size)
+	}
+
+	logged := false
+	for {
+		g.mu.Lock()
+		if g.cur+n <= g.size {
+			g.cur += n
+			g.mu.Unlock()
+			return nil
+		}
+		changed := g.changed
+		g.mu.Unlock()
+
+		if !logged {
+			g.log.Warn("max goroutines reached, waiting",
+				slog.Int64("max_goroutines", g.size))
+			logged = true
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// release returns n units acquired with acquire and wakes blocked callers.
+func (g *governor) release(n int64) {
+	if g == nil {
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.cur -= n
+	close(g.changed)
+	g.changed = make(chan struct{})
+}
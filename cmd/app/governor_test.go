@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGovernorNil(t *testing.T) {
+	g := newGovernor(0, slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})))
+	if g != nil {
+		t.Fatalf("newGovernor(0) = %v, want nil", g)
+	}
+
+	if err := g.acquire(context.Background(), 100); err != nil {
+		t.Errorf("acquire() on nil governor error = %v", err)
+	}
+	g.release(100)
+}
+
+func TestGovernorCap(t *testing.T) {
+	g := newGovernor(2, slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})))
+
+	var running, peak atomic.Int64
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := g.acquire(context.Background(), 1); err != nil {
+				t.Errorf("acquire() error = %v", err)
+				return
+			}
+			defer g.release(1)
+
+			n := running.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			running.Add(-1)
+		}()
+	}
+	wg.Wait()
+
+	if got := peak.Load(); got > 2 {
+		t.Errorf("peak concurrency = %d, want at most 2", got)
+	}
+}
+
+func TestGovernorAcquire(t *testing.T) {
+	g := newGovernor(2, slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})))
+
+	if err := g.acquire(context.Background(), 3); err == nil {
+		t.Error("acquire() above the cap expected error, got nil")
+	}
+
+	if err := g.acquire(context.Background(), 2); err != nil {
+		t.Fatalf("acquire() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := g.acquire(ctx, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("acquire() on saturated governor error = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	g.release(2)
+	if err := g.acquire(context.Background(), 1); err != nil {
+		t.Errorf("acquire() after release error = %v", err)
+	}
+}
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"slices"
+
+	"github.com/marintailor/asana-resource-exporter/internal"
+)
+
+// graphFileName is the name of the file, stored in the task directory, that
+// holds the relationship graph of the exported tasks.
+const graphFileName = "graph.json"
+
+// Relations of the edges of the task graph.
+const (
+	relationDependency = "dependency" // The task depends on the target task
+	relationDependent  = "dependent"  // The target task depends on the task
+	relationProject    = "project"    // The task is in the target project
+	relationSection    = "section"    // The task is in the target section
+)
+
+// graphFields are the opt_fields requested with export-graph, so the
+// memberships of exported tasks are known without further requests.
+var graphFields = []string{"memberships.project.name", "memberships.section.name"}
+
+// taskGraph is the adjacency list of the exported tasks and the tasks,
+// projects, and sections they are related to.
+type taskGraph struct {
+	Nodes  map[string]*graphNode `json:"nodes"`  // Nodes by GID
+	Cycles [][]string            `json:"cycles"` // GIDs of the tasks of every dependency cycle
+
+	tasks []string // GIDs of the exported tasks, in export order
+}
+
+// graphNode is a resource in the task graph with its outgoing edges.
+type graphNode struct {
+	Name         string      `json:"name,omitempty"`    // Resource name, unknown for missing tasks
+	ResourceType string      `json:"resource_type"`     // task, project, or section
+	Missing      bool        `json:"missing,omitempty"` // Referenced task that was not exported
+	Error        string      `json:"error,omitempty"`   // Why the task's relationships are unknown
+	Edges        []graphEdge `json:"edges"`             // Relationships of an exported task
+}
+
+// graphEdge relates a node to the node with GID.
+type graphEdge struct {
+	GID      string `json:"gid"`      // Target node
+	Relation string `json:"relation"` // One of the relation constants
+}
+
+// newTaskGraph returns an empty task graph.
+func newTaskGraph() *taskGraph {
+	return &taskGraph{Nodes: make(map[string]*graphNode), Cycles: [][]string{}}
+}
+
+// node returns the node with gid, adding it with resourceType if g does not
+// hold it yet. Added tasks are missing until they are exported.
+func (g *taskGraph) node(gid, resourceType string) *graphNode {
+	n, ok := g.Nodes[gid]
+	if !ok {
+		n = &graphNode{ResourceType: resourceType, Missing: resourceType == "task", Edges: []graphEdge{}}
+		g.Nodes[gid] = n
+	}
+	return n
+}
+
+// link adds an edge with relation from the node with gid from to the node
+// with gid to, unless it exists.
+func (g *taskGraph) link(from, to, relation string) {
+	n := g.Nodes[from]
+	edge := graphEdge{GID: to, Relation: relation}
+	if !slices.Contains(n.Edges, edge) {
+		n.Edges = append(n.Edges, edge)
+	}
+}
+
+// addTask records the exported task rc and the projects and sections it is a
+// member of. A task exported twice is recorded once.
+func (g *taskGraph) addTask(rc Resource) {
+	n := g.node(rc.GID, "task")
+	if !n.Missing {
+		return
+	}
+	n.Name, n.Missing = rc.Name, false
+	g.tasks = append(g.tasks, rc.GID)
+
+	type member struct {
+		GID  string `json:"gid"`
+		Name string `json:"name"`
+	}
+	var fields struct {
+		Memberships []struct {
+			Project *member `json:"project"`
+			Section *member `json:"section"`
+		} `json:"memberships"`
+	}
+	if err := json.Unmarshal(rc.raw, &fields); err != nil {
+		return
+	}
+	for _, m := range fields.Memberships {
+		for _, r := range []struct {
+			member   *member
+			relation string
+		}{{m.Project, relationProject}, {m.Section, relationSection}} {
+			if r.member == nil || r.member.GID == "" {
+				continue
+			}
+			g.node(r.member.GID, r.relation).Name = r.member.Name
+			g.link(rc.GID, r.member.GID, r.relation)
+		}
+	}
+}
+
+// cycles returns the GIDs of the tasks of every dependency cycle in g, each
+// sorted, found as the strongly connected components of the dependency edges
+// with more than one task or a task depending on itself.
+func (g *taskGraph) cycles() [][]string {
+	next := make(map[string][]string)
+	for gid, n := range g.Nodes {
+		for _, e := range n.Edges {
+			switch e.Relation {
+			case relationDependency:
+				next[gid] = append(next[gid], e.GID)
+			case relationDependent:
+				next[e.GID] = append(next[e.GID], gid)
+			}
+		}
+	}
+
+	index := make(map[string]int)
+	low := make(map[string]int)
+	onStack := make(map[string]bool)
+	var stack []string
+	cycles := [][]string{}
+
+	var visit func(v string)
+	visit = func(v string) {
+		index[v] = len(index)
+		low[v] = index[v]
+		stack = append(stack, v)
+		onStack[v] = true
+
+		for _, w := range next[v] {
+			if _, ok := index[w]; !ok {
+				visit(w)
+				low[v] = min(low[v], low[w])
+			} else if onStack[w] {
+				low[v] = min(low[v], index[w])
+			}
+		}
+		if low[v] != index[v] {
+			return
+		}
+
+		var scc []string
+		for {
+			w := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[w] = false
+			scc = append(scc, w)
+			if w == v {
+				break
+			}
+		}
+		if len(scc) > 1 || slices.Contains(next[v], v) {
+			slices.Sort(scc)
+			cycles = append(cycles, scc)
+		}
+	}
+
+	gids := make([]string, 0, len(g.Nodes))
+	for gid := range g.Nodes {
+		gids = append(gids, gid)
+	}
+	slices.Sort(gids)
+	for _, gid := range gids {
+		if _, ok := index[gid]; !ok {
+			visit(gid)
+		}
+	}
+	slices.SortFunc(cycles, slices.Compare)
+
+	return cycles
+}
+
+// graphSink records every task written through the wrapped sink in the task
+// graph. Tasks dropped by the filter, schema validation, or deduplication
+// never reach the sink and are therefore only part of the graph if an
+// exported task is related to them.
+type graphSink struct {
+	sink
+	graph *taskGraph // Graph the written tasks are added to
+}
+
+// write stores rc with the wrapped sink and adds it to the graph.
+func (s *graphSink) write(ctx context.Context, rc Resource) (string, error) {
+	filename, err := s.sink.write(ctx, rc)
+	if err != nil || filename == "" {
+		return filename, err
+	}
+	s.graph.addTask(rc)
+
+	return filename, nil
+}
+
+// writeGraph completes g with the dependencies and dependents of every
+// exported task and replaces the graph file in dir with it. The relationships
+// are listed one task after another through the rate limited client. Related
+// tasks that were not exported are added as missing nodes, so the graph stays
+// complete without following them further, which also keeps dependency
+// cycles from being followed endlessly. A task deleted or no longer
+// accessible since it was exported is kept with the error instead of its
+// dependencies, unless strict.
+func (a *app) writeGraph(ctx context.Context, g *taskGraph, dir string) error {
+	for _, gid := range g.tasks {
+		for _, rel := range []struct{ path, relation string }{
+			{"dependencies", relationDependency},
+			{"dependents", relationDependent},
+		} {
+			gids, err := a.listGIDs(ctx, "tasks/"+gid+"/"+rel.path, url.Values{})
+			var apiErr *internal.APIError
+			if errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusNotFound || apiErr.StatusCode == http.StatusForbidden) {
+				if err := a.degrade(fmt.Errorf("task %s relationships unavailable: %w", gid, err)); err != nil {
+					return err
+				}
+				g.Nodes[gid].Error = apiErr.Error()
+				break
+			}
+			if err != nil {
+				return fmt.Errorf("task %s %s: %w", gid, rel.path, err)
+			}
+			for _, target := range gids {
+				g.node(target, "task")
+				g.link(gid, target, rel.relation)
+			}
+		}
+	}
+
+	g.Cycles = g.cycles()
+	if len(g.Cycles) > 0 {
+		a.log.Warn("dependency cycles found", slog.Int("cycles", len(g.Cycles)))
+	}
+
+	data, err := json.MarshalIndent(g, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode graph: %w", err)
+	}
+	if err := a.resourceDir(dir); err != nil {
+		return fmt.Errorf("graph directory: %w", err)
+	}
+	path, err := a.dataPath(filepath.Join(dir, graphFileName))
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(path, data); err != nil {
+		return fmt.Errorf("write graph: %w", err)
+	}
+	a.log.Info("graph stored",
+		slog.String("filename", path),
+		slog.Int("tasks", len(g.tasks)),
+		slog.Int("nodes", len(g.Nodes)))
+
+	return nil
+}
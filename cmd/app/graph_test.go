@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/marintailor/asana-resource-exporter/internal"
+)
+
+func TestAppRunExportGraph(t *testing.T) {
+	relationships := map[string]string{
+		"/tasks/1/dependencies": `[{"gid": "2"}]`,
+		"/tasks/1/dependents":   `[{"gid": "2"}]`,
+		"/tasks/2/dependencies": `[{"gid": "1"}]`,
+		"/tasks/2/dependents":   `[{"gid": "1"}, {"gid": "9"}]`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/tasks" {
+			_, _ = w.Write([]byte(`{"data": [
+				{"gid": "1", "name": "Alpha", "resource_type": "task", "memberships": [
+					{"project": {"gid": "100", "name": "Launch"}, "section": {"gid": "200", "name": "Doing"}}
+				]},
+				{"gid": "2", "name": "Beta", "resource_type": "task"},
+				{"gid": "3", "name": "Gamma", "resource_type": "task"}
+			], "next_page": null}`))
+			return
+		}
+		data, ok := relationships[r.URL.Path]
+		if !ok {
+			// Task 3 was deleted after it was exported.
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data": ` + data + `, "next_page": null}`))
+	}))
+	defer server.Close()
+
+	dataDir := t.TempDir()
+	client, _ := internal.NewClient("token", 600)
+	app := &app{
+		cfg: &config{
+			entrypoint:   server.URL,
+			resource:     "task",
+			project:      "100",
+			rate:         600,
+			pageSize:     defaultPageSize,
+			dataDir:      dataDir,
+			emptyName:    defaultEmptyName,
+			outputFormat: formatJSON,
+			exportGraph:  true,
+		},
+		log:    slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+		client: client,
+	}
+
+	if err := app.runExport(context.Background()); err != nil {
+		t.Fatalf("runExport() error = %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dataDir, "task", graphFileName))
+	if err != nil {
+		t.Fatalf("Failed to read graph: %v", err)
+	}
+	var got taskGraph
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("Failed to decode graph: %v", err)
+	}
+
+	want := map[string]graphNode{
+		"1": {Name: "Alpha", ResourceType: "task", Edges: []graphEdge{
+			{GID: "100", Relation: relationProject},
+			{GID: "200", Relation: relationSection},
+			{GID: "2", Relation: relationDependency},
+			{GID: "2", Relation: relationDependent},
+		}},
+		"2": {Name: "Beta", ResourceType: "task", Edges: []graphEdge{
+			{GID: "1", Relation: relationDependency},
+			{GID: "1", Relation: relationDependent},
+			{GID: "9", Relation: relationDependent},
+		}},
+		"3":   {Name: "Gamma", ResourceType: "task", Edges: []graphEdge{}},
+		"9":   {ResourceType: "task", Missing: true, Edges: []graphEdge{}},
+		"100": {Name: "Launch", ResourceType: "project", Edges: []graphEdge{}},
+		"200": {Name: "Doing", ResourceType: "section", Edges: []graphEdge{}},
+	}
+	if len(got.Nodes) != len(want) {
+		t.Errorf("Expected %d nodes, got %d", len(want), len(got.Nodes))
+	}
+	for gid, w := range want {
+		n, ok := got.Nodes[gid]
+		if !ok {
+			t.Errorf("Expected node %s", gid)
+			continue
+		}
+		if gid == "3" {
+			// The error text is the client's; only its presence matters.
+			w.Error = n.Error
+		}
+		if !reflect.DeepEqual(*n, w) {
+			t.Errorf("Node %s = %+v, want %+v", gid, *n, w)
+		}
+	}
+	if got.Nodes["3"] == nil || got.Nodes["3"].Error == "" {
+		t.Error("Expected the error of the deleted task")
+	}
+	if want := [][]string{{"1", "2"}}; !reflect.DeepEqual(got.Cycles, want) {
+		t.Errorf("Cycles = %v, want %v", got.Cycles, want)
+	}
+}
+
+func TestAppRunExportGraphStrict(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/tasks" {
+			_, _ = w.Write([]byte(`{"data": [{"gid": "1", "name": "Alpha", "resource_type": "task"}], "next_page": null}`))
+			return
+		}
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	dataDir := t.TempDir()
+	client, _ := internal.NewClient("token", 600)
+	app := &app{
+		cfg: &config{
+			entrypoint:   server.URL,
+			resource:     "task",
+			project:      "100",
+			rate:         600,
+			pageSize:     defaultPageSize,
+			dataDir:      dataDir,
+			emptyName:    defaultEmptyName,
+			outputFormat: formatJSON,
+			exportGraph:  true,
+			strict:       true,
+		},
+		log:    slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+		client: client,
+	}
+
+	if err := app.runExport(context.Background()); err == nil {
+		t.Fatal("runExport() error = nil, want error in strict mode")
+	}
+	if _, err := os.Stat(filepath.Join(dataDir, "task", graphFileName)); !os.IsNotExist(err) {
+		t.Errorf("Expected no graph, got %v", err)
+	}
+}
+
+func TestTaskGraphCycles(t *testing.T) {
+	g := newTaskGraph()
+	for _, gid := range []string{"1", "2", "3", "4", "5"} {
+		g.addTask(Resource{GID: gid, ResourceType: "task"})
+	}
+	// 1 -> 2 -> 3 -> 1 is a cycle, 4 depends on itself, 5 only on the cycle.
+	g.link("1", "2", relationDependency)
+	g.link("3", "2", relationDependent)
+	g.link("3", "1", relationDependency)
+	g.link("4", "4", relationDependency)
+	g.link("5", "1", relationDependency)
+
+	want := [][]string{{"1", "2", "3"}, {"4"}}
+	if got := g.cycles(); !reflect.DeepEqual(got, want) {
+		t.Errorf("cycles() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// runPostHook runs the configured post-export command through the system shell
+// after a successful export. The run is described to the command through
+// environment variables:
+//   - ASANA_EXPORT_RESOURCE: exported resource type
+//   - ASANA_EXPORT_COUNT: number of resources written
+//   - ASANA_EXPORT_PAGES: number of pages processed
+//   - ASANA_EXPORT_DIR: directory the resources were written to
+//
+// The command is killed when ctx is cancelled or the hook timeout elapses.
+// Its combined output is logged; a non-zero exit status is returned as an error.
+func (a *app) runPostHook(ctx context.Context, sum *summary) error {
+	ctx, cancel := context.WithTimeout(ctx, a.cfg.postHookTimeout)
+	defer cancel()
+
+	name, flag := "sh", "-c"
+	if runtime.GOOS == "windows" {
+		name, flag = "cmd", "/C"
+	}
+
+	// #nosec G204 -- the hook command is operator-provided configuration
+	cmd := exec.CommandContext(ctx, name, flag, a.cfg.postHook)
+	cmd.Env = append(os.Environ(),
+		"ASANA_EXPORT_RESOURCE="+sum.resource,
+		"ASANA_EXPORT_COUNT="+strconv.Itoa(sum.written),
+		"ASANA_EXPORT_PAGES="+strconv.Itoa(sum.pages),
+		"ASANA_EXPORT_DIR="+sum.dir,
+	)
+	// Bound the wait for output pipes held open by children of the shell
+	// once the hook has been killed.
+	cmd.WaitDelay = time.Second
+
+	a.log.Debug("run post hook", slog.String("command", a.cfg.postHook))
+	out, err := cmd.CombinedOutput()
+	if len(out) > 0 {
+		a.log.Info("post hook output", slog.String("output", strings.TrimSpace(string(out))))
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("post hook: %w", ctx.Err())
+		}
+		return fmt.Errorf("post hook: %w", err)
+	}
+
+	a.log.Info("post hook completed")
+	return nil
+}
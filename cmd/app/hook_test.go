@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAppRunPostHook(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("post hook tests use a POSIX shell")
+	}
+
+	tests := []struct {
+		name    string
+		command string
+		timeout time.Duration
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "environment passed to hook",
+			command: `echo "$ASANA_EXPORT_RESOURCE $ASANA_EXPORT_COUNT $ASANA_EXPORT_PAGES $ASANA_EXPORT_DIR" > "$OUT"`,
+			timeout: 5 * time.Second,
+			want:    "project 42 3 data/project",
+			wantErr: false,
+		},
+		{
+			name:    "failing hook",
+			command: "exit 3",
+			timeout: 5 * time.Second,
+			wantErr: true,
+		},
+		{
+			name:    "hook timeout",
+			command: "sleep 5",
+			timeout: 50 * time.Millisecond,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := filepath.Join(t.TempDir(), "hook.out")
+			t.Setenv("OUT", out)
+
+			app := &app{
+				cfg: &config{
+					postHook:        tt.command,
+					postHookTimeout: tt.timeout,
+				},
+				log: slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+			}
+
+			sum := &summary{resource: "project", dir: "data/project", pages: 3, written: 42}
+			err := app.runPostHook(context.Background(), sum)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("runPostHook() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.want == "" {
+				return
+			}
+
+			content, err := os.ReadFile(out)
+			if err != nil {
+				t.Fatalf("Failed to read hook output: %v", err)
+			}
+			if got := strings.TrimSpace(string(content)); got != tt.want {
+				t.Errorf("hook environment = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
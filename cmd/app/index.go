@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// indexFileName is the name of the file, stored in each resource directory,
+// that maps the GID of every exported resource to the file holding it.
+const indexFileName = "index.json"
+
+// indexEntry locates an exported resource.
+type indexEntry struct {
+	Name         string `json:"name"`          // Resource name
+	Filename     string `json:"filename"`      // File name relative to the resource directory
+	ResourceType string `json:"resource_type"` // Resource category (project, task, user, etc.)
+}
+
+// indexSink records where every resource written through the wrapped sink
+// was stored and merges the records into the index file of the resource
+// directory when closed, so consumers can find a resource's file without
+// scanning the directory.
+type indexSink struct {
+	sink
+	a       *app                  // App providing path validation
+	dir     string                // Resource directory holding the index file
+	entries map[string]indexEntry // Resources written during this run by GID
+}
+
+// write stores rc with the wrapped sink and records the file it went to.
+func (s *indexSink) write(ctx context.Context, rc Resource) (string, error) {
+	filename, err := s.sink.write(ctx, rc)
+	if err != nil || filename == "" {
+		return filename, err
+	}
+
+	rel, err := filepath.Rel(s.dir, filename)
+	if err != nil {
+		rel = filepath.Base(filename)
+	}
+	s.entries[rc.GID] = indexEntry{
+		Name:         rc.Name,
+		Filename:     filepath.ToSlash(rel),
+		ResourceType: rc.ResourceType,
+	}
+
+	return filename, nil
+}
+
+// close closes the wrapped sink and saves the index unless keep is unset.
+func (s *indexSink) close(keep bool) error {
+	err := s.sink.close(keep)
+	if !keep {
+		s.a.log.Warn("index not updated, the run failed or its results are suspect")
+		return err
+	}
+	if ierr := s.saveIndex(); ierr != nil {
+		err = errors.Join(err, fmt.Errorf("save index: %w", ierr))
+	}
+
+	return err
+}
+
+// saveIndex merges the entries of this run into the index persisted by
+// previous runs, replacing the index file atomically. Entries whose file no
+// longer exists, e.g. after pruning, are dropped, and the index file is
+// removed once it has no entries left.
+func (s *indexSink) saveIndex() error {
+	path, err := s.a.dataPath(filepath.Join(s.dir, indexFileName))
+	if err != nil {
+		return err
+	}
+
+	index := make(map[string]indexEntry)
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return fmt.Errorf("read file: %w", err)
+	default:
+		if err := json.Unmarshal(data, &index); err != nil {
+			return fmt.Errorf("unmarshal index: %w", err)
+		}
+	}
+
+	for gid, e := range s.entries {
+		index[gid] = e
+	}
+	for gid, e := range index {
+		if _, err := os.Stat(filepath.Join(s.dir, filepath.FromSlash(e.Filename))); err != nil {
+			delete(index, gid)
+		}
+	}
+	if len(index) == 0 {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("remove index: %w", err)
+		}
+		return nil
+	}
+
+	if data, err = json.MarshalIndent(index, "", "  "); err != nil {
+		return fmt.Errorf("marshal index: %w", err)
+	}
+
+	return writeFileAtomic(path, data)
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames it
+// to path, so readers never see a partially written file.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("create file: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("write file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("close file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("rename file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/marintailor/asana-resource-exporter/internal"
+)
+
+func TestAppRunExportIndex(t *testing.T) {
+	tests := []struct {
+		name         string
+		outputFormat string
+		wantFiles    int
+	}{
+		{"json", formatJSON, 3},
+		{"jsonl", formatJSONL, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"data": [
+					{"gid": "1", "name": "Alpha", "resource_type": "project"},
+					{"gid": "2", "name": "Beta", "resource_type": "project"},
+					{"gid": "3", "name": "", "resource_type": "project"}
+				], "next_page": null}`))
+			}))
+			defer server.Close()
+
+			dataDir := t.TempDir()
+			client, _ := internal.NewClient("token", 600)
+			app := &app{
+				cfg: &config{
+					entrypoint:   server.URL,
+					resource:     "project",
+					rate:         600,
+					pageSize:     defaultPageSize,
+					dataDir:      dataDir,
+					emptyName:    defaultEmptyName,
+					outputFormat: tt.outputFormat,
+					writeIndex:   true,
+				},
+				log:    slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+				client: client,
+			}
+
+			if err := app.runExport(context.Background()); err != nil {
+				t.Fatalf("runExport() error = %v", err)
+			}
+
+			rcDir := filepath.Join(dataDir, "project")
+			data, err := os.ReadFile(filepath.Join(rcDir, indexFileName))
+			if err != nil {
+				t.Fatalf("Failed to read index: %v", err)
+			}
+			var index map[string]indexEntry
+			if err := json.Unmarshal(data, &index); err != nil {
+				t.Fatalf("Failed to unmarshal index: %v", err)
+			}
+			if len(index) != 3 {
+				t.Fatalf("Expected 3 index entries, got %d", len(index))
+			}
+
+			files := make(map[string]bool)
+			for gid, e := range index {
+				if e.ResourceType != "project" {
+					t.Errorf("Entry %s: resource type = %q, want project", gid, e.ResourceType)
+				}
+				content, err := os.ReadFile(filepath.Join(rcDir, e.Filename))
+				if err != nil {
+					t.Errorf("Entry %s: failed to read %s: %v", gid, e.Filename, err)
+					continue
+				}
+				if !strings.Contains(string(content), `"gid":"`+gid+`"`) {
+					t.Errorf("Entry %s: %s does not hold the resource", gid, e.Filename)
+				}
+				files[e.Filename] = true
+			}
+			if index["1"].Name != "Alpha" || index["3"].Name != "" {
+				t.Errorf("Unexpected names: %q, %q", index["1"].Name, index["3"].Name)
+			}
+
+			entries, err := os.ReadDir(rcDir)
+			if err != nil {
+				t.Fatalf("Failed to read resource directory: %v", err)
+			}
+			var written int
+			for _, e := range entries {
+				if e.Name() == indexFileName {
+					continue
+				}
+				written++
+				if !files[e.Name()] {
+					t.Errorf("File %s is missing from the index", e.Name())
+				}
+			}
+			if written != tt.wantFiles || len(files) != tt.wantFiles {
+				t.Errorf("Expected %d files, got %d written and %d indexed", tt.wantFiles, written, len(files))
+			}
+		})
+	}
+}
+
+func TestIndexSinkDropsMissingFiles(t *testing.T) {
+	dataDir := t.TempDir()
+	rcDir := filepath.Join(dataDir, "project")
+	if err := os.MkdirAll(rcDir, 0750); err != nil {
+		t.Fatalf("Failed to create resource directory: %v", err)
+	}
+	stale := `{"9": {"name": "Pruned", "filename": "project_Pruned_20240101000000.json", "resource_type": "project"}}`
+	if err := os.WriteFile(filepath.Join(rcDir, indexFileName), []byte(stale), 0600); err != nil {
+		t.Fatalf("Failed to write index: %v", err)
+	}
+
+	app := &app{
+		cfg: &config{resource: "project", dataDir: dataDir, outputFormat: formatJSON, emptyName: defaultEmptyName, writeIndex: true},
+		log: slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+	}
+	out := app.newSink(dataDir, time.Now())
+	if _, err := out.write(context.Background(), Resource{GID: "1", Name: "Alpha", ResourceType: "project"}); err != nil {
+		t.Fatalf("write() error = %v", err)
+	}
+	if err := out.close(true); err != nil {
+		t.Fatalf("close() error = %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(rcDir, indexFileName))
+	if err != nil {
+		t.Fatalf("Failed to read index: %v", err)
+	}
+	var index map[string]indexEntry
+	if err := json.Unmarshal(data, &index); err != nil {
+		t.Fatalf("Failed to unmarshal index: %v", err)
+	}
+	if _, ok := index["9"]; ok {
+		t.Error("Expected entry of a missing file to be dropped")
+	}
+	if _, ok := index["1"]; !ok {
+		t.Error("Expected entry of the written resource")
+	}
+}
+
+func TestIndexSinkRemovesEmptyIndex(t *testing.T) {
+	dataDir := t.TempDir()
+	rcDir := filepath.Join(dataDir, "project")
+	if err := os.MkdirAll(rcDir, 0750); err != nil {
+		t.Fatalf("Failed to create resource directory: %v", err)
+	}
+	stale := `{"9": {"name": "Pruned", "filename": "project_Pruned_20240101000000.json", "resource_type": "project"}}`
+	if err := os.WriteFile(filepath.Join(rcDir, indexFileName), []byte(stale), 0600); err != nil {
+		t.Fatalf("Failed to write index: %v", err)
+	}
+
+	app := &app{
+		cfg: &config{resource: "project", dataDir: dataDir, outputFormat: formatJSON, emptyName: defaultEmptyName, writeIndex: true},
+		log: slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+	}
+	if err := app.newSink(dataDir, time.Now()).close(true); err != nil {
+		t.Fatalf("close() error = %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(rcDir, indexFileName)); !os.IsNotExist(err) {
+		t.Errorf("Expected the index of pruned files to be removed, got %v", err)
+	}
+}
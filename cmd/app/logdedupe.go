@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// dedupeFlushInterval is how often repeats of warnings and errors held back
+// with dedupe-errors are logged as a single line.
+const dedupeFlushInterval = 10 * time.Second
+
+// volatileAttrs are the attributes that change with every repeat of the same
+// failure, such as the retry attempt, and are left out of the record key.
+var volatileAttrs = map[string]bool{
+	"attempt":     true,
+	"retry_after": true,
+}
+
+// urlQuery matches the query of a URL in an attribute value, which holds the
+// page offset in errors of paginated requests.
+var urlQuery = regexp.MustCompile(`(https?://[^\s"?]*)\?[^\s"]*`)
+
+// dedupeHandler collapses repeated identical warnings and errors, such as a
+// network failure hitting every request of a run, into a single line. The
+// first occurrence of a record is logged right away; identical records, with
+// the same level, message, and stable attributes, are only counted until the
+// next flush, which logs the message once more, suffixed with the number of
+// repeats, e.g. "(x42)". Records below warning level are never held back.
+type dedupeHandler struct {
+	next  slog.Handler // Handler records are logged with
+	key   string       // Attributes and groups added with WithAttrs and WithGroup
+	state *dedupeState // Repeats shared by all handlers derived from the same root
+}
+
+// dedupeState tracks the repeated records of a dedupeHandler and its
+// derived handlers.
+type dedupeState struct {
+	mu      sync.Mutex                 // Guards repeats and closed
+	repeats map[string]*repeatedRecord // Records logged since the last flush, by key
+	closed  bool                       // Records are passed through once closed
+
+	stop chan struct{} // Closed to stop the periodic flush
+	done chan struct{} // Closed once the periodic flush stopped
+}
+
+// repeatedRecord is a record logged since the last flush and the number of
+// identical records held back since.
+type repeatedRecord struct {
+	next   slog.Handler // Handler the record was logged with
+	record slog.Record  // First occurrence
+	count  int          // Identical records held back
+}
+
+// newDedupeHandler returns a dedupeHandler logging with next and flushing
+// held back repeats every interval until closed.
+func newDedupeHandler(next slog.Handler, interval time.Duration) *dedupeHandler {
+	state := &dedupeState{
+		repeats: make(map[string]*repeatedRecord),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+
+	go func() {
+		defer close(state.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-state.stop:
+				return
+			case <-ticker.C:
+				_ = state.flush(context.Background())
+			}
+		}
+	}()
+
+	return &dedupeHandler{next: next, state: state}
+}
+
+// Enabled reports whether the next handler handles records at level.
+func (h *dedupeHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+// Handle logs r, unless an identical warning or error was logged since the
+// last flush, in which case it is only counted.
+func (h *dedupeHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level < slog.LevelWarn {
+		return h.next.Handle(ctx, r)
+	}
+
+	key := h.recordKey(r)
+	h.state.mu.Lock()
+	if h.state.closed {
+		h.state.mu.Unlock()
+		return h.next.Handle(ctx, r)
+	}
+	if rep, ok := h.state.repeats[key]; ok {
+		rep.count++
+		h.state.mu.Unlock()
+		return nil
+	}
+	h.state.repeats[key] = &repeatedRecord{next: h.next, record: r.Clone()}
+	h.state.mu.Unlock()
+
+	return h.next.Handle(ctx, r)
+}
+
+// WithAttrs returns a handler adding attrs, whose records are only identical
+// to records of handlers with the same attributes.
+func (h *dedupeHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var b strings.Builder
+	b.WriteString(h.key)
+	for _, a := range attrs {
+		writeAttrKey(&b, a)
+	}
+	return &dedupeHandler{next: h.next.WithAttrs(attrs), key: b.String(), state: h.state}
+}
+
+// WithGroup returns a handler nesting attributes in the group name.
+func (h *dedupeHandler) WithGroup(name string) slog.Handler {
+	return &dedupeHandler{next: h.next.WithGroup(name), key: h.key + name + "{", state: h.state}
+}
+
+// recordKey returns the key identifying records identical to r: its level,
+// message, and attributes, without the volatile ones and URL queries, so the
+// retries of the same failure on different pages are identical.
+func (h *dedupeHandler) recordKey(r slog.Record) string {
+	var b strings.Builder
+	b.WriteString(h.key)
+	fmt.Fprintf(&b, "%s|%s|", r.Level, r.Message)
+	r.Attrs(func(a slog.Attr) bool {
+		if !volatileAttrs[a.Key] {
+			writeAttrKey(&b, a)
+		}
+		return true
+	})
+	return b.String()
+}
+
+// writeAttrKey writes a to the key of a record, with the queries of URLs in
+// its value removed.
+func writeAttrKey(b *strings.Builder, a slog.Attr) {
+	fmt.Fprintf(b, "%s=%s;", a.Key, urlQuery.ReplaceAllString(a.Value.Resolve().String(), "$1"))
+}
+
+// close stops the periodic flush, logs the repeats held back, and passes
+// every further record through.
+func (h *dedupeHandler) close(ctx context.Context) error {
+	h.state.mu.Lock()
+	if h.state.closed {
+		h.state.mu.Unlock()
+		return nil
+	}
+	h.state.closed = true
+	h.state.mu.Unlock()
+
+	close(h.state.stop)
+	<-h.state.done
+	return h.state.flush(ctx)
+}
+
+// flush logs every record held back since the last flush once, in the order
+// of their first occurrence, with the number of repeats appended to its
+// message, and forgets the records logged since, so their next occurrence is
+// logged right away.
+func (s *dedupeState) flush(ctx context.Context) error {
+	s.mu.Lock()
+	var repeats []*repeatedRecord
+	for _, rep := range s.repeats {
+		if rep.count > 0 {
+			repeats = append(repeats, rep)
+		}
+	}
+	s.repeats = make(map[string]*repeatedRecord)
+	s.mu.Unlock()
+
+	slices.SortFunc(repeats, func(a, b *repeatedRecord) int {
+		return a.record.Time.Compare(b.record.Time)
+	})
+
+	var errs []error
+	for _, rep := range repeats {
+		r := slog.NewRecord(time.Now(), rep.record.Level, fmt.Sprintf("%s (x%d)", rep.record.Message, rep.count), rep.record.PC)
+		rep.record.Attrs(func(a slog.Attr) bool {
+			r.AddAttrs(a)
+			return true
+		})
+		r.AddAttrs(slog.Int("repeated", rep.count))
+		if err := rep.next.Handle(ctx, r); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+// logLines returns the messages of the JSON log lines in buf, prefixed with
+// their workspace attribute if any.
+func logLines(t *testing.T, buf *bytes.Buffer) []string {
+	t.Helper()
+
+	var lines []string
+	for line := range strings.SplitSeq(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry struct {
+			Msg       string `json:"msg"`
+			Workspace string `json:"workspace"`
+		}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("Failed to decode log line %q: %v", line, err)
+		}
+		if entry.Workspace != "" {
+			entry.Msg = entry.Workspace + ": " + entry.Msg
+		}
+		lines = append(lines, entry.Msg)
+	}
+	return lines
+}
+
+func TestDedupeHandler(t *testing.T) {
+	var buf bytes.Buffer
+	h := newDedupeHandler(slog.NewJSONHandler(&buf, &slog.HandlerOptions{}), time.Hour)
+	log := slog.New(h)
+
+	for range 42 {
+		log.Error("request failed", slog.String("reason", "connection reset"))
+	}
+	log.Error("request failed", slog.String("reason", "timeout"))
+	log.Info("page fetched")
+	log.Info("page fetched")
+	log.With(slog.String("workspace", "111")).Warn("request failed", slog.String("reason", "timeout"))
+	log.With(slog.String("workspace", "111")).Warn("request failed", slog.String("reason", "timeout"))
+
+	want := []string{
+		"request failed",
+		"request failed",
+		"page fetched",
+		"page fetched",
+		"111: request failed",
+	}
+	if got := logLines(t, &buf); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Logged before close %q, want %q", got, want)
+	}
+
+	buf.Reset()
+	if err := h.close(context.Background()); err != nil {
+		t.Fatalf("close() error = %v", err)
+	}
+	want = []string{
+		"request failed (x41)",
+		"111: request failed (x1)",
+	}
+	if got := logLines(t, &buf); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Logged on close %q, want %q", got, want)
+	}
+	if !strings.Contains(buf.String(), `"reason":"connection reset","repeated":41`) {
+		t.Errorf("Expected the attributes and repeat count of the collapsed line, got %s", buf.String())
+	}
+
+	// Records are passed through once closed.
+	buf.Reset()
+	log.Error("request failed", slog.String("reason", "timeout"))
+	log.Error("request failed", slog.String("reason", "timeout"))
+	if got := logLines(t, &buf); len(got) != 2 {
+		t.Errorf("Logged after close %q, want 2 lines", got)
+	}
+}
+
+func TestDedupeHandlerPeriodicFlush(t *testing.T) {
+	var buf syncBuffer
+	h := newDedupeHandler(slog.NewJSONHandler(&buf, &slog.HandlerOptions{}), 20*time.Millisecond)
+	defer func() { _ = h.close(context.Background()) }()
+	log := slog.New(h)
+
+	log.Warn("too many requests")
+	log.Warn("too many requests")
+	log.Warn("too many requests")
+
+	deadline := time.Now().Add(time.Second)
+	for !strings.Contains(buf.String(), "too many requests (x2)") {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the repeats to be flushed, got %s", buf.String())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// The next occurrence after a flush is logged right away.
+	log.Warn("too many requests")
+	if n := strings.Count(buf.String(), `"msg":"too many requests"`); n != 2 {
+		t.Errorf("Expected the occurrence after the flush to be logged, got %d lines", n)
+	}
+}
+
+func TestDedupeHandlerVolatileAttrs(t *testing.T) {
+	var buf bytes.Buffer
+	h := newDedupeHandler(slog.NewJSONHandler(&buf, &slog.HandlerOptions{}), time.Hour)
+	log := slog.New(h)
+
+	for attempt := 1; attempt <= 3; attempt++ {
+		reason := fmt.Sprintf(`Get "https://app.asana.com/api/1.0/projects?limit=100&offset=page%d": connection reset`, attempt)
+		log.Warn("request failed, retrying",
+			slog.String("reason", reason),
+			slog.Int("attempt", attempt),
+			slog.String("retry_after", (time.Duration(attempt)*time.Second).String()))
+	}
+	log.Warn("request failed, retrying", slog.String("reason", "timeout"), slog.Int("attempt", 1))
+
+	if err := h.close(context.Background()); err != nil {
+		t.Fatalf("close() error = %v", err)
+	}
+	want := []string{
+		"request failed, retrying",
+		"request failed, retrying",
+		"request failed, retrying (x2)",
+	}
+	if got := logLines(t, &buf); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Logged %q, want %q", got, want)
+	}
+}
@@ -4,24 +4,73 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"log/slog"
+	"math/rand/v2"
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"slices"
 	"strconv"
+	"sync"
 	"syscall"
 	"time"
+
+	"github.com/marintailor/asana-resource-exporter/internal"
 )
 
 func main() {
-	app, err := newApp(os.Args)
+	opts, err := newOptions(os.Args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to initialize application: new options: %v\n", err)
+		os.Exit(1)
+	}
+
+	if opts.listFormats {
+		if err := printOutputFormats(os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to list output formats: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if opts.validateOnly {
+		ok, err := runValidate(context.Background(), opts, os.Stdout)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "validation failed: %v\n", err)
+		}
+		if !ok {
+			os.Exit(1)
+		}
+		return
+	}
+
+	app, err := newAppFromOptions(opts)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "failed to initialize application: %v\n", err)
 		os.Exit(1)
 	}
 
+	if app.dump {
+		if err := app.dumpConfig(os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to dump configuration: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if app.probe {
+		if err := app.runProbe(context.Background(), os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "probe failed: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	if err := app.run(); err != nil {
 		app.log.Error("application error",
 			slog.String("error", err.Error()))
@@ -32,6 +81,7 @@ func main() {
 
 func (a *app) run() error {
 	a.log.Debug("app started")
+	defer a.flushLogs()
 
 	a.done = make(chan struct{})
 
@@ -40,18 +90,39 @@ func (a *app) run() error {
 
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
-
-	go func() {
-		sig := <-sigCh
-		a.log.Info("received signal, initiating shutdown", slog.String("signal", sig.String()))
-		a.cancel()
-	}()
+	go a.handleSignals(sigCh, os.Exit)
 
 	interval, err := a.parseInterval()
 	if err != nil {
 		return err
 	}
 
+	if a.cfg.dedupe {
+		if a.seen, err = a.loadSeen(); err != nil {
+			return fmt.Errorf("load seen set: %w", err)
+		}
+	}
+
+	if a.cfg.startupJitter > 0 {
+		if err := a.waitStartupJitter(ctx); err != nil {
+			return a.finish(ctx, nil)
+		}
+	}
+
+	if a.cfg.warmup {
+		a.warmup(ctx)
+	}
+
+	if a.cfg.namePrefix != "" {
+		a.logNamePrefix()
+	}
+
+	if a.cfg.dedupContent && a.cfg.outputFormat != formatJSON {
+		a.log.Info("dedup content has no effect with NDJSON output", slog.String("output_format", a.cfg.outputFormat))
+	}
+
+	a.logPlan(interval)
+
 	if interval > 0 {
 		a.log.Debug("run with interval", slog.String("interval", interval.String()))
 		return a.runWithInterval(ctx, interval)
@@ -61,15 +132,89 @@ func (a *app) run() error {
 	return a.runOnce(ctx)
 }
 
+// waitStartupJitter waits a random duration between zero and the configured
+// startup jitter before the first request, so a fleet of exporters started
+// together, e.g. after a deploy, does not hit the API at once. It returns the
+// context error if cancelled while waiting.
+func (a *app) waitStartupJitter(ctx context.Context) error {
+	delay := rand.N(a.cfg.startupJitter + 1)
+	a.log.Info("delaying startup", slog.String("delay", delay.String()))
+
+	return sleep(ctx, delay)
+}
+
+// handleSignals initiates a graceful shutdown on the first signal received
+// on sigCh, such as the SIGTERM sent when a Kubernetes pod is stopped. The
+// export stops after the resource being written, if any. A second signal,
+// e.g. pressing Ctrl-C again while shutdown hangs, forces an exit with
+// forceExitCode once writes in progress completed, or after writeGrace.
+func (a *app) handleSignals(sigCh <-chan os.Signal, exit func(code int)) {
+	sig := <-sigCh
+	a.log.Info("received signal, initiating shutdown, send again to force exit", slog.String("signal", sig.String()))
+	a.cancel()
+
+	sig = <-sigCh
+	a.log.Warn("received second signal, forcing shutdown", slog.String("signal", sig.String()))
+	a.awaitWrites()
+	a.flushLogs()
+	exit(forceExitCode)
+}
+
+// flushLogs logs the warnings and errors held back with dedupe-errors, also
+// on exits that skip the shutdown of auxiliary components, such as a forced
+// shutdown or a run failing before it started.
+func (a *app) flushLogs() {
+	if h, ok := a.log.Handler().(*dedupeHandler); ok {
+		_ = h.close(context.Background())
+	}
+}
+
+// trackWrite marks a write of export files as in progress until the returned
+// function is called, so a forced shutdown does not cut it off.
+func (a *app) trackWrite() func() {
+	a.writes.Add(1)
+	return a.writes.Done
+}
+
+// awaitWrites waits for writes in progress to complete, for at most
+// writeGrace, and reports whether they did.
+func (a *app) awaitWrites() bool {
+	done := make(chan struct{})
+	go func() {
+		a.writes.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-time.After(writeGrace):
+		a.log.Warn("write grace period expired, files being written may be lost", slog.String("grace", writeGrace.String()))
+		return false
+	}
+}
+
 // parseInterval converts the configured interval string into a time.Duration.
 // It validates that the interval is at least 1 second if specified.
 // Returns 0 duration if no interval was configured.
 func (a *app) parseInterval() (time.Duration, error) {
-	if a.cfg.interval == "" {
+	interval, err := intervalDuration(a.cfg.interval)
+	if err != nil || interval == 0 {
+		return interval, err
+	}
+
+	a.log.Info("running with interval", slog.String("interval", interval.String()))
+	return interval, nil
+}
+
+// intervalDuration parses an interval string, validating that it is at least
+// 1 second if specified. An empty string yields 0.
+func intervalDuration(s string) (time.Duration, error) {
+	if s == "" {
 		return 0, nil
 	}
 
-	interval, err := time.ParseDuration(a.cfg.interval)
+	interval, err := time.ParseDuration(s)
 	if err != nil {
 		return 0, fmt.Errorf("invalid interval format: %w", err)
 	}
@@ -77,83 +222,336 @@ func (a *app) parseInterval() (time.Duration, error) {
 		return 0, fmt.Errorf("interval must be at least 1 second")
 	}
 
-	a.log.Info("running with interval", slog.String("interval", interval.String()))
 	return interval, nil
 }
 
 // runWithInterval executes export operations periodically at the specified interval.
-// It manages concurrent exports using goroutines and aggregates errors.
+// It manages concurrent exports using goroutines and aggregates their errors,
+// including those of exports still finishing on shutdown; see errorCollector.
+// With resume-schedule, ticks continue in the phase of the previous process,
+// and the time of every tick is persisted for the next one.
 // The operation continues until the context is cancelled or a fatal error occurs.
 func (a *app) runWithInterval(ctx context.Context, interval time.Duration) error {
-	ticker := time.NewTicker(interval)
-	defer ticker.Stop()
-
-	errCh := make(chan error, 1)
-	var errs []error
-
-	a.wg.Add(1)
-	go func() {
-		defer a.wg.Done()
-
-		data, err := a.fetchData(ctx)
-		if err != nil {
-			a.log.Error("fetch data", slog.String("error", err.Error()))
-			errCh <- err
-			return
-		}
+	runNow, wait, last := true, interval, time.Now()
+	if a.cfg.resumeSchedule {
+		runNow, wait, last = a.resumeSchedule(interval, last)
+	}
 
-		if err := a.export(ctx, data, a.cfg.dataDir); err != nil && !errors.Is(err, context.Canceled) {
-			a.log.Error("export error", slog.String("error", err.Error()))
-			errCh <- err
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	var ticker *time.Ticker
+	defer func() {
+		if ticker != nil {
+			ticker.Stop()
 		}
 	}()
+	tick := timer.C
+
+	errs := newErrorCollector()
+
+	if runNow {
+		a.recordTick(interval, last)
+		a.wg.Add(1)
+		go a.runTick(ctx, errs.ch)
+	}
 
 	for {
 		select {
 		case <-ctx.Done():
-			return a.finish(ctx, errs)
-		case <-ticker.C:
+			return a.finish(ctx, errs.wait(&a.wg, shutdownTimeout))
+		case t := <-tick:
+			if ticker == nil {
+				ticker = time.NewTicker(interval)
+				tick = ticker.C
+			}
 			a.log.Debug("starting interval-based export")
+			a.recordTick(interval, t)
 			a.wg.Add(1)
-			go func() {
-				defer a.wg.Done()
-
-				data, err := a.fetchData(ctx)
-				if err != nil {
-					a.log.Error("fetch data", slog.String("error", err.Error()))
-					errCh <- err
-					return
-				}
-
-				if err := a.export(ctx, data, a.cfg.dataDir); err != nil && !errors.Is(err, context.Canceled) {
-					a.log.Error("export error", slog.String("error", err.Error()))
-					errCh <- err
-				}
-			}()
-		case err := <-errCh:
-			errs = append(errs, err)
+			go a.runTick(ctx, errs.ch)
 		}
 	}
 }
 
+// errorCollector gathers the errors of concurrent interval exports. Errors
+// are received in a goroutine of its own, so exports never block on sending
+// them, however many finish at once and whatever the interval loop is busy
+// with.
+type errorCollector struct {
+	ch   chan error    // Errors of exports; closed once every export finished
+	done chan struct{} // Closed once ch is closed and drained
+
+	mu   sync.Mutex // Guards errs
+	errs []error    // Errors received so far
+}
+
+// newErrorCollector returns an errorCollector receiving errors on its channel.
+func newErrorCollector() *errorCollector {
+	c := &errorCollector{ch: make(chan error), done: make(chan struct{})}
+	go func() {
+		defer close(c.done)
+		for err := range c.ch {
+			c.mu.Lock()
+			c.errs = append(c.errs, err)
+			c.mu.Unlock()
+		}
+	}()
+	return c
+}
+
+// wait closes the collector once the exports tracked by wg have finished and
+// returns the errors received. Exports still running after timeout keep being
+// drained so they can finish, but their errors are not returned.
+func (c *errorCollector) wait(wg *sync.WaitGroup, timeout time.Duration) []error {
+	go func() {
+		wg.Wait()
+		close(c.ch)
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-c.done:
+	case <-timer.C:
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return slices.Clone(c.errs)
+}
+
+// runTick runs a single interval export and reports its error on errCh. With
+// an active window, ticks outside the window are skipped. With
+// max-goroutines, a tick waits for the governor before exporting. With
+// close-idle-conns, idle connections are closed once the export completes so
+// they are not kept open until the next tick.
+func (a *app) runTick(ctx context.Context, errCh chan<- error) {
+	defer a.wg.Done()
+
+	if a.cfg.window != nil && !a.cfg.window.contains(time.Now()) {
+		a.log.Debug("outside active window, skipping export",
+			slog.String("active_window", a.cfg.activeWindow))
+		return
+	}
+
+	if err := a.gov.acquire(ctx, 1); err != nil {
+		return
+	}
+	defer a.gov.release(1)
+
+	err := a.runExport(ctx)
+	if a.cfg.closeIdleConns {
+		a.client.CloseIdleConnections()
+		a.log.Debug("closed idle connections")
+	}
+	if err != nil {
+		errCh <- err
+	}
+}
+
 // runOnce performs a single export operation and returns any errors encountered.
 // It respects context cancellation for graceful shutdown.
 func (a *app) runOnce(ctx context.Context) error {
 	var errs []error
 
-	data, err := a.fetchData(ctx)
-	if err != nil {
-		a.log.Error("fetch data", slog.String("error", err.Error()))
+	if err := a.runExport(ctx); err != nil {
 		errs = append(errs, err)
-		return a.finish(ctx, errs)
 	}
 
-	if err := a.export(ctx, data, a.cfg.dataDir); err != nil && !errors.Is(err, context.Canceled) {
+	return a.finish(ctx, errs)
+}
+
+// runExport fetches resources page by page, or by GID when GIDs are
+// configured, and stores each page as it arrives, then runs the post-export
+// hook if one is configured. In count-only mode, resources are only counted;
+// see runCount. With retention, expired export files are deleted first. With
+// output-dir-per-run, each run is written under its own timestamped run
+// directory. A run that failed over to the fallback entrypoint does not carry
+// over to the next, which starts on the primary entrypoint again. With
+// min-count or max-drop-percent, the resource count of a completed run is
+// checked; see checkCount. With auto-workspaces, the run is repeated for
+// every workspace; see runWorkspaces. With export-graph, the relationship
+// graph of the exported tasks is written; see writeGraph. The run summary is
+// logged and, if configured, reported in the summary format and posted to
+// the notify URL, also when the export failed. Errors are logged and
+// returned, except for context cancellation which is part of a graceful
+// shutdown and returns nil.
+func (a *app) runExport(ctx context.Context) error {
+	if a.cfg.autoWorkspaces && a.cfg.workspace == "" {
+		return a.runWorkspaces(ctx)
+	}
+
+	dir := a.cfg.dataDir
+	sum := &summary{resource: a.cfg.resource, start: time.Now()}
+	if a.cfg.dirPerRun {
+		dir = a.runDir(time.Now())
+		sum.runDir = dir
+	}
+	sum.dir = a.exportDir(dir)
+
+	if a.failedOver {
+		a.log.Info("reverting to primary entrypoint", slog.String("entrypoint", a.client.Redact(a.cfg.entrypoint)))
+		a.failedOver = false
+	}
+
+	if a.cfg.countOnly {
+		return a.runCount(ctx)
+	}
+
+	if a.cfg.retentionDuration > 0 {
+		pruned, err := a.prune(time.Now())
+		if err != nil {
+			a.log.Warn("prune export files", slog.String("error", err.Error()))
+		}
+		sum.pruned = pruned
+	}
+
+	a.budget = newByteBudget(a.cfg.maxTotalBytes)
+	out := a.newSink(dir, time.Now())
+	var graph *taskGraph
+	if a.cfg.exportGraph {
+		graph = newTaskGraph()
+		out = &graphSink{sink: out, graph: graph}
+	}
+	err := a.fetcher()(ctx, dir, func(data []byte) error {
+		return a.store(ctx, [][]byte{data}, dir, out, sum)
+	})
+	err = a.skipForbidden(err, sum)
+	err = a.truncateOnBudget(err, sum)
+	err = a.truncateOnMaxPages(err, sum)
+	if err == nil {
+		err = a.checkCount(sum)
+	}
+	done := a.trackWrite()
+	// A failed run's listings are partial and must not replace complete ones.
+	if cerr := out.close(err == nil && !sum.suspect); cerr != nil {
+		err = errors.Join(err, cerr)
+	}
+	done()
+	switch {
+	case a.seen == nil:
+	case sum.suspect:
+		// Later interval runs must export the resources of this run again.
+		a.seen.rollback(sum.seenPrev)
+	default:
+		if serr := a.saveSeen(); serr != nil {
+			err = errors.Join(err, fmt.Errorf("save seen set: %w", serr))
+		}
+	}
+	if err == nil && graph != nil {
+		err = a.writeGraph(ctx, graph, sum.dir)
+	}
+	a.progress.finish(sum)
+	a.logSummary(sum)
+	if err == nil && a.cfg.postHook != "" {
+		err = a.runPostHook(ctx, sum)
+	}
+	if rerr := a.reportSummary(sum, err); rerr != nil {
+		err = errors.Join(err, fmt.Errorf("report summary: %w", rerr))
+	}
+	a.notify(ctx, sum, err)
+	if err != nil && !errors.Is(err, context.Canceled) {
 		a.log.Error("export error", slog.String("error", err.Error()))
-		errs = append(errs, err)
+		return err
 	}
 
-	return a.finish(ctx, errs)
+	return nil
+}
+
+// runCount counts the configured resources without exporting them, logs,
+// reports, and posts the summary and, if configured, writes the counts to the
+// count output file. Errors are handled as in runExport.
+func (a *app) runCount(ctx context.Context) error {
+	sum := &summary{resource: a.cfg.resource, start: time.Now()}
+
+	err := a.fetcher()(ctx, a.cfg.dataDir, func(data []byte) error {
+		return a.countPage(ctx, data, sum)
+	})
+	err = a.skipForbidden(err, sum)
+	err = a.truncateOnMaxPages(err, sum)
+	if err == nil {
+		err = a.checkCount(sum)
+	}
+	a.progress.finish(sum)
+	a.logSummary(sum)
+	if err == nil && a.cfg.countOutput != "" {
+		err = a.writeCounts(sum)
+	}
+	if rerr := a.reportSummary(sum, err); rerr != nil {
+		err = errors.Join(err, fmt.Errorf("report summary: %w", rerr))
+	}
+	a.notify(ctx, sum, err)
+	if err != nil && !errors.Is(err, context.Canceled) {
+		a.log.Error("count error", slog.String("error", err.Error()))
+		return err
+	}
+
+	return nil
+}
+
+// skipForbidden records a 403 Forbidden response, returned when the token
+// lacks access to the configured resource type, as an error of that type in
+// sum and tolerates it unless strict. Other errors are returned unchanged.
+func (a *app) skipForbidden(err error, sum *summary) error {
+	var apiErr *internal.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusForbidden {
+		return err
+	}
+
+	sum.typeErrors = map[string]string{sum.resource: apiErr.Error()}
+	return a.degrade(fmt.Errorf("resource type %s unauthorized: %w", sum.resource, err))
+}
+
+// fetcher returns the function retrieving the configured resources: from
+// stored raw pages with no-fetch, by GID through the batch API when GIDs are
+// configured, by typeahead search when the name prefix is applied
+// server-side, from every parent in the workspace for a nested type without
+// a configured parent, or by listing all pages.
+func (a *app) fetcher() func(ctx context.Context, dir string, handle func(data []byte) error) error {
+	if a.cfg.noFetch {
+		return a.fetchRaw
+	}
+	if len(a.cfg.gidList) > 0 {
+		return a.fetchGIDs
+	}
+	if a.serverSidePrefix() {
+		return a.fetchTypeahead
+	}
+	if resourceTypes[a.cfg.resource].parent != "" && a.cfg.project == "" {
+		return a.fetchNested
+	}
+	return a.fetchData
+}
+
+// runDir returns the run directory for a run starting at now.
+func (a *app) runDir(now time.Time) string {
+	return filepath.Join(a.cfg.dataDir, runDirPrefix+a.timestamp(now))
+}
+
+// retryAfterHint returns the retry_after value of a JSON error body, such as
+// {"retry_after": 30}, which some API gateways send instead of a Retry-After
+// header, or an empty string if the body holds none. Numbers are taken as
+// seconds, strings in any format retryAfter accepts.
+func (a *app) retryAfterHint(r io.Reader) string {
+	data, err := io.ReadAll(io.LimitReader(r, int64(maxErrorBodySize)))
+	if err != nil {
+		a.log.Debug("read error response body", slog.String("error", err.Error()))
+	}
+
+	var body struct {
+		RetryAfter any `json:"retry_after"`
+	}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return ""
+	}
+
+	switch v := body.RetryAfter.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64) + "s"
+	case string:
+		return v
+	default:
+		return ""
+	}
 }
 
 // retryAfter parses a Retry-After header value and returns the duration to wait.
@@ -161,8 +559,11 @@ func (a *app) runOnce(ctx context.Context) error {
 // - Duration string (e.g., "30s", "1m")
 // - Number of seconds as integer
 // - HTTP date format
+// An HTTP date is measured against date, the Date header of the response, if
+// it is a valid HTTP date, so a local clock skewed from the server clock does
+// not shorten or lengthen the wait; otherwise against the local clock.
 // If parsing fails, it returns the default retry duration.
-func (a *app) retryAfter(s string) time.Duration {
+func (a *app) retryAfter(s, date string) time.Duration {
 	if d, err := time.ParseDuration(s); err == nil {
 		return d
 	}
@@ -173,7 +574,9 @@ func (a *app) retryAfter(s string) time.Duration {
 
 	if t, err := http.ParseTime(s); err == nil {
 		wait := time.Until(t)
-		fmt.Println(wait)
+		if now, err := http.ParseTime(date); err == nil {
+			wait = t.Sub(now)
+		}
 		if wait > 0 {
 			return wait
 		}
@@ -206,14 +609,48 @@ func (a *app) finish(ctx context.Context, errs []error) error {
 	return nil
 }
 
-// cleanup performs cleanup operations during shutdown, including closing
-// idle connections. It implements a timeout to prevent hanging during cleanup.
+// cleanup performs cleanup operations during shutdown in a fixed order:
+// auxiliary servers stop accepting requests, in-flight exports finish,
+// then idle connections are closed. It implements a timeout to prevent
+// hanging during cleanup, after which only writes in progress are awaited.
 func (a *app) cleanup() {
+	a.shutdownAuxiliary()
+
 	select {
 	case <-a.done:
 		a.log.Debug("all operations completed, cleaning up connections")
-	case <-time.After(30 * time.Second):
+	case <-time.After(cleanupTimeout):
 		a.log.Warn("cleanup timeout reached, forcing shutdown")
+		a.awaitWrites()
 	}
+
+	conns, reused := a.client.ConnStats()
+	a.log.Debug("connection stats", slog.Int64("connections", conns), slog.Int64("reused", reused))
 	a.client.CloseIdleConnections()
 }
+
+// registerShutdown registers a teardown function for an auxiliary component.
+// Registered functions run during cleanup, before in-flight exports are awaited.
+func (a *app) registerShutdown(name string, fn func(ctx context.Context) error) {
+	a.shutdownFuncs = append(a.shutdownFuncs, shutdownFunc{name: name, fn: fn})
+}
+
+// shutdownAuxiliary stops registered auxiliary components in reverse order of
+// registration. Each component is given shutdownTimeout to stop; errors are
+// logged and do not prevent the remaining components from stopping.
+func (a *app) shutdownAuxiliary() {
+	for i := len(a.shutdownFuncs) - 1; i >= 0; i-- {
+		sf := a.shutdownFuncs[i]
+
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		if err := sf.fn(ctx); err != nil {
+			a.log.Error("shutdown component",
+				slog.String("component", sf.name),
+				slog.String("error", err.Error()))
+		} else {
+			a.log.Debug("component stopped", slog.String("component", sf.name))
+		}
+		cancel()
+	}
+	a.shutdownFuncs = nil
+}
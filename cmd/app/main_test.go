@@ -1,13 +1,21 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"log/slog"
+	"net"
 	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"strings"
+	"sync"
+	"syscall"
 	"testing"
 	"time"
 
@@ -197,9 +205,14 @@ func TestAppParseInterval(t *testing.T) {
 // }
 
 func TestAppRetryAfter(t *testing.T) {
+	// The server clock runs an hour ahead of, or behind, the local clock.
+	now := time.Now().UTC()
+	serverNow, serverBehind := now.Add(time.Hour), now.Add(-time.Hour)
+
 	tests := []struct {
 		name  string
 		input string
+		date  string
 		want  time.Duration
 	}{
 		{
@@ -212,11 +225,24 @@ func TestAppRetryAfter(t *testing.T) {
 			input: "60",
 			want:  60 * time.Second,
 		},
-		// {
-		// 	name:  "HTTP date format",
-		// 	input: time.Now().UTC().Add(2 * time.Minute).Format(http.TimeFormat),
-		// 	want:  2 * time.Minute,
-		// },
+		{
+			name:  "HTTP date format",
+			input: serverNow.Add(2 * time.Minute).Format(http.TimeFormat),
+			date:  serverNow.Format(http.TimeFormat),
+			want:  2 * time.Minute,
+		},
+		{
+			name:  "HTTP date behind the local clock",
+			input: serverBehind.Add(90 * time.Second).Format(http.TimeFormat),
+			date:  serverBehind.Format(http.TimeFormat),
+			want:  90 * time.Second,
+		},
+		{
+			name:  "HTTP date past on the server clock",
+			input: serverNow.Add(-time.Minute).Format(http.TimeFormat),
+			date:  serverNow.Format(http.TimeFormat),
+			want:  time.Duration(defaultRetryAfter) * time.Second,
+		},
 		{
 			name:  "empty input",
 			input: "",
@@ -232,13 +258,19 @@ func TestAppRetryAfter(t *testing.T) {
 			input: time.Now().UTC().Add(-2 * time.Minute).Format(http.TimeFormat),
 			want:  time.Duration(defaultRetryAfter) * time.Second,
 		},
+		{
+			name:  "past date with invalid date header",
+			input: time.Now().UTC().Add(-2 * time.Minute).Format(http.TimeFormat),
+			date:  "yesterday",
+			want:  time.Duration(defaultRetryAfter) * time.Second,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			app := &app{}
 
-			got := app.retryAfter(tt.input)
+			got := app.retryAfter(tt.input, tt.date)
 
 			if got != tt.want {
 				t.Errorf("retryAfter() = %v, want %v", got, tt.want)
@@ -247,6 +279,33 @@ func TestAppRetryAfter(t *testing.T) {
 	}
 }
 
+func TestAppRetryAfterHint(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"seconds as number", `{"retry_after": 30}`, "30s"},
+		{"fractional seconds", `{"retry_after": 0.5}`, "0.5s"},
+		{"duration string", `{"retry_after": "1m"}`, "1m"},
+		{"no hint", `{"errors": [{"message": "rate limited"}]}`, ""},
+		{"unexpected type", `{"retry_after": true}`, ""},
+		{"not json", `Too Many Requests`, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := &app{log: slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{}))}
+
+			got := app.retryAfterHint(strings.NewReader(tt.body))
+
+			if got != tt.want {
+				t.Errorf("retryAfterHint() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestAppFinish(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -343,3 +402,460 @@ func TestAppCleanup(t *testing.T) {
 		})
 	}
 }
+
+func TestAppFinishShutdownServers(t *testing.T) {
+	client, _ := internal.NewClient("token", 1)
+	app := &app{
+		done:   make(chan struct{}),
+		log:    slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+		client: client,
+	}
+
+	var order []string
+	served := make([]chan error, 2)
+	for i, name := range []string{"metrics", "health"} {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("Failed to listen: %v", err)
+		}
+
+		srv := &http.Server{Handler: http.NotFoundHandler(), ReadHeaderTimeout: time.Second}
+		served[i] = make(chan error, 1)
+		go func(ch chan error) {
+			ch <- srv.Serve(ln)
+		}(served[i])
+
+		app.registerShutdown(name, func(ctx context.Context) error {
+			order = append(order, name)
+			return srv.Shutdown(ctx)
+		})
+	}
+
+	if err := app.finish(context.Background(), nil); err != nil {
+		t.Fatalf("finish() error = %v", err)
+	}
+
+	for i, ch := range served {
+		select {
+		case err := <-ch:
+			if !errors.Is(err, http.ErrServerClosed) {
+				t.Errorf("server %d Serve() error = %v, want %v", i, err, http.ErrServerClosed)
+			}
+		case <-time.After(time.Second):
+			t.Errorf("server %d still serving after finish()", i)
+		}
+	}
+
+	if len(order) != 2 || order[0] != "health" || order[1] != "metrics" {
+		t.Errorf("shutdown order = %v, want [health metrics]", order)
+	}
+	if len(app.shutdownFuncs) != 0 {
+		t.Errorf("shutdown funcs not cleared after finish(), got %d", len(app.shutdownFuncs))
+	}
+}
+
+func TestAppRunExportDirPerRun(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data": [{"gid": "1", "name": "one"}], "next_page": null}`))
+	}))
+	defer server.Close()
+
+	dataDir := t.TempDir()
+	client, _ := internal.NewClient("token", 600)
+	app := &app{
+		cfg: &config{
+			entrypoint:  server.URL,
+			resource:    "project",
+			rate:        600,
+			pageSize:    defaultPageSize,
+			dataDir:     dataDir,
+			dirPerRun:   true,
+			preserveRaw: true,
+		},
+		log:    slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+		client: client,
+	}
+
+	if err := app.runExport(context.Background()); err != nil {
+		t.Fatalf("runExport() error = %v", err)
+	}
+
+	runs, err := filepath.Glob(filepath.Join(dataDir, runDirPrefix+"*"))
+	if err != nil || len(runs) != 1 {
+		t.Fatalf("Expected 1 run directory, got %v (error %v)", runs, err)
+	}
+
+	exported, _ := filepath.Glob(filepath.Join(runs[0], "project", "project_one_*.json"))
+	if len(exported) != 1 {
+		t.Errorf("Expected 1 exported file in run directory, got %d", len(exported))
+	}
+	raw, _ := filepath.Glob(filepath.Join(runs[0], "project", rawDirName, "*.json"))
+	if len(raw) != 1 {
+		t.Errorf("Expected 1 raw file in run directory, got %d", len(raw))
+	}
+}
+
+func TestAppRunExportForbidden(t *testing.T) {
+	tests := []struct {
+		name    string
+		strict  bool
+		wantErr bool
+	}{
+		{"tolerated", false, false},
+		{"strict", true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusForbidden)
+			}))
+			defer server.Close()
+
+			var logs strings.Builder
+			client, _ := internal.NewClient("token", 600)
+			app := &app{
+				cfg: &config{
+					entrypoint: server.URL,
+					resource:   "goal",
+					rate:       600,
+					pageSize:   defaultPageSize,
+					dataDir:    t.TempDir(),
+					strict:     tt.strict,
+				},
+				log:    slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{})),
+				client: client,
+			}
+
+			err := app.runExport(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("runExport() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !strings.Contains(logs.String(), `"type_errors":{"goal":"unexpected status 403`) {
+				t.Errorf("Expected goal error in summary, got %s", logs.String())
+			}
+		})
+	}
+}
+
+func TestAppWaitStartupJitter(t *testing.T) {
+	app := &app{
+		cfg: &config{startupJitter: 20 * time.Millisecond},
+		log: slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+	}
+
+	start := time.Now()
+	if err := app.waitStartupJitter(context.Background()); err != nil {
+		t.Fatalf("waitStartupJitter() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("waitStartupJitter() waited %v, want at most %v", elapsed, app.cfg.startupJitter)
+	}
+
+	app.cfg.startupJitter = time.Hour
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := app.waitStartupJitter(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("waitStartupJitter() error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestAppRunTickCloseIdleConns(t *testing.T) {
+	tests := []struct {
+		name      string
+		closeIdle bool
+		wantConns int
+	}{
+		{
+			name:      "connections kept between ticks",
+			closeIdle: false,
+			wantConns: 1,
+		},
+		{
+			name:      "connections closed after each tick",
+			closeIdle: true,
+			wantConns: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			conns := 0
+			server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"data": [{"gid": "1", "name": "one"}], "next_page": null}`))
+			}))
+			server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+				if state == http.StateNew {
+					mu.Lock()
+					conns++
+					mu.Unlock()
+				}
+			}
+			server.Start()
+			defer server.Close()
+
+			client, _ := internal.NewClient("token", 600)
+			app := &app{
+				cfg: &config{
+					entrypoint:     server.URL,
+					resource:       "project",
+					rate:           600,
+					pageSize:       defaultPageSize,
+					dataDir:        t.TempDir(),
+					closeIdleConns: tt.closeIdle,
+				},
+				log:    slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+				client: client,
+			}
+
+			errCh := make(chan error, 2)
+			for range 2 {
+				app.wg.Add(1)
+				app.runTick(context.Background(), errCh)
+			}
+
+			select {
+			case err := <-errCh:
+				t.Fatalf("runTick() error = %v", err)
+			default:
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if conns != tt.wantConns {
+				t.Errorf("opened %d connections, want %d", conns, tt.wantConns)
+			}
+		})
+	}
+}
+
+func TestAppRunWithIntervalCollectsErrors(t *testing.T) {
+	// Slow failing responses make ticks pile up behind the governor, each
+	// waiting to export and report its error.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(50 * time.Millisecond):
+			w.WriteHeader(http.StatusNotFound)
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+
+	var logs syncBuffer
+	client, _ := internal.NewClient("token", 6000)
+	app := &app{
+		cfg: &config{
+			entrypoint:   server.URL,
+			resource:     "project",
+			rate:         6000,
+			pageSize:     defaultPageSize,
+			dataDir:      t.TempDir(),
+			emptyName:    defaultEmptyName,
+			outputFormat: formatJSON,
+		},
+		log:    slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{})),
+		client: client,
+		gov:    newGovernor(1, slog.New(slog.DiscardHandler)),
+		done:   make(chan struct{}),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	err := app.runWithInterval(ctx, 20*time.Millisecond)
+
+	// Every export finished, none is left blocked on reporting its error.
+	select {
+	case <-app.done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected every interval export to finish after shutdown")
+	}
+
+	failed := strings.Count(logs.String(), `"msg":"export error"`)
+	if failed < 2 {
+		t.Fatalf("Expected several failed exports, got %d", failed)
+	}
+	if err == nil || !strings.Contains(err.Error(), fmt.Sprintf("encountered %d errors", failed)) {
+		t.Errorf("runWithInterval() error = %v, want %d errors", err, failed)
+	}
+}
+
+// syncBuffer is a bytes.Buffer safe for concurrent use.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestErrorCollectorTimeout(t *testing.T) {
+	c := newErrorCollector()
+	var wg sync.WaitGroup
+	wg.Add(1)
+	c.ch <- errors.New("first")
+
+	if errs := c.wait(&wg, 10*time.Millisecond); len(errs) != 1 {
+		t.Errorf("wait() = %v, want the error received before the timeout", errs)
+	}
+
+	// A late export can still report its error and finish.
+	sent := make(chan struct{})
+	go func() {
+		c.ch <- errors.New("late")
+		wg.Done()
+		close(sent)
+	}()
+	select {
+	case <-sent:
+	case <-time.After(time.Second):
+		t.Fatal("Expected a late error to be received")
+	}
+}
+
+func TestAppHandleSignalsFlushesLogs(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var buf syncBuffer
+	app := &app{
+		log:    slog.New(newDedupeHandler(slog.NewJSONHandler(&buf, &slog.HandlerOptions{}), time.Hour)),
+		cancel: cancel,
+	}
+	app.log.Error("request failed")
+	app.log.Error("request failed")
+
+	sigCh := make(chan os.Signal)
+	exitCh := make(chan int, 1)
+	go app.handleSignals(sigCh, func(code int) { exitCh <- code })
+
+	sigCh <- os.Interrupt
+	<-ctx.Done()
+	sigCh <- os.Interrupt
+	select {
+	case <-exitCh:
+	case <-time.After(time.Second):
+		t.Fatal("second signal did not force exit")
+	}
+
+	if !strings.Contains(buf.String(), "request failed (x1)") {
+		t.Errorf("Expected the held back repeats to be logged on forced exit, got %s", buf.String())
+	}
+}
+
+func TestAppHandleSignals(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	app := &app{
+		log:    slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+		cancel: cancel,
+	}
+
+	sigCh := make(chan os.Signal)
+	exitCh := make(chan int, 1)
+	go app.handleSignals(sigCh, func(code int) { exitCh <- code })
+
+	sigCh <- os.Interrupt
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("first signal did not cancel the context")
+	}
+	select {
+	case code := <-exitCh:
+		t.Fatalf("first signal forced exit with code %d", code)
+	default:
+	}
+
+	sigCh <- os.Interrupt
+	select {
+	case code := <-exitCh:
+		if code != forceExitCode {
+			t.Errorf("exit code = %d, want %d", code, forceExitCode)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("second signal did not force exit")
+	}
+}
+
+// slowSink delays every write to the wrapped sink, like a slow file system,
+// and reports on started when a write begins.
+type slowSink struct {
+	sink
+	delay   time.Duration
+	started chan<- struct{}
+}
+
+func (s *slowSink) write(ctx context.Context, rc Resource) (string, error) {
+	s.started <- struct{}{}
+	time.Sleep(s.delay)
+	return s.sink.write(ctx, rc)
+}
+
+func TestAppHandleSignalsInFlightWrite(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	dataDir := t.TempDir()
+	app := &app{
+		cfg: &config{
+			resource:     "project",
+			dataDir:      dataDir,
+			emptyName:    defaultEmptyName,
+			outputFormat: formatJSON,
+		},
+		log:    slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+		cancel: cancel,
+	}
+
+	started := make(chan struct{}, 2)
+	out := &slowSink{sink: app.newSink(dataDir, time.Now()), delay: 200 * time.Millisecond, started: started}
+	page := []byte(`{"data": [
+		{"gid": "1", "name": "Alpha", "resource_type": "project"},
+		{"gid": "2", "name": "Beta", "resource_type": "project"}
+	], "next_page": null}`)
+
+	exportErr := make(chan error, 1)
+	go func() {
+		exportErr <- app.export(ctx, page, dataDir, out, &summary{})
+	}()
+
+	sigCh := make(chan os.Signal)
+	exitCh := make(chan []string, 1)
+	go app.handleSignals(sigCh, func(int) {
+		files, _ := filepath.Glob(filepath.Join(dataDir, "project", "project_*.json"))
+		exitCh <- files
+	})
+
+	<-started
+	sigCh <- syscall.SIGTERM
+	sigCh <- syscall.SIGTERM
+
+	select {
+	case files := <-exitCh:
+		if len(files) != 1 {
+			t.Fatalf("Expected the file being written to be complete at exit, got %v", files)
+		}
+		var stored Resource
+		data, err := os.ReadFile(files[0])
+		if err != nil || json.Unmarshal(data, &stored) != nil || stored.GID != "1" {
+			t.Errorf("Expected a complete file of resource 1, got %q (%v)", data, err)
+		}
+	case <-time.After(writeGrace):
+		t.Fatal("second signal did not force exit")
+	}
+
+	if err := <-exportErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("export() error = %v, want %v", err, context.Canceled)
+	}
+}
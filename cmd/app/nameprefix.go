@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// maxTypeaheadResults is the maximum number of results the typeahead endpoint
+// returns for a single query. It does not paginate.
+const maxTypeaheadResults = 100
+
+// serverSidePrefix reports whether the name prefix is applied by the API:
+// the resource type supports typeahead search, a workspace to search is
+// configured, resources are listed rather than fetched by GID or read from
+// raw pages, and the listing is not filtered in a way typeahead cannot be.
+// Otherwise the prefix is applied to fetched resources.
+func (a *app) serverSidePrefix() bool {
+	return a.cfg.namePrefix != "" &&
+		resourceTypes[a.cfg.resource].typeahead &&
+		a.cfg.workspace != "" &&
+		len(a.cfg.gidList) == 0 &&
+		!a.cfg.noFetch &&
+		!a.listingFiltered()
+}
+
+// listingFiltered reports whether the listing of the configured resources is
+// narrowed by a filter the typeahead endpoint does not support: the modified
+// or completed date filters, or the owner of types listed per owner.
+func (a *app) listingFiltered() bool {
+	return len(a.filters(time.Now())) > 0 || resourceTypes[a.cfg.resource].requiresOwner
+}
+
+// logNamePrefix logs whether the name prefix is applied server-side or, and
+// why, client-side.
+func (a *app) logNamePrefix() {
+	var reason string
+	switch {
+	case a.serverSidePrefix():
+		a.log.Info("filtering by name prefix server-side with typeahead", slog.String("name_prefix", a.cfg.namePrefix))
+		return
+	case !resourceTypes[a.cfg.resource].typeahead:
+		reason = "resource type does not support typeahead"
+	case a.cfg.workspace == "":
+		reason = "typeahead requires a workspace"
+	case len(a.cfg.gidList) > 0:
+		reason = "resources are fetched by gid"
+	case a.listingFiltered():
+		reason = "typeahead does not support the listing filters"
+	default:
+		reason = "resources are read from raw pages"
+	}
+
+	a.log.Info("filtering by name prefix client-side",
+		slog.String("name_prefix", a.cfg.namePrefix),
+		slog.String("reason", reason))
+}
+
+// matchesPrefix reports whether the name of rc starts with the configured
+// name prefix. It is applied in both paths, as typeahead also matches the
+// beginning of later words in a name.
+func (a *app) matchesPrefix(rc Resource) bool {
+	return strings.HasPrefix(rc.Name, a.cfg.namePrefix)
+}
+
+// fetchTypeahead retrieves the resources whose name matches the configured
+// name prefix with the workspace typeahead endpoint, a single page of at most
+// maxTypeaheadResults resources. As a full page may be missing matches, the
+// resources are then listed instead and the prefix is applied client-side.
+// When preserve-raw is enabled, the page is also stored unmodified under dir.
+func (a *app) fetchTypeahead(ctx context.Context, dir string, handle func(data []byte) error) error {
+	a.log.Debug("fetch resources by name prefix", slog.String("name_prefix", a.cfg.namePrefix))
+
+	data, err := a.fetchPage(ctx, a.typeaheadEndpoint())
+	if err != nil {
+		return err
+	}
+
+	if n := a.count(data); n >= maxTypeaheadResults {
+		a.log.Warn("typeahead result limit reached, filtering by name prefix client-side",
+			slog.Int("limit", maxTypeaheadResults),
+			slog.String("name_prefix", a.cfg.namePrefix))
+		return a.fetchData(ctx, dir, handle)
+	}
+
+	if a.cfg.preserveRaw {
+		if err := a.storeRaw(data, dir, time.Now(), 1); err != nil {
+			return fmt.Errorf("store raw response: %w", err)
+		}
+	}
+
+	return handle(data)
+}
+
+// typeaheadEndpoint builds the typeahead endpoint of the configured workspace
+// searching the configured resource type for the name prefix, with the
+// requested opt_fields and opt_pretty if enabled.
+func (a *app) typeaheadEndpoint() string {
+	query := url.Values{}
+	query.Set("resource_type", a.cfg.resource)
+	query.Set("query", a.cfg.namePrefix)
+	query.Set("count", fmt.Sprint(maxTypeaheadResults))
+	if len(a.cfg.optFields) > 0 {
+		query.Set("opt_fields", strings.Join(a.cfg.optFields, ","))
+	}
+	if a.cfg.optPretty {
+		query.Set("opt_pretty", "true")
+	}
+
+	return fmt.Sprintf("%s/workspaces/%s/typeahead?%s", a.entrypoint(), url.PathEscape(a.cfg.workspace), query.Encode())
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/marintailor/asana-resource-exporter/internal"
+)
+
+func TestAppRunExportNamePrefix(t *testing.T) {
+	tests := []struct {
+		name          string
+		resource      string
+		workspace     string
+		modifiedSince string
+		path          string
+	}{
+		{"server-side", "project", "12345", "", "/workspaces/12345/typeahead"},
+		{"no workspace", "project", "", "", "/projects"},
+		{"type without typeahead", "workspace", "12345", "", "/workspaces"},
+		{"filtered listing", "project", "12345", "2024-06-01T00:00:00Z", "/projects"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != tt.path {
+					t.Errorf("Expected path %s, got %s", tt.path, r.URL.Path)
+				}
+				if strings.HasSuffix(r.URL.Path, "/typeahead") {
+					q := r.URL.Query()
+					if q.Get("resource_type") != tt.resource || q.Get("query") != "Q3" {
+						t.Errorf("Unexpected typeahead query %s", r.URL.RawQuery)
+					}
+				}
+				// Typeahead also matches later words, so "Plan Q3" is returned
+				// server-side and must still be dropped.
+				_, _ = w.Write([]byte(`{"data": [{"gid": "1", "name": "Q3 Plan"}, {"gid": "2", "name": "Plan Q3"}, {"gid": "3", "name": "Q4 Plan"}], "next_page": null}`))
+			}))
+			defer server.Close()
+
+			dataDir := t.TempDir()
+			client, _ := internal.NewClient("token", 600)
+			app := &app{
+				cfg: &config{
+					entrypoint:    server.URL,
+					resource:      tt.resource,
+					workspace:     tt.workspace,
+					rate:          600,
+					pageSize:      defaultPageSize,
+					dataDir:       dataDir,
+					namePrefix:    "Q3",
+					modifiedSince: tt.modifiedSince,
+				},
+				log:    slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+				client: client,
+			}
+
+			if err := app.runExport(context.Background()); err != nil {
+				t.Fatalf("runExport() error = %v", err)
+			}
+
+			files, err := os.ReadDir(filepath.Join(dataDir, tt.resource))
+			if err != nil {
+				t.Fatalf("Failed to read resource directory: %v", err)
+			}
+			if len(files) != 1 || !strings.HasPrefix(files[0].Name(), tt.resource+"_Q3 Plan_") {
+				t.Errorf("Expected only the Q3 Plan file, got %v", files)
+			}
+		})
+	}
+}
+
+func TestAppRunExportNamePrefixTypeaheadLimit(t *testing.T) {
+	var full strings.Builder
+	for i := range maxTypeaheadResults {
+		if i > 0 {
+			full.WriteString(",")
+		}
+		fmt.Fprintf(&full, `{"gid": "%d", "name": "Plan Q3 %d"}`, i+100, i)
+	}
+
+	var listed atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/typeahead") {
+			_, _ = w.Write([]byte(`{"data": [` + full.String() + `]}`))
+			return
+		}
+		listed.Store(true)
+		_, _ = w.Write([]byte(`{"data": [{"gid": "1", "name": "Q3 Plan"}, {"gid": "2", "name": "Q4 Plan"}], "next_page": null}`))
+	}))
+	defer server.Close()
+
+	dataDir := t.TempDir()
+	client, _ := internal.NewClient("token", 600)
+	app := &app{
+		cfg: &config{
+			entrypoint: server.URL,
+			resource:   "project",
+			workspace:  "12345",
+			rate:       600,
+			pageSize:   defaultPageSize,
+			dataDir:    dataDir,
+			namePrefix: "Q3",
+		},
+		log:    slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+		client: client,
+	}
+
+	if err := app.runExport(context.Background()); err != nil {
+		t.Fatalf("runExport() error = %v", err)
+	}
+	if !listed.Load() {
+		t.Fatal("Expected a full typeahead page to fall back to listing")
+	}
+
+	files, err := os.ReadDir(filepath.Join(dataDir, "project"))
+	if err != nil {
+		t.Fatalf("Failed to read resource directory: %v", err)
+	}
+	if len(files) != 1 || !strings.HasPrefix(files[0].Name(), "project_Q3 Plan_") {
+		t.Errorf("Expected only the listed Q3 Plan file, got %v", files)
+	}
+}
+
+func TestNewConfigNamePrefixFields(t *testing.T) {
+	tests := []struct {
+		name      string
+		fields    string
+		countOnly bool
+		want      string
+	}{
+		{"name added", "notes", false, "notes,name"},
+		{"name kept", "name,notes", false, "name,notes"},
+		{"count only", "", true, "gid,name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := newConfig(options{cfg: config{
+				entrypoint: defaultEntrypoint,
+				resource:   "project",
+				rate:       60,
+				pageSize:   defaultPageSize,
+				fields:     tt.fields,
+				countOnly:  tt.countOnly,
+				namePrefix: "Q3",
+			}})
+			if err != nil {
+				t.Fatalf("newConfig() error = %v", err)
+			}
+			if got := strings.Join(cfg.optFields, ","); got != tt.want {
+				t.Errorf("optFields = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
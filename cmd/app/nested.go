@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+)
+
+// fetchNested retrieves the resources of a type nested in a parent, such as
+// sections in projects, from every parent in the workspace. The parents are
+// listed first, then the resources within each are fetched with fetchPages,
+// so every page is passed to handle as for a type without a parent.
+func (a *app) fetchNested(ctx context.Context, dir string, handle func(data []byte) error) error {
+	parent := resourceTypes[a.cfg.resource].parent
+	gids, err := a.listParents(ctx)
+	if err != nil {
+		return fmt.Errorf("list %s parents: %w", parent, err)
+	}
+	a.log.Info("fetching nested resources", slog.String("parent", parent), slog.Int("parents", len(gids)))
+
+	if len(gids) == 0 {
+		return a.degrade(errEmptyResult)
+	}
+
+	for _, gid := range gids {
+		if err := a.fetchPages(ctx, dir, gid, handle); err != nil {
+			return fmt.Errorf("%s %s: %w", parent, gid, err)
+		}
+	}
+
+	return nil
+}
+
+// listParents returns the GIDs of all resources of the parent type of the
+// configured resource in the workspace, following pagination.
+func (a *app) listParents(ctx context.Context) ([]string, error) {
+	parent := resourceTypes[resourceTypes[a.cfg.resource].parent]
+	query := url.Values{}
+	query.Set("workspace", a.cfg.workspace)
+
+	return a.listGIDs(ctx, parent.path, query)
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/marintailor/asana-resource-exporter/internal"
+)
+
+func TestAppRunExportSections(t *testing.T) {
+	tests := []struct {
+		name      string
+		project   string
+		wantFiles int
+	}{
+		{"single project", "100", 1},
+		{"every project in the workspace", "", 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/projects":
+					if r.URL.Query().Get("workspace") != "12345" {
+						t.Errorf("Expected workspace 12345, got %q", r.URL.Query().Get("workspace"))
+					}
+					if r.URL.Query().Get("offset") == "" {
+						_, _ = w.Write([]byte(`{"data": [{"gid": "100"}], "next_page": {"offset": "p2"}}`))
+						return
+					}
+					_, _ = w.Write([]byte(`{"data": [{"gid": "200"}], "next_page": null}`))
+				case "/projects/100/sections":
+					if r.URL.Query().Has("workspace") {
+						t.Error("Expected no workspace parameter for nested resources")
+					}
+					_, _ = w.Write([]byte(`{"data": [{"gid": "1", "name": "To Do", "resource_type": "section"}], "next_page": null}`))
+				case "/projects/200/sections":
+					_, _ = w.Write([]byte(`{"data": [
+						{"gid": "2", "name": "Doing", "resource_type": "section"},
+						{"gid": "3", "name": "Done", "resource_type": "section"}
+					], "next_page": null}`))
+				default:
+					t.Errorf("Unexpected path %s", r.URL.Path)
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer server.Close()
+
+			dataDir := t.TempDir()
+			client, _ := internal.NewClient("token", 600)
+			app := &app{
+				cfg: &config{
+					entrypoint:   server.URL,
+					resource:     "section",
+					workspace:    "12345",
+					project:      tt.project,
+					rate:         600,
+					pageSize:     defaultPageSize,
+					dataDir:      dataDir,
+					emptyName:    defaultEmptyName,
+					outputFormat: formatJSON,
+				},
+				log:    slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+				client: client,
+			}
+
+			if err := app.runExport(context.Background()); err != nil {
+				t.Fatalf("runExport() error = %v", err)
+			}
+
+			files, err := os.ReadDir(filepath.Join(dataDir, "section"))
+			if err != nil {
+				t.Fatalf("Failed to read resource directory: %v", err)
+			}
+			if len(files) != tt.wantFiles {
+				t.Errorf("Expected %d files, got %d", tt.wantFiles, len(files))
+			}
+		})
+	}
+}
+
+func TestNewConfigSections(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config
+		wantErr bool
+	}{
+		{"project", config{resource: "section", project: "100"}, false},
+		{"workspace", config{resource: "section", workspace: "12345"}, false},
+		{"gids", config{resource: "section", gids: "1,2"}, false},
+		{"neither project nor workspace", config{resource: "section"}, true},
+		{"resume without project", config{resource: "section", workspace: "12345", resume: true}, true},
+		{"project for a type without parent", config{resource: "tag", project: "100"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.entrypoint = defaultEntrypoint
+			tt.cfg.rate = 60
+			tt.cfg.pageSize = defaultPageSize
+			_, err := newConfig(options{cfg: tt.cfg})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("newConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"time"
+
+	"github.com/marintailor/asana-resource-exporter/internal"
+)
+
+// Notification payloads selectable with -notify-format.
+const (
+	notifyJSON  = "json"  // The notification schema
+	notifySlack = "slack" // A Slack incoming webhook message
+)
+
+// notifyFormats lists the supported notification payloads.
+var notifyFormats = []string{notifyJSON, notifySlack}
+
+// notifyRate is the rate limit, in requests per minute, of the notification
+// client. A run posts a single notification, so it only guards against
+// interval runs following each other in quick succession.
+const notifyRate = 60
+
+// notifyTimeout bounds the request posting a notification, which is also
+// sent when the run was cancelled by a shutdown.
+const notifyTimeout = 10 * time.Second
+
+// notification is the status of a run posted to the notify URL with
+// -notify-format=json.
+type notification struct {
+	Resource   string        `json:"resource"`    // Resource type exported
+	Success    bool          `json:"success"`     // Whether the run completed without errors
+	Status     string        `json:"status"`      // ok, error, or canceled
+	DurationMS int64         `json:"duration_ms"` // Duration of the run in milliseconds
+	Counts     summaryCounts `json:"counts"`      // Resource and file counts
+	ErrorCount int           `json:"error_count"` // Number of errors that failed the run
+	Errors     []string      `json:"errors"`      // Errors that failed the run, empty if none
+}
+
+// newNotifier returns the client posting notifications. It carries no API
+// token, so the token is never sent to the webhook, and none of the options
+// of the API client, which apply to the Asana API only. It logs nothing, as
+// webhook URLs commonly embed a secret.
+func newNotifier() (apiClient, error) {
+	return internal.NewClient("", notifyRate)
+}
+
+// notify posts the status of the run described by sum that ended with err to
+// the notify URL in the configured format, after successful and failed runs
+// alike. A failed notification is logged and does not fail the run.
+func (a *app) notify(ctx context.Context, sum *summary, err error) {
+	if a.notifier == nil {
+		return
+	}
+
+	body, merr := a.notification(sum.report(err, time.Now()), err)
+	if merr != nil {
+		a.log.Warn("encode notification", slog.String("error", merr.Error()))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), notifyTimeout)
+	defer cancel()
+
+	if nerr := a.postNotification(ctx, body); nerr != nil {
+		a.log.Warn("notify", slog.String("error", nerr.Error()))
+		return
+	}
+	a.log.Debug("notification sent")
+}
+
+// notification returns the payload of the run reported by r that ended with
+// err in the configured notify format.
+func (a *app) notification(r summaryReport, err error) ([]byte, error) {
+	n := notification{
+		Resource:   r.Resource,
+		Success:    r.Status == "ok",
+		Status:     r.Status,
+		DurationMS: r.DurationMS,
+		Counts:     r.Counts,
+		Errors:     r.Errors,
+	}
+	if n.Status == "error" {
+		n.ErrorCount = errorCount(err)
+	}
+	if a.cfg.notifyFormat != notifySlack {
+		return json.Marshal(n)
+	}
+
+	outcome := "succeeded"
+	switch n.Status {
+	case "error":
+		outcome = "failed"
+	case "canceled":
+		outcome = "was canceled"
+	}
+	text := fmt.Sprintf("Asana %s export %s in %s: %d written, %d pages, %d errors",
+		n.Resource, outcome, time.Duration(n.DurationMS)*time.Millisecond, n.Counts.Written, n.Counts.Pages, n.ErrorCount)
+	for _, e := range n.Errors {
+		text += "\n" + e
+	}
+
+	return json.Marshal(struct {
+		Text string `json:"text"`
+	}{text})
+}
+
+// postNotification posts body to the notify URL and fails on a response
+// without a 2xx status.
+func (a *app) postNotification(ctx context.Context, body []byte) error {
+	resp, err := a.notifier.PostJSON(ctx, a.cfg.notifyURL, bytes.NewReader(body))
+	if err != nil {
+		// Keep the webhook URL out of the logged error.
+		var uerr *url.Error
+		if errors.As(err, &uerr) {
+			err = uerr.Err
+		}
+		return fmt.Errorf("post notification: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("post notification: unexpected status %s", resp.Status)
+	}
+
+	return nil
+}
+
+// errorCount returns the number of errors err consists of: those joined by
+// errors.Join, one for any other error, and none for nil.
+func errorCount(err error) int {
+	if err == nil {
+		return 0
+	}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		return len(joined.Unwrap())
+	}
+	return 1
+}
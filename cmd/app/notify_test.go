@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/marintailor/asana-resource-exporter/internal"
+)
+
+func TestAppRunExportNotify(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("workspace") == "fail" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data": [
+			{"gid": "1", "name": "Alpha", "resource_type": "project"},
+			{"gid": "2", "name": "Beta", "resource_type": "project"}
+		], "next_page": null}`))
+	}))
+	defer api.Close()
+
+	tests := []struct {
+		name        string
+		workspace   string
+		wantSuccess bool
+		wantWritten int
+		wantErrors  int
+	}{
+		{"success", "1", true, 2, 0},
+		{"failure", "fail", false, 0, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got notification
+			var auth string
+			webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				auth = r.Header.Get("Authorization")
+				if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+					t.Errorf("Failed to decode notification: %v", err)
+				}
+			}))
+			defer webhook.Close()
+
+			client, _ := internal.NewClient("token", 600)
+			notifier, _ := newNotifier()
+			app := &app{
+				cfg: &config{
+					entrypoint:   api.URL,
+					resource:     "project",
+					workspace:    tt.workspace,
+					rate:         600,
+					pageSize:     defaultPageSize,
+					dataDir:      t.TempDir(),
+					emptyName:    defaultEmptyName,
+					outputFormat: formatJSON,
+					notifyURL:    webhook.URL,
+					notifyFormat: notifyJSON,
+				},
+				log:      slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+				client:   client,
+				notifier: notifier,
+			}
+
+			err := app.runExport(context.Background())
+			if (err == nil) != tt.wantSuccess {
+				t.Fatalf("runExport() error = %v, want success %v", err, tt.wantSuccess)
+			}
+
+			if got.Success != tt.wantSuccess || got.Resource != "project" {
+				t.Errorf("notification = %+v, want success %v for project", got, tt.wantSuccess)
+			}
+			if got.Counts.Written != tt.wantWritten || got.ErrorCount != tt.wantErrors || len(got.Errors) != tt.wantErrors {
+				t.Errorf("notification = %+v, want %d written and %d errors", got, tt.wantWritten, tt.wantErrors)
+			}
+			if auth != "" {
+				t.Errorf("Expected no API token sent to the webhook, got %q", auth)
+			}
+		})
+	}
+}
+
+func TestAppNotifySlack(t *testing.T) {
+	var got struct {
+		Text string `json:"text"`
+	}
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("Failed to decode notification: %v", err)
+		}
+	}))
+	defer webhook.Close()
+
+	notifier, _ := newNotifier()
+	app := &app{
+		cfg:      &config{notifyURL: webhook.URL, notifyFormat: notifySlack},
+		log:      slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+		notifier: notifier,
+	}
+
+	sum := &summary{resource: "task", written: 3, pages: 1}
+	app.notify(context.Background(), sum, errors.Join(errors.New("first"), errors.New("second")))
+
+	for _, want := range []string{"Asana task export failed", "3 written", "2 errors", "first\nsecond"} {
+		if !strings.Contains(got.Text, want) {
+			t.Errorf("Slack text = %q, want it to contain %q", got.Text, want)
+		}
+	}
+}
+
+func TestAppNotifyFailure(t *testing.T) {
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+
+	var logs strings.Builder
+	notifier, _ := newNotifier()
+	app := &app{
+		cfg:      &config{notifyURL: webhook.URL + "/secret", notifyFormat: notifyJSON},
+		log:      slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{})),
+		notifier: notifier,
+	}
+
+	app.notify(context.Background(), &summary{resource: "project"}, nil)
+	if !strings.Contains(logs.String(), "unexpected status 500") {
+		t.Errorf("Expected the failed notification to be logged, got %s", logs.String())
+	}
+
+	// An unreachable webhook fails without the URL in the error.
+	webhook.Close()
+	logs.Reset()
+	app.notify(context.Background(), &summary{resource: "project"}, nil)
+	if !strings.Contains(logs.String(), "post notification") {
+		t.Errorf("Expected the failed notification to be logged, got %s", logs.String())
+	}
+	if strings.Contains(logs.String(), "secret") {
+		t.Errorf("Expected the notify URL to be kept out of the logs, got %s", logs.String())
+	}
+}
+
+func TestErrorCount(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"nil", nil, 0},
+		{"single", errors.New("failed"), 1},
+		{"joined", errors.Join(errors.New("first"), errors.New("second")), 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := errorCount(tt.err); got != tt.want {
+				t.Errorf("errorCount() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
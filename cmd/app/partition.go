@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"time"
+)
+
+// partitionLayout is the layout of the date subdirectories of the resource
+// directory files are written to with -partition-by-modified.
+const partitionLayout = "2006/01/02"
+
+// partitionDir returns the subdirectory of rcDir rc is written to with
+// -partition-by-modified, {yyyy}/{mm}/{dd} of its modified_at date in the
+// configured time zone, creating it if needed. Resources without a parsable
+// modified_at, e.g. because their type has no such field, fall back to the
+// date of the run.
+func (a *app) partitionDir(rcDir string, rc Resource, runTime time.Time) (string, error) {
+	date := runTime
+	if modified, ok := modifiedAt(rc); ok {
+		date = modified
+	} else {
+		a.log.Debug("modified_at missing, partitioning by run date", slog.String("gid", rc.GID))
+	}
+
+	dir, err := a.dataPath(filepath.Join(rcDir, filepath.FromSlash(date.In(a.location()).Format(partitionLayout))))
+	if err != nil {
+		return "", err
+	}
+	if err := a.resourceDir(dir); err != nil {
+		return "", fmt.Errorf("resource directory: %w", err)
+	}
+
+	return dir, nil
+}
+
+// modifiedAt returns the modified_at time of rc, reporting whether the field
+// is present and holds an RFC3339 time.
+func modifiedAt(rc Resource) (time.Time, bool) {
+	var fields struct {
+		ModifiedAt string `json:"modified_at"`
+	}
+	if len(rc.raw) == 0 || json.Unmarshal(rc.raw, &fields) != nil || fields.ModifiedAt == "" {
+		return time.Time{}, false
+	}
+
+	t, err := time.Parse(time.RFC3339, fields.ModifiedAt)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return t, true
+}
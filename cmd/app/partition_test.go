@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/marintailor/asana-resource-exporter/internal"
+)
+
+func TestAppRunExportPartitionByModified(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.URL.Query().Get("opt_fields"), "modified_at") {
+			t.Errorf("Expected modified_at in opt_fields, got %q", r.URL.Query().Get("opt_fields"))
+		}
+		_, _ = w.Write([]byte(`{"data": [
+			{"gid": "1", "name": "Alpha", "resource_type": "project", "modified_at": "2024-06-01T12:30:00.000Z"},
+			{"gid": "2", "name": "Beta", "resource_type": "project", "modified_at": "2023-12-31T23:59:59+00:00"},
+			{"gid": "3", "name": "Gamma", "resource_type": "project"}
+		], "next_page": null}`))
+	}))
+	defer server.Close()
+
+	dataDir := t.TempDir()
+	cfg, err := newConfig(options{cfg: config{
+		entrypoint:   server.URL,
+		resource:     "project",
+		workspace:    "12345",
+		rate:         600,
+		pageSize:     defaultPageSize,
+		dataDir:      dataDir,
+		outputFormat: formatJSON,
+		writeIndex:   true,
+		partition:    true,
+	}})
+	if err != nil {
+		t.Fatalf("newConfig() error = %v", err)
+	}
+
+	client, _ := internal.NewClient("token", 600)
+	app := &app{
+		cfg:    cfg,
+		log:    slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+		client: client,
+	}
+
+	before := time.Now().UTC().Format(partitionLayout)
+	if err := app.runExport(context.Background()); err != nil {
+		t.Fatalf("runExport() error = %v", err)
+	}
+	after := time.Now().UTC().Format(partitionLayout)
+
+	rcDir := filepath.Join(dataDir, "project")
+	for _, tt := range []struct {
+		name string
+		dirs []string
+	}{
+		{"Alpha", []string{"2024/06/01"}},
+		{"Beta", []string{"2023/12/31"}},
+		{"Gamma", []string{before, after}},
+	} {
+		var found bool
+		for _, dir := range tt.dirs {
+			files, _ := filepath.Glob(filepath.Join(rcDir, filepath.FromSlash(dir), "project_"+tt.name+"_*.json"))
+			found = found || len(files) == 1
+		}
+		if !found {
+			t.Errorf("Expected %s under %v", tt.name, tt.dirs)
+		}
+	}
+
+	index, err := os.ReadFile(filepath.Join(rcDir, indexFileName))
+	if err != nil {
+		t.Fatalf("Failed to read index: %v", err)
+	}
+	if !strings.Contains(string(index), `"filename": "2024/06/01/project_Alpha_`) {
+		t.Errorf("Expected index file names relative to the resource directory, got %s", index)
+	}
+}
+
+func TestAppPartitionDir(t *testing.T) {
+	dataDir := t.TempDir()
+	blocked := filepath.Join(dataDir, "blocked")
+	if err := os.MkdirAll(filepath.Join(blocked, "2024"), 0o755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(blocked, "2024", "06"), nil, 0o600); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		rcDir   string
+		want    string
+		wantErr bool
+	}{
+		{"created", filepath.Join(dataDir, "project"), filepath.Join(dataDir, "project", "2024", "06", "01"), false},
+		{"outside data dir", t.TempDir(), "", true},
+		{"file in the way", blocked, "", true},
+	}
+
+	var rc Resource
+	if err := rc.UnmarshalJSON([]byte(`{"gid": "1", "modified_at": "2024-06-01T12:30:00Z"}`)); err != nil {
+		t.Fatalf("Failed to unmarshal resource: %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := &app{
+				cfg: &config{dataDir: dataDir},
+				log: slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+			}
+
+			dir, err := app.partitionDir(tt.rcDir, rc, time.Now())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("partitionDir() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if dir != tt.want {
+				t.Errorf("partitionDir() = %q, want %q", dir, tt.want)
+			}
+			if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+				t.Errorf("Expected directory %s to exist, got %v", dir, err)
+			}
+		})
+	}
+}
+
+func TestNewConfigPartitionByModified(t *testing.T) {
+	tests := []struct {
+		name         string
+		outputFormat string
+		fields       string
+		wantErr      bool
+		wantFields   []string
+	}{
+		{"json", formatJSON, "name", false, []string{"name", "modified_at"}},
+		{"modified_at already requested", formatJSON, "name,modified_at", false, []string{"name", "modified_at"}},
+		{"jsonl", formatJSONL, "", true, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := newConfig(options{cfg: config{
+				entrypoint:   defaultEntrypoint,
+				resource:     "project",
+				workspace:    "12345",
+				rate:         60,
+				pageSize:     defaultPageSize,
+				outputFormat: tt.outputFormat,
+				fields:       tt.fields,
+				partition:    true,
+			}})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !slices.Equal(cfg.optFields, tt.wantFields) {
+				t.Errorf("optFields = %v, want %v", cfg.optFields, tt.wantFields)
+			}
+		})
+	}
+}
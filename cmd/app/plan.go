@@ -0,0 +1,82 @@
+package main
+
+import (
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// logPlan logs, once before the first request, a single line describing what
+// the run will do: what is read from where, how it is narrowed down, and
+// where the result goes. It records the intent of a run so exports can be
+// correlated with their configuration long after the fact.
+func (a *app) logPlan(interval time.Duration) {
+	mode := "export"
+	switch {
+	case a.cfg.countOnly:
+		mode = "count"
+	case a.cfg.noFetch:
+		mode = "from-raw"
+	}
+
+	source := []any{
+		slog.String("entrypoint", a.client.Redact(a.cfg.entrypoint)),
+		slog.String("workspace", a.cfg.workspace),
+		slog.Bool("auto_workspaces", a.cfg.autoWorkspaces),
+		slog.String("project", a.cfg.project),
+		slog.Int("page_size", a.cfg.pageSize),
+		slog.Int("rate", a.cfg.rate),
+		slog.String("fields", strings.Join(a.cfg.optFields, ",")),
+		slog.Int("gids", len(a.cfg.gidList)),
+	}
+	if a.cfg.fallbackEntrypoint != "" {
+		source = append(source, slog.String("fallback_entrypoint", a.client.Redact(a.cfg.fallbackEntrypoint)))
+	}
+	if a.cfg.noFetch {
+		source = append(source, slog.String("from_raw", a.cfg.fromRaw))
+	}
+
+	var filters []any
+	for _, f := range []struct{ key, value string }{
+		{"modified_since", a.cfg.modifiedSince},
+		{"since", a.cfg.since},
+		{"completed_since", a.cfg.completedSince},
+		{"completed", a.completedFilter()},
+		{"name_prefix", a.cfg.namePrefix},
+		{"filter", a.cfg.filterExpr},
+		{"schema", a.cfg.schemaFile},
+	} {
+		if f.value != "" {
+			filters = append(filters, slog.String(f.key, f.value))
+		}
+	}
+
+	destination := []any{
+		slog.String("data_dir", a.cfg.dataDir),
+		slog.String("output_format", a.cfg.outputFormat),
+		slog.Bool("dir_per_run", a.cfg.dirPerRun),
+		slog.Bool("partition_by_modified", a.cfg.partition),
+		slog.Bool("flatten_dir", a.cfg.flattenDir),
+		slog.Bool("export_graph", a.cfg.exportGraph),
+	}
+	if a.cfg.countOnly {
+		destination = []any{slog.String("count_output", a.cfg.countOutput)}
+	}
+
+	a.log.Info("plan",
+		slog.String("mode", mode),
+		slog.String("resource", a.cfg.resource),
+		slog.String("interval", interval.String()),
+		slog.Group("source", source...),
+		slog.Group("filters", filters...),
+		slog.Group("destination", destination...))
+}
+
+// completedFilter returns the -completed value in effect, or an empty string
+// if tasks are not filtered by completion.
+func (a *app) completedFilter() string {
+	if a.cfg.completed == completedAll {
+		return ""
+	}
+	return a.cfg.completed
+}
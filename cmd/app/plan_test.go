@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/marintailor/asana-resource-exporter/internal"
+)
+
+func TestAppLogPlan(t *testing.T) {
+	var logs strings.Builder
+	client, _ := internal.NewClient("secret-token", 600)
+	app := &app{
+		cfg: &config{
+			entrypoint:   "https://proxy.example.com/secret-token/api/1.0",
+			resource:     "task",
+			workspace:    "123",
+			rate:         600,
+			pageSize:     50,
+			dataDir:      "/exports",
+			outputFormat: formatJSONL,
+			optFields:    []string{"name", "notes"},
+			since:        "24h",
+			namePrefix:   "Q3",
+		},
+		log:    slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{})),
+		client: client,
+	}
+
+	app.logPlan(time.Hour)
+
+	if strings.Contains(logs.String(), "secret-token") {
+		t.Fatalf("Plan leaks the token: %s", logs.String())
+	}
+
+	var plan struct {
+		Msg      string `json:"msg"`
+		Mode     string `json:"mode"`
+		Resource string `json:"resource"`
+		Interval string `json:"interval"`
+		Source   struct {
+			Entrypoint string `json:"entrypoint"`
+			PageSize   int    `json:"page_size"`
+			Fields     string `json:"fields"`
+		} `json:"source"`
+		Filters     map[string]string `json:"filters"`
+		Destination struct {
+			DataDir      string `json:"data_dir"`
+			OutputFormat string `json:"output_format"`
+		} `json:"destination"`
+	}
+	if err := json.Unmarshal([]byte(logs.String()), &plan); err != nil {
+		t.Fatalf("Failed to unmarshal plan: %v", err)
+	}
+
+	if plan.Msg != "plan" || plan.Mode != "export" || plan.Resource != "task" || plan.Interval != "1h0m0s" {
+		t.Errorf("Unexpected plan: %+v", plan)
+	}
+	if plan.Source.Entrypoint != "https://proxy.example.com/[REDACTED]/api/1.0" || plan.Source.PageSize != 50 || plan.Source.Fields != "name,notes" {
+		t.Errorf("Unexpected source: %+v", plan.Source)
+	}
+	if len(plan.Filters) != 2 || plan.Filters["since"] != "24h" || plan.Filters["name_prefix"] != "Q3" {
+		t.Errorf("Unexpected filters: %v", plan.Filters)
+	}
+	if plan.Destination.DataDir != "/exports" || plan.Destination.OutputFormat != formatJSONL {
+		t.Errorf("Unexpected destination: %+v", plan.Destination)
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+
+	"github.com/marintailor/asana-resource-exporter/internal"
+)
+
+// runProbe checks connectivity and the API token with a single request for the
+// authenticated user and writes the result to w. The returned error explains
+// whether the entrypoint, the network, or the token is at fault.
+func (a *app) runProbe(ctx context.Context, w io.Writer) error {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	endpoint := a.cfg.entrypoint + "/users/me?opt_fields=name,email"
+	data, err := a.call(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return probeError(a.cfg.entrypoint, err)
+	}
+
+	var resp struct {
+		Data struct {
+			GID   string `json:"gid"`
+			Name  string `json:"name"`
+			Email string `json:"email"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil || resp.Data.GID == "" {
+		return fmt.Errorf("unexpected response from %s, is it the Asana API?", a.cfg.entrypoint)
+	}
+
+	_, err = fmt.Fprintf(w, "ok: authenticated as %s <%s> (gid %s) at %s\n",
+		resp.Data.Name, resp.Data.Email, resp.Data.GID, a.cfg.entrypoint)
+	return err
+}
+
+// probeError translates a failed probe request into an error naming the
+// likely cause.
+func probeError(entrypoint string, err error) error {
+	var apiErr *internal.APIError
+	var netErr net.Error
+	switch {
+	case errors.Is(err, internal.ErrInvalidEndpoint):
+		return fmt.Errorf("invalid entrypoint URL %q", entrypoint)
+	case errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnauthorized:
+		return fmt.Errorf("token rejected, check ASANA_API_TOKEN: %w", err)
+	case errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusForbidden:
+		return fmt.Errorf("token not permitted to read the authenticated user: %w", err)
+	case errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound:
+		return fmt.Errorf("entrypoint %s does not serve the Asana API: %w", entrypoint, err)
+	case errors.As(err, &apiErr):
+		return fmt.Errorf("api error: %w", err)
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr):
+		return fmt.Errorf("cannot reach %s: %w", entrypoint, err)
+	default:
+		return err
+	}
+}
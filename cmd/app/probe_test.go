@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/marintailor/asana-resource-exporter/internal"
+)
+
+func TestAppRunProbe(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		closed  bool
+		want    string
+		wantErr string
+	}{
+		{
+			name:   "authenticated",
+			status: http.StatusOK,
+			body:   `{"data": {"gid": "42", "name": "Jane Smith", "email": "jane@example.com"}}`,
+			want:   "ok: authenticated as Jane Smith <jane@example.com> (gid 42)",
+		},
+		{
+			name:    "invalid token",
+			status:  http.StatusUnauthorized,
+			wantErr: "token rejected",
+		},
+		{
+			name:    "wrong entrypoint",
+			status:  http.StatusNotFound,
+			wantErr: "does not serve the Asana API",
+		},
+		{
+			name:    "not the asana api",
+			status:  http.StatusOK,
+			body:    `<html></html>`,
+			wantErr: "unexpected response",
+		},
+		{
+			name:    "unreachable",
+			closed:  true,
+			wantErr: "cannot reach",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/users/me" {
+					t.Errorf("Expected path /users/me, got %s", r.URL.Path)
+				}
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+			if tt.closed {
+				server.Close()
+			}
+
+			client, _ := internal.NewClient("token", 600)
+			app := &app{
+				cfg:    &config{entrypoint: server.URL},
+				log:    slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+				client: client,
+			}
+
+			var out bytes.Buffer
+			err := app.runProbe(context.Background(), &out)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("runProbe() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("runProbe() error = %v", err)
+			}
+			if !strings.HasPrefix(out.String(), tt.want) {
+				t.Errorf("runProbe() output = %q, want prefix %q", out.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestNewConfigProbeWithoutResource(t *testing.T) {
+	_, err := newConfig(options{
+		cfg:   config{entrypoint: defaultEntrypoint, rate: 60, pageSize: defaultPageSize},
+		probe: true,
+	})
+	if err != nil {
+		t.Errorf("newConfig() error = %v, want nil for probe without resource", err)
+	}
+}
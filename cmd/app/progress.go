@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// progressInterval is the minimum time between redraws of the progress bar,
+// so fast runs do not flood the terminal.
+const progressInterval = 100 * time.Millisecond
+
+// progressFrames are the frames of the spinner showing the bar is alive.
+const progressFrames = `|/-\`
+
+// progressBar draws a single, continuously redrawn status line of the current
+// run on a terminal: the resource type, the pages fetched, and the resources
+// written, or counted in count-only mode. A nil progressBar draws nothing.
+type progressBar struct {
+	w io.Writer // Terminal the line is drawn on
+
+	mu    sync.Mutex // Guards the fields below
+	drawn time.Time  // Time of the last redraw
+	frame int        // Current spinner frame
+	width int        // Length of the last line drawn, cleared by the next
+}
+
+// newProgressBar returns a progress bar drawn on f, or nil if f is not a
+// terminal, in which case the line would only clutter redirected output.
+func newProgressBar(f *os.File) *progressBar {
+	if !isTerminal(f) {
+		return nil
+	}
+	return &progressBar{w: f}
+}
+
+// isTerminal reports whether f is attached to a terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// update redraws the line with the counters of sum, at most once per
+// progressInterval.
+func (p *progressBar) update(sum *summary) {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if time.Since(p.drawn) < progressInterval {
+		return
+	}
+	p.frame = (p.frame + 1) % len(progressFrames)
+	p.draw(sum, string(progressFrames[p.frame]))
+}
+
+// finish draws the final counters of sum and ends the line, so the next
+// output starts on a line of its own.
+func (p *progressBar) finish(sum *summary) {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.draw(sum, "done")
+	_, _ = io.WriteString(p.w, "\n")
+	p.width = 0
+}
+
+// draw overwrites the current line with the counters of sum and status.
+func (p *progressBar) draw(sum *summary, status string) {
+	done := fmt.Sprintf("%d written", sum.written)
+	if sum.counted > 0 {
+		done = fmt.Sprintf("%d counted", sum.counted)
+	}
+	line := fmt.Sprintf("%s %s %d pages, %s", sum.resource, status, sum.pages, done)
+
+	pad := ""
+	if n := p.width - len(line); n > 0 {
+		pad = strings.Repeat(" ", n)
+	}
+	_, _ = io.WriteString(p.w, "\r"+line+pad)
+	p.width = len(line)
+	p.drawn = time.Now()
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProgressBar(t *testing.T) {
+	var buf bytes.Buffer
+	p := &progressBar{w: &buf}
+	sum := &summary{resource: "project", pages: 1, written: 100}
+
+	p.update(sum)
+	if got := buf.String(); got != "\rproject / 1 pages, 100 written" {
+		t.Errorf("update() drew %q", got)
+	}
+
+	// Redraws are throttled.
+	sum.pages, sum.written = 2, 200
+	p.update(sum)
+	if strings.Contains(buf.String(), "2 pages") {
+		t.Errorf("Expected the second update to be throttled, got %q", buf.String())
+	}
+
+	buf.Reset()
+	p.finish(sum)
+	if got := buf.String(); got != "\rproject done 2 pages, 200 written\n" {
+		t.Errorf("finish() drew %q", got)
+	}
+}
+
+func TestProgressBarClearsLongerLine(t *testing.T) {
+	var buf bytes.Buffer
+	p := &progressBar{w: &buf, width: 40}
+
+	p.finish(&summary{resource: "task", pages: 3, counted: 250})
+	if got, want := buf.String(), "\rtask done 3 pages, 250 counted"+strings.Repeat(" ", 10)+"\n"; got != want {
+		t.Errorf("finish() drew %q, want %q", got, want)
+	}
+}
+
+func TestNewProgressBarNotTerminal(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "out"))
+	if err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	p := newProgressBar(f)
+	if p != nil {
+		t.Fatal("Expected no progress bar on a regular file")
+	}
+
+	// A disabled progress bar draws nothing.
+	p.update(&summary{})
+	p.finish(&summary{})
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+// exportFilePattern returns a pattern matching the names of files written by
+// the exporter for resource: exported resources ({resource}_{name}_{timestamp}.json),
+// raw pages ({resource}_{timestamp}_{page}.json), and NDJSON files
+// ({resource}_{timestamp}[_{part}].jsonl[.gz]).
+func exportFilePattern(resource string) *regexp.Regexp {
+	return regexp.MustCompile(`^` + regexp.QuoteMeta(resource) + `_(.*_)?` + timestampPattern + `(_\d{4})?\.(json|jsonl|jsonl\.gz)$`)
+}
+
+// prune deletes export files of the configured resource in the data directory
+// whose modification time is older than the retention period before now.
+// Files not matching the exporter's naming pattern are never touched, except
+// for the sidecar checksum files of deleted files. It returns the number of
+// deleted files.
+func (a *app) prune(now time.Time) (int, error) {
+	pattern := exportFilePattern(a.cfg.resource)
+	cutoff := now.Add(-a.cfg.retentionDuration)
+
+	pruned := 0
+	err := filepath.WalkDir(a.cfg.dataDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if !d.Type().IsRegular() || !pattern.MatchString(d.Name()) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return fmt.Errorf("file info: %w", err)
+		}
+		if !info.ModTime().Before(cutoff) {
+			return nil
+		}
+
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("remove file: %w", err)
+		}
+		if err := os.Remove(path + checksumSuffix); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("remove checksum: %w", err)
+		}
+		a.log.Debug("pruned export file", slog.String("filename", path))
+		pruned++
+
+		return nil
+	})
+
+	return pruned, err
+}
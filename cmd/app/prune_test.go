@@ -0,0 +1,110 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestExportFilePattern(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		want bool
+	}{
+		{"resource file", "project_My Project_20240205143022.json", true},
+		{"raw page", "project_20240205143022_0001.json", true},
+		{"zoned resource file", "project_My Project_20241027023000+0200.json", true},
+		{"zoned raw page", "project_20241027023000-0500_0001.json", true},
+		{"zoned ndjson part", "project_20241027023000+0100_0002.jsonl.gz", true},
+		{"other resource", "user_Alice_20240205143022.json", false},
+		{"checkpoint", checkpointFileName, false},
+		{"foreign file", "notes.json", false},
+		{"missing timestamp", "project_report.json", false},
+	}
+
+	pattern := exportFilePattern("project")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pattern.MatchString(tt.file); got != tt.want {
+				t.Errorf("MatchString(%q) = %v, want %v", tt.file, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAppPrune(t *testing.T) {
+	dataDir := t.TempDir()
+	now := time.Now()
+	old := now.Add(-48 * time.Hour)
+
+	files := []struct {
+		path    string
+		modTime time.Time
+		pruned  bool
+	}{
+		{"project/project_Old_20240205143022.json", old, true},
+		{"project/_raw/project_20240205143022_0001.json", old, true},
+		{"run-20240205143022/project/project_Old_20240205143022.json", old, true},
+		{"project/project_Zoned_20241027023000+0200.json", old, true},
+		{"project/_raw/project_20241027023000+0100_0001.json", old, true},
+		{"project/project_New_20240205143022.json", now, false},
+		{"project/notes.json", old, false},
+		{"user/user_Old_20240205143022.json", old, false},
+	}
+
+	for _, f := range files {
+		path := filepath.Join(dataDir, f.path)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte("{}"), 0o600); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+		if err := os.Chtimes(path, f.modTime, f.modTime); err != nil {
+			t.Fatalf("Failed to set modification time: %v", err)
+		}
+	}
+
+	app := &app{
+		cfg: &config{
+			resource:          "project",
+			dataDir:           dataDir,
+			retentionDuration: 24 * time.Hour,
+		},
+		log: slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+	}
+
+	pruned, err := app.prune(now)
+	if err != nil {
+		t.Fatalf("prune() error = %v", err)
+	}
+	if pruned != 5 {
+		t.Errorf("prune() = %d, want 5", pruned)
+	}
+
+	for _, f := range files {
+		_, err := os.Stat(filepath.Join(dataDir, f.path))
+		if exists := err == nil; exists == f.pruned {
+			t.Errorf("%s exists = %v, want %v", f.path, exists, !f.pruned)
+		}
+	}
+}
+
+func TestAppPruneMissingDataDir(t *testing.T) {
+	app := &app{
+		cfg: &config{
+			resource:          "project",
+			dataDir:           filepath.Join(t.TempDir(), "missing"),
+			retentionDuration: time.Hour,
+		},
+		log: slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+	}
+
+	if _, err := app.prune(time.Now()); err != nil {
+		t.Errorf("prune() error = %v, want nil", err)
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// resourceType describes how an Asana resource type is exported. Supporting
+// a new type only requires an entry in resourceTypes.
+type resourceType struct {
+	path              string   // Path segment of the collection endpoint (e.g. "projects")
+	requiresWorkspace bool     // Listing the type requires the workspace parameter
+	requiresOwner     bool     // Listing the type requires the owner parameter
+	inWorkspace       bool     // The collection is nested in the workspace (/workspaces/{gid}/{path}) instead of taking the workspace parameter
+	parent            string   // Type whose resources contain the type, listed per parent GID (e.g. /projects/{gid}/sections)
+	typeahead         bool     // The type can be searched by name with the workspace typeahead endpoint
+	defaultFields     []string // opt_fields requested when no fields are configured
+}
+
+// resourceTypes is the registry of exportable resource types, keyed by the
+// name accepted by -resource. Every default field set includes name, which
+// export filenames are built from.
+var resourceTypes = map[string]resourceType{
+	"custom_field": {
+		path:              "custom_fields",
+		requiresWorkspace: true,
+		inWorkspace:       true,
+		typeahead:         true,
+		defaultFields:     []string{"name", "resource_subtype", "description", "enum_options", "precision", "created_by"},
+	},
+	"goal": {
+		path:              "goals",
+		requiresWorkspace: true,
+		typeahead:         true,
+		defaultFields:     []string{"name", "owner", "due_on", "status", "notes"},
+	},
+	"portfolio": {
+		path:              "portfolios",
+		requiresWorkspace: true,
+		requiresOwner:     true,
+		typeahead:         true,
+		defaultFields:     []string{"name", "owner", "color", "created_at"},
+	},
+	"project": {
+		path:          "projects",
+		typeahead:     true,
+		defaultFields: []string{"name", "owner", "notes", "archived", "created_at", "modified_at"},
+	},
+	"section": {
+		path:          "sections",
+		parent:        "project",
+		defaultFields: []string{"name", "project", "created_at"},
+	},
+	"tag": {
+		path:              "tags",
+		requiresWorkspace: true,
+		typeahead:         true,
+		defaultFields:     []string{"name", "color", "notes", "created_at"},
+	},
+	"task": {
+		path:          "tasks",
+		typeahead:     true,
+		defaultFields: []string{"name", "completed", "completed_at", "assignee", "due_on", "created_at", "modified_at"},
+	},
+	"team": {
+		path:          "teams",
+		typeahead:     true,
+		defaultFields: []string{"name", "description", "organization"},
+	},
+	"user": {
+		path:          "users",
+		typeahead:     true,
+		defaultFields: []string{"name", "email"},
+	},
+	"workspace": {
+		path:          "workspaces",
+		defaultFields: []string{"name", "is_organization", "email_domains"},
+	},
+}
+
+// lookupResourceType returns the registry entry of the named resource type,
+// or an error listing the supported types when it is not registered.
+func lookupResourceType(name string) (resourceType, error) {
+	rt, ok := resourceTypes[name]
+	if !ok {
+		return resourceType{}, fmt.Errorf("unsupported resource type %q, must be one of: %s", name, strings.Join(resourceTypeNames(), ", "))
+	}
+
+	return rt, nil
+}
+
+// resourceTypeNames returns the names of all registered resource types in
+// alphabetical order.
+func resourceTypeNames() []string {
+	return slices.Sorted(maps.Keys(resourceTypes))
+}
+
+// canonicalResourceType returns the registered name of a resource type given
+// either its name or its path segment, e.g. "task" for "tasks".
+func canonicalResourceType(name string) (string, bool) {
+	if _, ok := resourceTypes[name]; ok {
+		return name, true
+	}
+	for n, rt := range resourceTypes {
+		if rt.path == name {
+			return n, true
+		}
+	}
+
+	return "", false
+}
+
+// parseAliases parses a comma-separated list of alias=type pairs into a map
+// from alias to registered resource type name. Targets may be given by name
+// or path segment; aliases must not shadow a registered type.
+func parseAliases(s string) (map[string]string, error) {
+	aliases := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		alias, target, ok := strings.Cut(pair, "=")
+		alias, target = strings.TrimSpace(alias), strings.TrimSpace(target)
+		if !ok || alias == "" || target == "" {
+			return nil, fmt.Errorf("invalid alias %q, must be alias=type", pair)
+		}
+		if _, registered := canonicalResourceType(alias); registered {
+			return nil, fmt.Errorf("alias %q shadows a resource type", alias)
+		}
+
+		name, registered := canonicalResourceType(target)
+		if !registered {
+			return nil, fmt.Errorf("alias %q: unsupported resource type %q", alias, target)
+		}
+		aliases[alias] = name
+	}
+
+	return aliases, nil
+}
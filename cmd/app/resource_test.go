@@ -0,0 +1,84 @@
+package main
+
+import (
+	"maps"
+	"slices"
+	"testing"
+)
+
+func TestLookupResourceType(t *testing.T) {
+	tests := []struct {
+		name          string
+		resource      string
+		wantPath      string
+		wantWorkspace bool
+		wantErr       bool
+	}{
+		{"project", "project", "projects", false, false},
+		{"task", "task", "tasks", false, false},
+		{"user", "user", "users", false, false},
+		{"goal", "goal", "goals", true, false},
+		{"portfolio", "portfolio", "portfolios", true, false},
+		{"tag", "tag", "tags", true, false},
+		{"custom field", "custom_field", "custom_fields", true, false},
+		{"unsupported", "attachment", "", false, true},
+		{"plural name", "projects", "", false, true},
+		{"empty", "", "", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rt, err := lookupResourceType(tt.resource)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("lookupResourceType() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if rt.path != tt.wantPath {
+				t.Errorf("lookupResourceType() path = %q, want %q", rt.path, tt.wantPath)
+			}
+			if rt.requiresWorkspace != tt.wantWorkspace {
+				t.Errorf("lookupResourceType() requiresWorkspace = %v, want %v", rt.requiresWorkspace, tt.wantWorkspace)
+			}
+		})
+	}
+}
+
+func TestResourceTypesDefaultFields(t *testing.T) {
+	for _, name := range resourceTypeNames() {
+		rt := resourceTypes[name]
+		if rt.path == "" {
+			t.Errorf("resource type %s has no path", name)
+		}
+		if !slices.Contains(rt.defaultFields, "name") {
+			t.Errorf("resource type %s default fields %v do not include name", name, rt.defaultFields)
+		}
+	}
+}
+
+func TestParseAliases(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    map[string]string
+		wantErr bool
+	}{
+		{"empty", "", map[string]string{}, false},
+		{"by path", "todos=tasks", map[string]string{"todos": "task"}, false},
+		{"by name", " todos = task , people=user,", map[string]string{"todos": "task", "people": "user"}, false},
+		{"missing target", "todos=", nil, true},
+		{"missing separator", "todos", nil, true},
+		{"unsupported target", "files=attachments", nil, true},
+		{"shadows type", "projects=task", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseAliases(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseAliases() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("parseAliases() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// scheduleFileName is the name of the interval schedule file stored in each
+// resource directory with -resume-schedule.
+const scheduleFileName = ".schedule.json"
+
+// schedule records the phase of interval exports, so a restarted process
+// keeps ticking at the times the previous one would have.
+type schedule struct {
+	Resource string    `json:"resource"`  // Resource type being exported
+	Interval string    `json:"interval"`  // Interval of the ticks
+	LastTick time.Time `json:"last_tick"` // Scheduled time of the last tick
+}
+
+// schedulePath returns the schedule file path for the configured resource.
+func (a *app) schedulePath() string {
+	return filepath.Join(a.cfg.dataDir, a.cfg.resource, scheduleFileName)
+}
+
+// loadSchedule reads the saved schedule for the configured resource and
+// interval. It returns nil without error if no schedule exists, and ignores
+// a schedule saved for a different resource type or interval.
+func (a *app) loadSchedule(interval time.Duration) (*schedule, error) {
+	data, err := os.ReadFile(a.schedulePath())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read file: %w", err)
+	}
+
+	var s schedule
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("unmarshal schedule: %w", err)
+	}
+
+	if s.Resource != a.cfg.resource || s.Interval != interval.String() || s.LastTick.IsZero() {
+		a.log.Info("ignoring schedule of another configuration", slog.String("path", a.schedulePath()))
+		return nil, nil
+	}
+
+	return &s, nil
+}
+
+// saveSchedule persists tick as the last tick of interval, replacing any
+// previous schedule atomically.
+func (a *app) saveSchedule(interval time.Duration, tick time.Time) error {
+	if err := a.resourceDir(filepath.Dir(a.schedulePath())); err != nil {
+		return fmt.Errorf("resource directory: %w", err)
+	}
+
+	path, err := a.dataPath(a.schedulePath())
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(schedule{Resource: a.cfg.resource, Interval: interval.String(), LastTick: tick.UTC()})
+	if err != nil {
+		return fmt.Errorf("marshal schedule: %w", err)
+	}
+
+	return writeFileAtomic(path, data)
+}
+
+// resumeSchedule returns when the first ticks of a process starting at now
+// are due: whether to run immediately, the time until the next tick in the
+// phase of the saved schedule, and the scheduled time of the last tick.
+// Without a usable schedule, the process runs immediately and ticks every
+// interval from now. A tick missed while no process was running is run
+// immediately, without shifting the phase of the following ticks.
+func (a *app) resumeSchedule(interval time.Duration, now time.Time) (bool, time.Duration, time.Time) {
+	s, err := a.loadSchedule(interval)
+	if err != nil {
+		a.log.Warn("ignoring unreadable schedule", slog.String("error", err.Error()))
+	}
+	if s == nil || s.LastTick.After(now) {
+		return true, interval, now
+	}
+
+	elapsed := now.Sub(s.LastTick)
+	missed := elapsed / interval
+	last := s.LastTick.Add(missed * interval)
+	wait := interval - elapsed%interval
+	a.log.Info("resuming schedule",
+		slog.Time("last_tick", s.LastTick),
+		slog.Int64("missed_ticks", int64(missed)),
+		slog.String("next_tick", wait.String()))
+
+	return missed > 0, wait, last
+}
+
+// recordTick saves tick as the last tick of interval with -resume-schedule.
+// A schedule that cannot be saved is logged, as it only affects restarts.
+func (a *app) recordTick(interval time.Duration, tick time.Time) {
+	if !a.cfg.resumeSchedule {
+		return
+	}
+	if err := a.saveSchedule(interval, tick); err != nil {
+		a.log.Warn("save schedule", slog.String("error", err.Error()))
+	}
+}
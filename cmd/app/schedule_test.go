@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/marintailor/asana-resource-exporter/internal"
+)
+
+func TestAppResumeSchedule(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		lastTick   time.Time
+		interval   string
+		wantRunNow bool
+		wantWait   time.Duration
+		wantLast   time.Time
+	}{
+		{"no schedule", time.Time{}, "", true, time.Minute, now},
+		{"within interval", now.Add(-20 * time.Second), "1m0s", false, 40 * time.Second, now.Add(-20 * time.Second)},
+		{"missed ticks", now.Add(-150 * time.Second), "1m0s", true, 30 * time.Second, now.Add(-30 * time.Second)},
+		{"other interval", now.Add(-20 * time.Second), "5m0s", true, time.Minute, now},
+		{"last tick in the future", now.Add(time.Hour), "1m0s", true, time.Minute, now},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := &app{
+				cfg: &config{resource: "project", dataDir: t.TempDir()},
+				log: slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+			}
+			if tt.interval != "" {
+				interval, _ := time.ParseDuration(tt.interval)
+				if err := app.saveSchedule(interval, tt.lastTick); err != nil {
+					t.Fatalf("saveSchedule() error = %v", err)
+				}
+			}
+
+			runNow, wait, last := app.resumeSchedule(time.Minute, now)
+			if runNow != tt.wantRunNow || wait != tt.wantWait || !last.Equal(tt.wantLast) {
+				t.Errorf("resumeSchedule() = %v, %v, %v, want %v, %v, %v", runNow, wait, last, tt.wantRunNow, tt.wantWait, tt.wantLast)
+			}
+		})
+	}
+}
+
+func TestAppRunWithIntervalResumeSchedule(t *testing.T) {
+	var mu sync.Mutex
+	var requests []time.Time
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, time.Now())
+		mu.Unlock()
+		_, _ = w.Write([]byte(`{"data": [{"gid": "1", "name": "Alpha", "resource_type": "project"}], "next_page": null}`))
+	}))
+	defer server.Close()
+
+	dataDir := t.TempDir()
+	client, _ := internal.NewClient("token", 600)
+	app := &app{
+		cfg: &config{
+			entrypoint:     server.URL,
+			resource:       "project",
+			rate:           600,
+			pageSize:       defaultPageSize,
+			dataDir:        dataDir,
+			emptyName:      defaultEmptyName,
+			outputFormat:   formatJSON,
+			resumeSchedule: true,
+		},
+		log:    slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+		client: client,
+		done:   make(chan struct{}),
+	}
+
+	// The previous process ticked 700ms ago, so the next tick is due in 300ms.
+	start := time.Now()
+	lastTick := start.Add(-700 * time.Millisecond)
+	if err := app.saveSchedule(time.Second, lastTick); err != nil {
+		t.Fatalf("saveSchedule() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 600*time.Millisecond)
+	defer cancel()
+	if err := app.runWithInterval(ctx, time.Second); err != nil {
+		t.Fatalf("runWithInterval() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(requests) != 1 {
+		t.Fatalf("Expected 1 export, got %d", len(requests))
+	}
+	if delay := requests[0].Sub(start); delay < 250*time.Millisecond {
+		t.Errorf("Expected the export at the resumed tick, started after %v", delay)
+	}
+
+	if _, err := os.Stat(filepath.Join(dataDir, "project", scheduleFileName)); err != nil {
+		t.Fatalf("Expected the schedule file: %v", err)
+	}
+	s, err := app.loadSchedule(time.Second)
+	if err != nil || s == nil {
+		t.Fatalf("loadSchedule() = %v, %v", s, err)
+	}
+	if d := s.LastTick.Sub(lastTick); d < 950*time.Millisecond || d > 1100*time.Millisecond {
+		t.Errorf("Expected the last tick one interval after the previous one, got %v later", d)
+	}
+}
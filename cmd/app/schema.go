@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// invalidDirName is the directory, inside a resource directory, that holds
+// resources failing schema validation.
+const invalidDirName = "_invalid"
+
+// schema is a JSON Schema document. Only the subset of keywords needed to
+// describe exported resources is supported: type, enum, required,
+// properties, additionalProperties, items, minLength, maxLength, minimum,
+// and maximum. Other keywords are ignored.
+type schema struct {
+	Type                 schemaType         `json:"type"`
+	Enum                 []any              `json:"enum"`
+	Required             []string           `json:"required"`
+	Properties           map[string]*schema `json:"properties"`
+	AdditionalProperties *bool              `json:"additionalProperties"`
+	Items                *schema            `json:"items"`
+	MinLength            *int               `json:"minLength"`
+	MaxLength            *int               `json:"maxLength"`
+	Minimum              *float64           `json:"minimum"`
+	Maximum              *float64           `json:"maximum"`
+}
+
+// schemaType holds the allowed types of a value, given in a schema either as
+// a single type name or a list of names.
+type schemaType []string
+
+// UnmarshalJSON accepts a single type name or a list of type names.
+func (t *schemaType) UnmarshalJSON(b []byte) error {
+	var one string
+	if err := json.Unmarshal(b, &one); err == nil {
+		*t = schemaType{one}
+		return nil
+	}
+
+	var many []string
+	if err := json.Unmarshal(b, &many); err != nil {
+		return fmt.Errorf("type must be a string or a list of strings: %w", err)
+	}
+	*t = many
+
+	return nil
+}
+
+// loadSchema reads and parses the JSON Schema file at path.
+func loadSchema(path string) (*schema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read file: %w", err)
+	}
+
+	var s schema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("unmarshal schema: %w", err)
+	}
+
+	return &s, nil
+}
+
+// validate checks the JSON document d against the schema and returns all
+// violations joined, or nil if d conforms.
+func (s *schema) validate(d []byte) error {
+	var v any
+	if err := json.Unmarshal(d, &v); err != nil {
+		return fmt.Errorf("decode resource: %w", err)
+	}
+
+	var errs []error
+	s.check("$", v, &errs)
+	return errors.Join(errs...)
+}
+
+// check validates v, located at path, appending violations to errs.
+func (s *schema) check(path string, v any, errs *[]error) {
+	if len(s.Type) > 0 && !s.Type.allows(v) {
+		*errs = append(*errs, fmt.Errorf("%s: expected type %s, got %s", path, strings.Join(s.Type, " or "), jsonType(v)))
+		return
+	}
+
+	if len(s.Enum) > 0 && !containsValue(s.Enum, v) {
+		*errs = append(*errs, fmt.Errorf("%s: value is not one of the allowed values", path))
+	}
+
+	switch v := v.(type) {
+	case map[string]any:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				*errs = append(*errs, fmt.Errorf("%s: missing required property %q", path, name))
+			}
+		}
+
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			prop, ok := s.Properties[name]
+			switch {
+			case ok:
+				prop.check(path+"."+name, v[name], errs)
+			case s.AdditionalProperties != nil && !*s.AdditionalProperties:
+				*errs = append(*errs, fmt.Errorf("%s: unexpected property %q", path, name))
+			}
+		}
+	case []any:
+		if s.Items != nil {
+			for i, item := range v {
+				s.Items.check(fmt.Sprintf("%s[%d]", path, i), item, errs)
+			}
+		}
+	case string:
+		n := utf8.RuneCountInString(v)
+		if s.MinLength != nil && n < *s.MinLength {
+			*errs = append(*errs, fmt.Errorf("%s: length %d is less than %d", path, n, *s.MinLength))
+		}
+		if s.MaxLength != nil && n > *s.MaxLength {
+			*errs = append(*errs, fmt.Errorf("%s: length %d is greater than %d", path, n, *s.MaxLength))
+		}
+	case float64:
+		if s.Minimum != nil && v < *s.Minimum {
+			*errs = append(*errs, fmt.Errorf("%s: %v is less than %v", path, v, *s.Minimum))
+		}
+		if s.Maximum != nil && v > *s.Maximum {
+			*errs = append(*errs, fmt.Errorf("%s: %v is greater than %v", path, v, *s.Maximum))
+		}
+	}
+}
+
+// allows reports whether v has one of the types in t.
+func (t schemaType) allows(v any) bool {
+	for _, name := range t {
+		switch name {
+		case jsonType(v):
+			return true
+		case "number":
+			if _, ok := v.(float64); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// jsonType returns the JSON Schema type name of a decoded JSON value. Whole
+// numbers are reported as integer.
+func jsonType(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+// containsValue reports whether values holds a value deeply equal to v.
+func containsValue(values []any, v any) bool {
+	for _, candidate := range values {
+		if reflect.DeepEqual(candidate, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateResource validates rc against the configured schema. A resource
+// that does not conform is stored under the _invalid directory of rcDir
+// instead of being exported, or fails the export in strict mode. It reports
+// whether the resource is valid.
+func (a *app) validateResource(ctx context.Context, rc Resource, rcDir string) (bool, error) {
+	data, err := rc.MarshalJSON()
+	if err != nil {
+		return false, fmt.Errorf("marshal resource: %w", err)
+	}
+
+	verr := a.cfg.schema.validate(data)
+	if verr == nil {
+		return true, nil
+	}
+
+	if err := a.degrade(fmt.Errorf("resource %s does not match schema: %w", rc.GID, verr)); err != nil {
+		return false, err
+	}
+
+	invalidDir := rcDir + "/" + invalidDirName
+	if err := a.resourceDir(invalidDir); err != nil {
+		return false, fmt.Errorf("invalid directory: %w", err)
+	}
+	if err := a.storeResource(ctx, rc, invalidDir+"/"+a.resourceFilename(a.resourceName(rc), time.Now())); err != nil {
+		return false, fmt.Errorf("store invalid resource: %w", err)
+	}
+
+	return false, nil
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSchema = `{
+	"type": "object",
+	"required": ["gid", "name"],
+	"properties": {
+		"gid": {"type": "string", "minLength": 1},
+		"name": {"type": "string", "maxLength": 10},
+		"archived": {"type": "boolean"},
+		"color": {"type": ["string", "null"], "enum": ["red", "blue", null]},
+		"priority": {"type": "integer", "minimum": 1, "maximum": 5},
+		"followers": {"type": "array", "items": {"type": "object", "required": ["gid"]}}
+	}
+}`
+
+func TestSchemaValidate(t *testing.T) {
+	var s schema
+	if err := json.Unmarshal([]byte(testSchema), &s); err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{
+			name: "valid",
+			data: `{"gid": "1", "name": "Q3", "archived": false, "color": null, "priority": 3, "followers": [{"gid": "2"}]}`,
+		},
+		{
+			name:    "missing required",
+			data:    `{"gid": "1"}`,
+			wantErr: `missing required property "name"`,
+		},
+		{
+			name:    "wrong type",
+			data:    `{"gid": "1", "name": "Q3", "archived": "no"}`,
+			wantErr: "$.archived: expected type boolean, got string",
+		},
+		{
+			name:    "not in enum",
+			data:    `{"gid": "1", "name": "Q3", "color": "green"}`,
+			wantErr: "$.color: value is not one of the allowed values",
+		},
+		{
+			name:    "too long",
+			data:    `{"gid": "1", "name": "a very long name"}`,
+			wantErr: "$.name: length 16 is greater than 10",
+		},
+		{
+			name:    "not an integer",
+			data:    `{"gid": "1", "name": "Q3", "priority": 2.5}`,
+			wantErr: "$.priority: expected type integer, got number",
+		},
+		{
+			name:    "out of range",
+			data:    `{"gid": "1", "name": "Q3", "priority": 9}`,
+			wantErr: "$.priority: 9 is greater than 5",
+		},
+		{
+			name:    "invalid item",
+			data:    `{"gid": "1", "name": "Q3", "followers": [{"name": "x"}]}`,
+			wantErr: `$.followers[0]: missing required property "gid"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.validate([]byte(tt.data))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validate() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAppExportSchema(t *testing.T) {
+	var s schema
+	if err := json.Unmarshal([]byte(testSchema), &s); err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+
+	data := []byte(`{"data": [{"gid": "1", "name": "Valid"}, {"gid": "2", "name": "Invalid", "archived": "no"}]}`)
+
+	tests := []struct {
+		name        string
+		strict      bool
+		wantErr     bool
+		wantFiles   int
+		wantInvalid int
+	}{
+		{
+			name:        "invalid resource quarantined",
+			wantFiles:   1,
+			wantInvalid: 1,
+		},
+		{
+			name:    "invalid resource fails in strict mode",
+			strict:  true,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dataDir := t.TempDir()
+			app := &app{
+				cfg: &config{
+					resource: "project",
+					dataDir:  dataDir,
+					schema:   &s,
+					strict:   tt.strict,
+				},
+				log: slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+			}
+
+			sum := &summary{}
+			err := app.export(context.Background(), data, dataDir, app.newSink(dataDir, time.Now()), sum)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("export() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if sum.valid != 1 || sum.invalid != tt.wantInvalid {
+				t.Errorf("summary valid = %d, invalid = %d, want 1, %d", sum.valid, sum.invalid, tt.wantInvalid)
+			}
+
+			exported, _ := filepath.Glob(filepath.Join(dataDir, "project", "*.json"))
+			if len(exported) != tt.wantFiles {
+				t.Errorf("exported %d files, want %d", len(exported), tt.wantFiles)
+			}
+			invalid, _ := filepath.Glob(filepath.Join(dataDir, "project", invalidDirName, "*.json"))
+			if len(invalid) != tt.wantInvalid {
+				t.Errorf("quarantined %d files, want %d", len(invalid), tt.wantInvalid)
+			}
+		})
+	}
+}
+
+func TestLoadSchema(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "valid.json")
+	if err := os.WriteFile(valid, []byte(testSchema), 0o600); err != nil {
+		t.Fatalf("Failed to write schema: %v", err)
+	}
+	invalid := filepath.Join(dir, "invalid.json")
+	if err := os.WriteFile(invalid, []byte(`{"type": 5}`), 0o600); err != nil {
+		t.Fatalf("Failed to write schema: %v", err)
+	}
+
+	if _, err := loadSchema(valid); err != nil {
+		t.Errorf("loadSchema() error = %v", err)
+	}
+	if _, err := loadSchema(invalid); err == nil {
+		t.Error("loadSchema() error = nil, want error for invalid type")
+	}
+	if _, err := loadSchema(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("loadSchema() error = nil, want error for missing file")
+	}
+}
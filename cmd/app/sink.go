@@ -0,0 +1,389 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+)
+
+// Output formats selectable with -output-format.
+const (
+	formatJSON      = "json"     // One JSON file per resource
+	formatJSONL     = "jsonl"    // One NDJSON file per resource type and run
+	formatJSONLGzip = "jsonl.gz" // formatJSONL compressed with gzip
+)
+
+// Collision policies selectable with -on-collision, applied when the file a
+// resource is written to already holds a different resource.
+const (
+	collisionOverwrite = "overwrite"  // Replace the file
+	collisionGIDSuffix = "gid-suffix" // Append the GID to the new file name
+	collisionError     = "error"      // Fail the export
+)
+
+// collisionPolicies lists the supported collision policies.
+var collisionPolicies = []string{collisionOverwrite, collisionGIDSuffix, collisionError}
+
+// outputFormats lists the supported output formats in the order they are
+// documented, each with the description printed by -list-output-formats.
+// newSink selects the sink of each of them.
+var outputFormats = []struct {
+	name        string
+	description string
+}{
+	{formatJSON, "one JSON file per resource"},
+	{formatJSONL, "one NDJSON file per resource type and run"},
+	{formatJSONLGzip, "NDJSON compressed with gzip"},
+}
+
+// outputFormatNames returns the names of the supported output formats.
+func outputFormatNames() []string {
+	names := make([]string, 0, len(outputFormats))
+	for _, f := range outputFormats {
+		names = append(names, f.name)
+	}
+	return names
+}
+
+// printOutputFormats writes the supported output formats to w, one per line
+// with its description.
+func printOutputFormats(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, f := range outputFormats {
+		if _, err := fmt.Fprintf(tw, "%s\t%s\n", f.name, f.description); err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
+}
+
+// encodeFile returns the content of the json file of rc: its encoding as
+// returned by the API, ending in a newline unless -trailing-newline=false.
+func (a *app) encodeFile(rc Resource) ([]byte, error) {
+	data, err := json.Marshal(rc)
+	if err != nil {
+		return nil, err
+	}
+	if a.cfg.newline {
+		data = append(data, '\n')
+	}
+
+	return data, nil
+}
+
+// encodeLine returns the NDJSON record of rc: its compacted encoding, as raw
+// API resources may be pretty-printed, which would break lines, always
+// terminated by the newline delimiting records.
+func encodeLine(rc Resource) ([]byte, error) {
+	data, err := json.Marshal(rc)
+	if err != nil {
+		return nil, err
+	}
+
+	var line bytes.Buffer
+	if err := json.Compact(&line, data); err != nil {
+		return nil, fmt.Errorf("compact resource: %w", err)
+	}
+	line.WriteByte('\n')
+
+	return line.Bytes(), nil
+}
+
+// sink receives the resources of a single export run.
+type sink interface {
+	// write stores rc and returns the path of the file it was written to.
+	// Cancelling ctx interrupts the wait between retries of a failed write.
+	write(ctx context.Context, rc Resource) (string, error)
+	// close flushes buffered resources and releases open files. Unless keep
+	// is set, as after a run that failed or whose resource count failed the
+	// count checks, the listings of the run, the index and the GIDs file, do
+	// not replace the previous ones.
+	close(keep bool) error
+}
+
+// newSink returns the sink for a run starting at runTime that writes the
+// configured resource type under dir in the configured output format,
+// recording written files in the index and GIDs file if enabled.
+func (a *app) newSink(dir string, runTime time.Time) sink {
+	rcDir := a.exportDir(dir)
+
+	var s sink
+	switch a.cfg.outputFormat {
+	case formatJSONL, formatJSONLGzip:
+		s = &ndjsonSink{
+			a:       a,
+			dir:     rcDir,
+			runTime: runTime,
+			gzip:    a.cfg.outputFormat == formatJSONLGzip,
+			maxSize: a.cfg.maxFileSize,
+			flush:   a.cfg.flushEachLine,
+			digest:  a.cfg.checksums,
+		}
+	default:
+		s = &fileSink{a: a, dir: rcDir, runTime: runTime}
+	}
+
+	if a.cfg.writeIndex {
+		s = &indexSink{sink: s, a: a, dir: rcDir, entries: make(map[string]indexEntry)}
+	}
+	if a.cfg.gidsOut != "" {
+		s = &gidsSink{sink: s, a: a, seen: make(map[string]bool)}
+	}
+
+	return s
+}
+
+// fileSink stores each resource in its own JSON file.
+type fileSink struct {
+	a       *app      // App providing file naming and storage
+	dir     string    // Resource directory files are written to
+	runTime time.Time // Start of the run, the fallback date of partitions
+}
+
+// write stores rc in a new file named after the resource. If a file of that
+// name already holds a different resource, the collision policy decides
+// whether it is overwritten, rc is stored under a name suffixed with its GID,
+// or the write fails. With -dedup-content, the file is a link to content
+// shared with identical files. With -partition-by-modified, the file is
+// written to the date subdirectory of the resource's modified_at date. With
+// -flatten-dir, the name is always suffixed with the GID, so files of all
+// resource types can share a directory without colliding.
+func (s *fileSink) write(ctx context.Context, rc Resource) (string, error) {
+	dir := s.dir
+	if s.a.cfg.partition {
+		var err error
+		if dir, err = s.a.partitionDir(s.dir, rc, s.runTime); err != nil {
+			return "", err
+		}
+	}
+
+	now := time.Now()
+	name := s.a.resourceName(rc)
+	filename := dir + "/" + s.a.resourceFilename(name, now)
+	if s.a.cfg.flattenDir {
+		filename = dir + "/" + s.a.taggedFilename(name, rc.GID, now)
+	}
+
+	switch s.a.cfg.onCollision {
+	case collisionError:
+		if s.collides(filename, rc.GID) {
+			return "", fmt.Errorf("file %s already holds another resource than %s", filename, rc.GID)
+		}
+	case collisionGIDSuffix:
+		if s.collides(filename, rc.GID) {
+			s.a.log.Debug("file name collision, appending gid", slog.String("filename", filename), slog.String("gid", rc.GID))
+			filename = dir + "/" + s.a.taggedFilename(name, rc.GID, now)
+		}
+	}
+
+	store := s.a.storeResource
+	if s.a.cfg.dedupContent {
+		store = s.a.storeContent
+	}
+	if err := store(ctx, rc, filename); err != nil {
+		return "", err
+	}
+
+	return filename, nil
+}
+
+// collides reports whether filename exists and holds a resource other than
+// the one with gid. Files that cannot be read or decoded are treated as
+// holding another resource, so they are never silently replaced.
+func (s *fileSink) collides(filename, gid string) bool {
+	data, err := os.ReadFile(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return false
+	}
+
+	var stored struct {
+		GID string `json:"gid"`
+	}
+	if err != nil || json.Unmarshal(data, &stored) != nil {
+		return true
+	}
+
+	return stored.GID != gid
+}
+
+// close is a no-op, as every file is closed once written.
+func (s *fileSink) close(bool) error {
+	return nil
+}
+
+// ndjsonSink streams resources as newline-delimited JSON into a single file
+// per run, optionally gzip-compressed. With a maximum file size, it rolls
+// over to a new numbered part before a record would exceed the limit. Output
+// is buffered unless flush is set, in which case every record reaches the
+// file as soon as it is written.
+type ndjsonSink struct {
+	a       *app      // App providing path validation and logging
+	dir     string    // Resource directory files are written to
+	runTime time.Time // Start of the run, used in file names
+	gzip    bool      // Compress output with gzip
+	maxSize int64     // Maximum uncompressed bytes per file; 0 disables rollover
+	flush   bool      // Flush after every record for consumers tailing the file
+	digest  bool      // Write a sidecar checksum file of every part
+
+	part int           // Number of the current part, starting at 1
+	size int64         // Uncompressed bytes written to the current part
+	file *os.File      // Current part; nil until the first write
+	gz   *gzip.Writer  // Compressor between buf and file when gzip is set
+	buf  *bufio.Writer // Buffered writer records are written to
+	hash hash.Hash     // SHA-256 of the bytes written to file when digest is set
+}
+
+// write appends rc as a single line, rolling over to a new part first if the
+// line would exceed the maximum file size.
+func (s *ndjsonSink) write(_ context.Context, rc Resource) (string, error) {
+	line, err := encodeLine(rc)
+	if err != nil {
+		s.a.recordError(rc.GID, phaseEncode, err)
+		if err := s.a.degrade(fmt.Errorf("resource %s skipped: %w", rc.GID, err)); err != nil {
+			return "", err
+		}
+		return "", nil
+	}
+
+	if err := s.a.budget.check(); err != nil {
+		return "", err
+	}
+
+	if s.file != nil && s.maxSize > 0 && s.size > 0 && s.size+int64(len(line)) > s.maxSize {
+		if err := s.closePart(); err != nil {
+			return "", err
+		}
+	}
+	if s.file == nil {
+		if err := s.open(); err != nil {
+			return "", err
+		}
+	}
+
+	n, err := s.buf.Write(line)
+	s.size += int64(n)
+	if err != nil {
+		return "", fmt.Errorf("write record: %w", err)
+	}
+	if s.flush {
+		if err := s.flushLine(); err != nil {
+			return "", err
+		}
+	}
+
+	return s.file.Name(), nil
+}
+
+// flushLine pushes buffered records through the compressor, if any, to the
+// file, so readers see complete lines.
+func (s *ndjsonSink) flushLine() error {
+	if err := s.buf.Flush(); err != nil {
+		return fmt.Errorf("flush record: %w", err)
+	}
+	if s.gz != nil {
+		if err := s.gz.Flush(); err != nil {
+			return fmt.Errorf("flush record: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// open creates the next part file.
+func (s *ndjsonSink) open() error {
+	s.part++
+	cleanPath, err := s.a.dataPath(filepath.Join(s.dir, s.filename()))
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(cleanPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("create file: %w", err)
+	}
+
+	var w io.Writer = file
+	if s.a.budget != nil {
+		w = budgetWriter{Writer: w, b: s.a.budget}
+	}
+	if s.digest {
+		s.hash = sha256.New()
+		w = io.MultiWriter(w, s.hash)
+	}
+	if s.gzip {
+		s.gz = gzip.NewWriter(w)
+		w = s.gz
+	}
+	s.file = file
+	s.buf = bufio.NewWriter(w)
+	s.size = 0
+	s.a.log.Debug("ndjson file opened", slog.String("filename", cleanPath))
+
+	return nil
+}
+
+// filename returns the name of the current part:
+// {resource_type}_{timestamp}.jsonl, or {resource_type}_{timestamp}_{part}.jsonl
+// with rollover, with a .gz suffix when compressed.
+func (s *ndjsonSink) filename() string {
+	name := s.a.cfg.resource + "_" + s.a.timestamp(s.runTime)
+	if s.maxSize > 0 {
+		name += fmt.Sprintf("_%04d", s.part)
+	}
+	name += ".jsonl"
+	if s.gzip {
+		name += ".gz"
+	}
+
+	return name
+}
+
+// close closes the current part, if any.
+func (s *ndjsonSink) close(bool) error {
+	return s.closePart()
+}
+
+// closePart flushes and closes the current part, completing its gzip stream,
+// so every part is a valid file on its own, and writes its sidecar checksum
+// file if enabled. It is safe to call without an open part.
+func (s *ndjsonSink) closePart() error {
+	if s.file == nil {
+		return nil
+	}
+
+	err := s.buf.Flush()
+	if s.gz != nil {
+		if cerr := s.gz.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if cerr := s.file.Close(); err == nil {
+		err = cerr
+	}
+	name, sum := s.file.Name(), s.hash
+	s.file, s.gz, s.buf, s.hash = nil, nil, nil, nil
+	if err != nil {
+		return fmt.Errorf("close ndjson file: %w", err)
+	}
+
+	if sum != nil {
+		// The files of the run are completed, also on shutdown.
+		if err := s.a.writeChecksum(context.Background(), name, sum.Sum(nil)); err != nil {
+			return fmt.Errorf("write checksum: %w", err)
+		}
+	}
+
+	return nil
+}
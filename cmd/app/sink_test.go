@@ -0,0 +1,448 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/marintailor/asana-resource-exporter/internal"
+)
+
+// readNDJSON returns the lines of an NDJSON file, decompressing it if its
+// name ends in .gz.
+func readNDJSON(t *testing.T, path string) []string {
+	t.Helper()
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open %s: %v", path, err)
+	}
+	defer file.Close()
+
+	var r io.Reader = file
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			t.Fatalf("Failed to read gzip stream of %s: %v", path, err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("Failed to read %s: %v", path, err)
+	}
+
+	return lines
+}
+
+func TestAppExportNDJSON(t *testing.T) {
+	var page strings.Builder
+	page.WriteString(`{"data": [`)
+	for i := range 10 {
+		if i > 0 {
+			page.WriteString(",")
+		}
+		// Pretty-printed as with opt_pretty, which must not break lines.
+		fmt.Fprintf(&page, "{\n  \"gid\": \"%d\",\n  \"name\": \"Project %d\",\n  \"resource_type\": \"project\"\n}", i, i)
+	}
+	page.WriteString(`]}`)
+
+	tests := []struct {
+		name        string
+		format      string
+		maxFileSize int64
+		wantFiles   []string
+	}{
+		{
+			name:      "jsonl",
+			format:    formatJSONL,
+			wantFiles: []string{"project_20240101120000.jsonl"},
+		},
+		{
+			name:      "jsonl.gz",
+			format:    formatJSONLGzip,
+			wantFiles: []string{"project_20240101120000.jsonl.gz"},
+		},
+		{
+			name:        "jsonl.gz with rollover",
+			format:      formatJSONLGzip,
+			maxFileSize: 200,
+			wantFiles: []string{
+				"project_20240101120000_0001.jsonl.gz",
+				"project_20240101120000_0002.jsonl.gz",
+				"project_20240101120000_0003.jsonl.gz",
+				"project_20240101120000_0004.jsonl.gz",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dataDir := t.TempDir()
+			app := &app{
+				cfg: &config{
+					resource:     "project",
+					dataDir:      dataDir,
+					outputFormat: tt.format,
+					maxFileSize:  tt.maxFileSize,
+				},
+				log: slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+			}
+
+			out := app.newSink(dataDir, time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+			sum := &summary{}
+			if err := app.export(context.Background(), []byte(page.String()), dataDir, out, sum); err != nil {
+				t.Fatalf("export() error = %v", err)
+			}
+			if err := out.close(true); err != nil {
+				t.Fatalf("close() error = %v", err)
+			}
+
+			entries, err := os.ReadDir(filepath.Join(dataDir, "project"))
+			if err != nil {
+				t.Fatalf("Failed to read resource directory: %v", err)
+			}
+			var names []string
+			for _, e := range entries {
+				names = append(names, e.Name())
+			}
+			if strings.Join(names, ",") != strings.Join(tt.wantFiles, ",") {
+				t.Fatalf("files = %v, want %v", names, tt.wantFiles)
+			}
+
+			var gids []string
+			for _, name := range names {
+				info, _ := os.Stat(filepath.Join(dataDir, "project", name))
+				lines := readNDJSON(t, filepath.Join(dataDir, "project", name))
+				var size int64
+				for _, line := range lines {
+					var rc Resource
+					if err := json.Unmarshal([]byte(line), &rc); err != nil {
+						t.Fatalf("line %q of %s is not a resource: %v", line, name, err)
+					}
+					gids = append(gids, rc.GID)
+					size += int64(len(line)) + 1
+				}
+				if tt.maxFileSize > 0 && size > tt.maxFileSize {
+					t.Errorf("%s holds %d uncompressed bytes, want at most %d", name, size, tt.maxFileSize)
+				}
+				if !exportFilePattern("project").MatchString(name) || info.Mode().Perm() != 0o600 {
+					t.Errorf("%s does not match the export file pattern or permissions", name)
+				}
+			}
+			if strings.Join(gids, ",") != "0,1,2,3,4,5,6,7,8,9" || sum.written != 10 {
+				t.Errorf("exported gids = %v, written = %d, want all 10 in order", gids, sum.written)
+			}
+		})
+	}
+}
+
+func TestNDJSONSinkCloseWithoutWrites(t *testing.T) {
+	dataDir := t.TempDir()
+	app := &app{
+		cfg: &config{resource: "project", dataDir: dataDir, outputFormat: formatJSONL},
+		log: slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+	}
+
+	if err := app.newSink(dataDir, time.Now()).close(true); err != nil {
+		t.Fatalf("close() error = %v", err)
+	}
+	if entries, _ := os.ReadDir(dataDir); len(entries) != 0 {
+		t.Errorf("Expected no files for an empty run, got %d", len(entries))
+	}
+}
+
+func TestNDJSONSinkFlushEachLine(t *testing.T) {
+	tests := []struct {
+		name      string
+		flush     bool
+		wantLines int
+	}{
+		{name: "buffered", flush: false, wantLines: 0},
+		{name: "flush each line", flush: true, wantLines: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dataDir := t.TempDir()
+			app := &app{
+				cfg: &config{resource: "project", dataDir: dataDir, outputFormat: formatJSONL, flushEachLine: tt.flush},
+				log: slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+			}
+			if err := os.MkdirAll(filepath.Join(dataDir, "project"), 0750); err != nil {
+				t.Fatalf("Failed to create resource directory: %v", err)
+			}
+
+			out := app.newSink(dataDir, time.Now())
+			defer out.close(true)
+
+			var filename string
+			for i := range 3 {
+				name, err := out.write(context.Background(), Resource{GID: fmt.Sprint(i), Name: fmt.Sprintf("Project %d", i)})
+				if err != nil {
+					t.Fatalf("write() error = %v", err)
+				}
+				filename = name
+			}
+
+			// Read before close, as a consumer tailing the file would.
+			lines := readNDJSON(t, filename)
+			if len(lines) != tt.wantLines {
+				t.Fatalf("Expected %d lines before close, got %d", tt.wantLines, len(lines))
+			}
+			for _, line := range lines {
+				var rc Resource
+				if err := json.Unmarshal([]byte(line), &rc); err != nil {
+					t.Errorf("Incomplete line %q: %v", line, err)
+				}
+			}
+		})
+	}
+}
+
+func TestFileSinkOnCollision(t *testing.T) {
+	tests := []struct {
+		policy    string
+		wantErr   bool
+		wantFiles map[string]string // File name to GID held
+	}{
+		{
+			policy:    collisionOverwrite,
+			wantFiles: map[string]string{"project_Same.json": "2"},
+		},
+		{
+			policy:    collisionGIDSuffix,
+			wantFiles: map[string]string{"project_Same.json": "1", "project_Same_2.json": "2"},
+		},
+		{
+			policy:    collisionError,
+			wantErr:   true,
+			wantFiles: map[string]string{"project_Same.json": "1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			dataDir := t.TempDir()
+			rcDir := filepath.Join(dataDir, "project")
+			if err := os.MkdirAll(rcDir, 0750); err != nil {
+				t.Fatalf("Failed to create resource directory: %v", err)
+			}
+			app := &app{
+				cfg: &config{resource: "project", dataDir: dataDir, outputFormat: formatJSON, noTimestamp: true, onCollision: tt.policy},
+				log: slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+			}
+			out := app.newSink(dataDir, time.Now())
+
+			// Rewriting the same resource, as a later run does, is no collision.
+			for _, gid := range []string{"1", "1"} {
+				if _, err := out.write(context.Background(), Resource{GID: gid, Name: "Same", ResourceType: "project"}); err != nil {
+					t.Fatalf("write() error = %v", err)
+				}
+			}
+			_, err := out.write(context.Background(), Resource{GID: "2", Name: "Same", ResourceType: "project"})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("write() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			entries, err := os.ReadDir(rcDir)
+			if err != nil {
+				t.Fatalf("Failed to read resource directory: %v", err)
+			}
+			if len(entries) != len(tt.wantFiles) {
+				t.Errorf("Expected %d files, got %d", len(tt.wantFiles), len(entries))
+			}
+			for name, gid := range tt.wantFiles {
+				data, err := os.ReadFile(filepath.Join(rcDir, name))
+				if err != nil {
+					t.Errorf("Failed to read %s: %v", name, err)
+					continue
+				}
+				var rc Resource
+				if err := json.Unmarshal(data, &rc); err != nil || rc.GID != gid {
+					t.Errorf("%s holds GID %q, want %q", name, rc.GID, gid)
+				}
+			}
+		})
+	}
+}
+
+func TestAppRunExportTrailingNewline(t *testing.T) {
+	const record = `{"gid":"1","name":"Alpha","resource_type":"project"}`
+
+	tests := []struct {
+		name         string
+		outputFormat string
+		newline      bool
+		want         string
+	}{
+		{"json with newline", formatJSON, true, record + "\n"},
+		{"json without newline", formatJSON, false, record},
+		{"jsonl with newline", formatJSONL, true, record + "\n"},
+		{"jsonl without newline", formatJSONL, false, record + "\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dataDir := t.TempDir()
+			app := &app{
+				cfg: &config{
+					resource:     "project",
+					dataDir:      dataDir,
+					emptyName:    defaultEmptyName,
+					outputFormat: tt.outputFormat,
+					newline:      tt.newline,
+				},
+				log: slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+			}
+
+			out := app.newSink(dataDir, time.Now())
+			page := []byte(`{"data": [{"gid": "1", "name": "Alpha", "resource_type": "project"}], "next_page": null}`)
+			if err := app.export(context.Background(), page, dataDir, out, &summary{}); err != nil {
+				t.Fatalf("export() error = %v", err)
+			}
+			if err := out.close(true); err != nil {
+				t.Fatalf("close() error = %v", err)
+			}
+
+			files, err := filepath.Glob(filepath.Join(dataDir, "project", "project_*"))
+			if err != nil || len(files) != 1 {
+				t.Fatalf("Expected 1 file, got %v (%v)", files, err)
+			}
+			got, err := os.ReadFile(files[0])
+			if err != nil {
+				t.Fatalf("Failed to read %s: %v", files[0], err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("File content = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrintOutputFormats(t *testing.T) {
+	var buf bytes.Buffer
+	if err := printOutputFormats(&buf); err != nil {
+		t.Fatalf("printOutputFormats() error = %v", err)
+	}
+
+	want := "json      one JSON file per resource\n" +
+		"jsonl     one NDJSON file per resource type and run\n" +
+		"jsonl.gz  NDJSON compressed with gzip\n"
+	if got := buf.String(); got != want {
+		t.Errorf("printOutputFormats() = %q, want %q", got, want)
+	}
+}
+
+func TestNewSinkOutputFormats(t *testing.T) {
+	for _, name := range outputFormatNames() {
+		t.Run(name, func(t *testing.T) {
+			app := &app{cfg: &config{resource: "project", outputFormat: name}}
+
+			s := app.newSink(t.TempDir(), time.Now())
+			switch s := s.(type) {
+			case *fileSink:
+				if name != formatJSON {
+					t.Errorf("newSink() = %T for %s", s, name)
+				}
+			case *ndjsonSink:
+				if s.gzip != (name == formatJSONLGzip) {
+					t.Errorf("newSink() gzip = %v for %s", s.gzip, name)
+				}
+			default:
+				t.Errorf("newSink() = %T, no sink for %s", s, name)
+			}
+		})
+	}
+}
+
+func TestAppRunExportFlattenDir(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/projects":
+			_, _ = w.Write([]byte(`{"data": [{"gid": "1", "name": "Shared", "resource_type": "project"}], "next_page": null}`))
+		case "/tags":
+			_, _ = w.Write([]byte(`{"data": [
+				{"gid": "2", "name": "Shared", "resource_type": "tag"},
+				{"gid": "3", "name": "Shared", "resource_type": "tag"}
+			], "next_page": null}`))
+		default:
+			t.Errorf("Unexpected path %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	dataDir := t.TempDir()
+	client, _ := internal.NewClient("token", 600)
+	for _, resource := range []string{"project", "tag"} {
+		app := &app{
+			cfg: &config{
+				entrypoint:   server.URL,
+				resource:     resource,
+				workspace:    "12345",
+				rate:         600,
+				pageSize:     defaultPageSize,
+				dataDir:      dataDir,
+				emptyName:    defaultEmptyName,
+				outputFormat: formatJSON,
+				noTimestamp:  true,
+				onCollision:  collisionError,
+				flattenDir:   true,
+			},
+			log:    slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+			client: client,
+		}
+		if err := app.runExport(context.Background()); err != nil {
+			t.Fatalf("runExport() of %s error = %v", resource, err)
+		}
+	}
+
+	entries, err := os.ReadDir(dataDir)
+	if err != nil {
+		t.Fatalf("Failed to read data directory: %v", err)
+	}
+	var got []string
+	for _, e := range entries {
+		if e.IsDir() {
+			t.Errorf("Expected no subdirectory, got %s", e.Name())
+			continue
+		}
+		got = append(got, e.Name())
+	}
+	want := []string{"project_Shared_1.json", "tag_Shared_2.json", "tag_Shared_3.json"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Files = %v, want %v", got, want)
+	}
+}
+
+func TestFileSinkFlattenDirTraversal(t *testing.T) {
+	dataDir := t.TempDir()
+	app := &app{
+		cfg: &config{resource: "project", dataDir: dataDir, outputFormat: formatJSON, noTimestamp: true, flattenDir: true},
+		log: slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+	}
+	out := app.newSink(dataDir, time.Now())
+
+	if _, err := out.write(context.Background(), Resource{GID: "1", Name: "../../../escape", ResourceType: "project"}); err == nil {
+		t.Error("write() error = nil, want error for a name leaving the data directory")
+	}
+}
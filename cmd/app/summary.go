@@ -0,0 +1,254 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// Summary formats selectable with -summary-format.
+const (
+	summaryText = "text" // Logged only, or key: value lines in the summary file
+	summaryJSON = "json" // The summaryReport schema
+)
+
+// summaryFormats lists the supported summary formats.
+var summaryFormats = []string{summaryText, summaryJSON}
+
+// summaryVersion is the version of the summaryReport schema. It is increased
+// whenever a field is removed or changes meaning; new fields may be added
+// without a version change.
+const summaryVersion = 1
+
+// summary collects the outcome of a single export run.
+type summary struct {
+	resource  string // Resource type exported
+	dir       string // Directory the resources were written to
+	runDir    string // Run directory with output-dir-per-run, empty otherwise
+	pages     int    // Number of pages processed
+	written   int    // Number of resources written
+	filtered  int    // Number of resources dropped by the filter expression
+	unchanged int    // Number of resources skipped as unchanged since a previous run
+	counted   int    // Number of resources counted in count-only mode
+	valid     int    // Number of resources matching the schema
+	invalid   int    // Number of resources quarantined for not matching the schema
+	pruned    int    // Number of expired export files deleted by retention
+	truncated bool   // Whether the export stopped early on max total bytes or max pages
+	suspect   bool   // Whether the resource count failed the count checks
+
+	start time.Time // Start of the run
+	files []string  // Files written, in order of their first write
+
+	typeErrors map[string]string // Errors of resource types skipped without failing the run, by type
+	seenPrev   map[string]string // Seen-set hashes replaced by the run, by GID, restored if it is suspect
+}
+
+// logSummary logs the outcome of an export run at info level.
+// The run directory is included when output-dir-per-run is enabled, the
+// resource count in count-only mode, the unchanged count with dedupe, and
+// validation counts with a schema, the errors of skipped resource types if
+// any, and whether the export was truncated by max total bytes or max pages.
+func (a *app) logSummary(sum *summary) {
+	attrs := []any{
+		slog.String("resource", sum.resource),
+		slog.String("dir", sum.dir),
+		slog.Int("pages", sum.pages),
+		slog.Int("written", sum.written),
+		slog.Int("filtered", sum.filtered),
+		slog.Int("pruned", sum.pruned),
+	}
+	if sum.runDir != "" {
+		attrs = append(attrs, slog.String("run_dir", sum.runDir))
+	}
+	if a.cfg.countOnly {
+		attrs = append(attrs, slog.Int("counted", sum.counted))
+	}
+	if a.seen != nil {
+		attrs = append(attrs, slog.Int("unchanged", sum.unchanged))
+	}
+	if a.cfg.schema != nil {
+		attrs = append(attrs, slog.Int("valid", sum.valid), slog.Int("invalid", sum.invalid))
+	}
+	if len(sum.typeErrors) > 0 {
+		attrs = append(attrs, slog.Any("type_errors", sum.typeErrors))
+	}
+	if sum.truncated {
+		attrs = append(attrs, slog.Bool("truncated", true))
+	}
+
+	a.log.Info("export summary", attrs...)
+}
+
+// addFile records filename as written, unless it is empty, as for skipped
+// resources, or the file written last, as for NDJSON output.
+func (s *summary) addFile(filename string) {
+	if filename == "" || (len(s.files) > 0 && s.files[len(s.files)-1] == filename) {
+		return
+	}
+	s.files = append(s.files, filename)
+}
+
+// summaryReport is the machine-readable summary of a run written with
+// -summary-format=json. Its schema is documented in the README and versioned
+// by summaryVersion.
+type summaryReport struct {
+	Version    int               `json:"version"`               // Schema version
+	Resource   string            `json:"resource"`              // Resource type exported
+	Status     string            `json:"status"`                // ok, error, or canceled
+	StartedAt  time.Time         `json:"started_at"`            // Start of the run in UTC
+	FinishedAt time.Time         `json:"finished_at"`           // End of the run in UTC
+	DurationMS int64             `json:"duration_ms"`           // Duration of the run in milliseconds
+	Dir        string            `json:"dir"`                   // Directory the resources were written to
+	RunDir     string            `json:"run_dir,omitempty"`     // Run directory with output-dir-per-run
+	Counts     summaryCounts     `json:"counts"`                // Resource and file counts
+	Truncated  bool              `json:"truncated"`             // Whether max total bytes or max pages stopped the export
+	Errors     []string          `json:"errors"`                // Errors that failed the run, empty if none
+	TypeErrors map[string]string `json:"type_errors,omitempty"` // Errors of tolerated resource types, by type
+	Files      []string          `json:"files"`                 // Files written, empty if none
+}
+
+// summaryCounts holds the counts of a summaryReport.
+type summaryCounts struct {
+	Pages     int `json:"pages"`     // Pages processed
+	Written   int `json:"written"`   // Resources written
+	Filtered  int `json:"filtered"`  // Resources dropped by the name prefix or filter expression
+	Unchanged int `json:"unchanged"` // Resources skipped as unchanged since a previous run
+	Counted   int `json:"counted"`   // Resources counted in count-only mode
+	Valid     int `json:"valid"`     // Resources matching the schema
+	Invalid   int `json:"invalid"`   // Resources quarantined for not matching the schema
+	Pruned    int `json:"pruned"`    // Expired export files deleted by retention
+	Files     int `json:"files"`     // Files written
+}
+
+// report returns the summaryReport of the run ending at end with err.
+func (s *summary) report(err error, end time.Time) summaryReport {
+	r := summaryReport{
+		Version:    summaryVersion,
+		Resource:   s.resource,
+		Status:     "ok",
+		StartedAt:  s.start.UTC(),
+		FinishedAt: end.UTC(),
+		DurationMS: end.Sub(s.start).Milliseconds(),
+		Dir:        s.dir,
+		RunDir:     s.runDir,
+		Counts: summaryCounts{
+			Pages:     s.pages,
+			Written:   s.written,
+			Filtered:  s.filtered,
+			Unchanged: s.unchanged,
+			Counted:   s.counted,
+			Valid:     s.valid,
+			Invalid:   s.invalid,
+			Pruned:    s.pruned,
+			Files:     len(s.files),
+		},
+		Truncated:  s.truncated,
+		Errors:     []string{},
+		TypeErrors: s.typeErrors,
+		Files:      s.files,
+	}
+	if r.Files == nil {
+		r.Files = []string{}
+	}
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		r.Status = "canceled"
+	case err != nil:
+		r.Status = "error"
+		r.Errors = append(r.Errors, err.Error())
+	}
+
+	return r
+}
+
+// reportSummary writes the summary of a run that ended with err in the
+// configured summary format: to the summary file, replaced atomically, if
+// configured, creating its directory if needed, and to stdout otherwise. Text
+// summaries without a summary file are only logged by logSummary.
+func (a *app) reportSummary(sum *summary, err error) error {
+	if a.cfg.summaryFile == "" && a.cfg.summaryFormat != summaryJSON {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	if err := writeSummary(&buf, a.cfg.summaryFormat, sum.report(err, time.Now())); err != nil {
+		return err
+	}
+
+	if a.cfg.summaryFile == "" {
+		_, err := os.Stdout.Write(buf.Bytes())
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(a.cfg.summaryFile), os.FileMode(permissions)); err != nil {
+		return fmt.Errorf("make dir: %w", err)
+	}
+	if err := writeFileAtomic(a.cfg.summaryFile, buf.Bytes()); err != nil {
+		return err
+	}
+	a.log.Debug("summary stored", slog.String("filename", a.cfg.summaryFile))
+
+	return nil
+}
+
+// writeSummary writes r to w in format: indented JSON, or one key: value
+// line per field, with the errors and files listed one per line.
+func writeSummary(w io.Writer, format string, r summaryReport) error {
+	if format == summaryJSON {
+		data, err := json.MarshalIndent(r, "", "  ")
+		if err != nil {
+			return fmt.Errorf("marshal summary: %w", err)
+		}
+		_, err = fmt.Fprintf(w, "%s\n", data)
+		return err
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "resource: %s\n", r.Resource)
+	fmt.Fprintf(&b, "status: %s\n", r.Status)
+	fmt.Fprintf(&b, "started_at: %s\n", r.StartedAt.Format(time.RFC3339))
+	fmt.Fprintf(&b, "finished_at: %s\n", r.FinishedAt.Format(time.RFC3339))
+	fmt.Fprintf(&b, "duration: %s\n", time.Duration(r.DurationMS)*time.Millisecond)
+	fmt.Fprintf(&b, "dir: %s\n", r.Dir)
+	if r.RunDir != "" {
+		fmt.Fprintf(&b, "run_dir: %s\n", r.RunDir)
+	}
+	for _, c := range []struct {
+		key   string
+		value int
+	}{
+		{"pages", r.Counts.Pages},
+		{"written", r.Counts.Written},
+		{"filtered", r.Counts.Filtered},
+		{"unchanged", r.Counts.Unchanged},
+		{"counted", r.Counts.Counted},
+		{"valid", r.Counts.Valid},
+		{"invalid", r.Counts.Invalid},
+		{"pruned", r.Counts.Pruned},
+		{"files", r.Counts.Files},
+	} {
+		fmt.Fprintf(&b, "%s: %d\n", c.key, c.value)
+	}
+	fmt.Fprintf(&b, "truncated: %t\n", r.Truncated)
+	for _, e := range r.Errors {
+		fmt.Fprintf(&b, "error: %s\n", e)
+	}
+	for _, t := range slices.Sorted(maps.Keys(r.TypeErrors)) {
+		fmt.Fprintf(&b, "type_error: %s: %s\n", t, r.TypeErrors[t])
+	}
+	for _, f := range r.Files {
+		fmt.Fprintf(&b, "file: %s\n", f)
+	}
+
+	_, err := w.Write(b.Bytes())
+	return err
+}
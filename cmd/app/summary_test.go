@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/marintailor/asana-resource-exporter/internal"
+)
+
+func TestAppRunExportSummaryFile(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		wantStatus string
+		wantFiles  int
+		wantErrors int
+	}{
+		{"successful export", http.StatusOK, "ok", 2, 0},
+		{"failed export", http.StatusInternalServerError, "error", 0, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(`{"data": [
+					{"gid": "1", "name": "Alpha", "resource_type": "project"},
+					{"gid": "2", "name": "Beta", "resource_type": "project"}
+				], "next_page": null}`))
+			}))
+			defer server.Close()
+
+			dataDir := t.TempDir()
+			summaryFile := filepath.Join(dataDir, "out", "summary.json")
+			client, _ := internal.NewClient("token", 600)
+			app := &app{
+				cfg: &config{
+					entrypoint:    server.URL,
+					resource:      "project",
+					rate:          600,
+					pageSize:      defaultPageSize,
+					dataDir:       dataDir,
+					emptyName:     defaultEmptyName,
+					outputFormat:  formatJSON,
+					summaryFormat: summaryJSON,
+					summaryFile:   summaryFile,
+				},
+				log:    slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+				client: client,
+			}
+
+			err := app.runExport(context.Background())
+			if (err != nil) != (tt.wantErrors > 0) {
+				t.Fatalf("runExport() error = %v", err)
+			}
+
+			data, err := os.ReadFile(summaryFile)
+			if err != nil {
+				t.Fatalf("Failed to read summary: %v", err)
+			}
+			var report summaryReport
+			if err := json.Unmarshal(data, &report); err != nil {
+				t.Fatalf("Failed to unmarshal summary: %v", err)
+			}
+
+			if report.Version != summaryVersion || report.Resource != "project" || report.Status != tt.wantStatus {
+				t.Errorf("Unexpected summary: %+v", report)
+			}
+			if report.Counts.Written != tt.wantFiles || report.Counts.Files != tt.wantFiles || len(report.Files) != tt.wantFiles {
+				t.Errorf("Expected %d files, got counts %+v and files %v", tt.wantFiles, report.Counts, report.Files)
+			}
+			if len(report.Errors) != tt.wantErrors {
+				t.Errorf("Expected %d errors, got %v", tt.wantErrors, report.Errors)
+			}
+			if report.StartedAt.IsZero() || report.FinishedAt.Before(report.StartedAt) {
+				t.Errorf("Unexpected run times: %v to %v", report.StartedAt, report.FinishedAt)
+			}
+		})
+	}
+}
+
+func TestWriteSummaryText(t *testing.T) {
+	start := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	sum := &summary{resource: "project", dir: "data/project", start: start, pages: 1, written: 2, typeErrors: map[string]string{"project": "forbidden"}}
+	sum.addFile("data/project/a.json")
+	sum.addFile("data/project/a.json")
+	sum.addFile("data/project/b.json")
+
+	var b strings.Builder
+	if err := writeSummary(&b, summaryText, sum.report(nil, start.Add(1500*time.Millisecond))); err != nil {
+		t.Fatalf("writeSummary() error = %v", err)
+	}
+
+	want := `resource: project
+status: ok
+started_at: 2024-06-01T12:00:00Z
+finished_at: 2024-06-01T12:00:01Z
+duration: 1.5s
+dir: data/project
+pages: 1
+written: 2
+filtered: 0
+unchanged: 0
+counted: 0
+valid: 0
+invalid: 0
+pruned: 0
+files: 2
+truncated: false
+type_error: project: forbidden
+file: data/project/a.json
+file: data/project/b.json
+`
+	if b.String() != want {
+		t.Errorf("writeSummary() = %q, want %q", b.String(), want)
+	}
+}
+
+func TestNewConfigSummaryFormat(t *testing.T) {
+	tests := []struct {
+		format  string
+		want    string
+		wantErr bool
+	}{
+		{"", summaryText, false},
+		{summaryJSON, summaryJSON, false},
+		{"yaml", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			cfg, err := newConfig(options{cfg: config{
+				entrypoint:    defaultEntrypoint,
+				resource:      "project",
+				workspace:     "12345",
+				rate:          60,
+				pageSize:      defaultPageSize,
+				summaryFormat: tt.format,
+			}})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && cfg.summaryFormat != tt.want {
+				t.Errorf("summaryFormat = %q, want %q", cfg.summaryFormat, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// defaultTimezone is the time zone of timestamps and active windows unless
+// -timezone is set, so output does not depend on the machine it runs on.
+const defaultTimezone = "UTC"
+
+// timestampLayout is the layout of the timestamps in file and directory names.
+const timestampLayout = "20060102150405"
+
+// zonedTimestampLayout is timestampLayout with the UTC offset appended, used
+// for time zones other than UTC, so the wall clock times repeated when
+// daylight saving time ends still give distinct names.
+const zonedTimestampLayout = timestampLayout + "-0700"
+
+// timestampPattern matches the timestamps formatted by timestamp, with or
+// without the UTC offset, in file name patterns.
+const timestampPattern = `\d{14}([+-]\d{4})?`
+
+// loadTimezone loads the IANA time zone name, such as "Europe/Berlin".
+func loadTimezone(name string) (*time.Location, error) {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("load time zone: %w", err)
+	}
+
+	return loc, nil
+}
+
+// location returns the time zone configured with -timezone, UTC if none was
+// loaded.
+func (a *app) location() *time.Location {
+	if a.cfg.location == nil {
+		return time.UTC
+	}
+	return a.cfg.location
+}
+
+// timestamp formats t in the configured time zone for use in file and
+// directory names, with the UTC offset unless the time zone is UTC.
+func (a *app) timestamp(t time.Time) string {
+	loc := a.location()
+	if loc == time.UTC {
+		return t.UTC().Format(timestampLayout)
+	}
+	return t.In(loc).Format(zonedTimestampLayout)
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAppTimestamp(t *testing.T) {
+	tests := []struct {
+		name     string
+		timezone string
+		t        time.Time
+		want     string
+	}{
+		{"default", "", time.Date(2024, 3, 10, 6, 59, 59, 0, time.UTC), "20240310065959"},
+		{"before spring forward", "America/New_York", time.Date(2024, 3, 10, 6, 59, 59, 0, time.UTC), "20240310015959-0500"},
+		{"after spring forward", "America/New_York", time.Date(2024, 3, 10, 7, 0, 0, 0, time.UTC), "20240310030000-0400"},
+		{"before fall back", "Europe/Berlin", time.Date(2024, 10, 27, 0, 30, 0, 0, time.UTC), "20241027023000+0200"},
+		{"after fall back", "Europe/Berlin", time.Date(2024, 10, 27, 1, 30, 0, 0, time.UTC), "20241027023000+0100"},
+		{"input zone ignored", "UTC", time.Date(2024, 6, 1, 12, 0, 0, 0, time.FixedZone("UTC+2", 2*60*60)), "20240601100000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config{resource: "project"}
+			if tt.timezone != "" {
+				loc, err := loadTimezone(tt.timezone)
+				if err != nil {
+					t.Fatalf("loadTimezone() error = %v", err)
+				}
+				cfg.location = loc
+			}
+			app := &app{cfg: cfg}
+
+			if got := app.timestamp(tt.t); got != tt.want {
+				t.Errorf("timestamp() = %s, want %s", got, tt.want)
+			}
+			if got, want := app.resourceFilename("Alpha", tt.t), "project_Alpha_"+tt.want+".json"; got != want {
+				t.Errorf("resourceFilename() = %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestAppTimestampFallBackDistinct(t *testing.T) {
+	loc, err := loadTimezone("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("loadTimezone() error = %v", err)
+	}
+	app := &app{cfg: &config{resource: "project", location: loc}}
+
+	// 02:30 occurs twice in Berlin on the night daylight saving time ends.
+	before := app.timestamp(time.Date(2024, 10, 27, 0, 30, 0, 0, time.UTC))
+	after := app.timestamp(time.Date(2024, 10, 27, 1, 30, 0, 0, time.UTC))
+	if before == after {
+		t.Errorf("Expected distinct timestamps in the repeated hour, got %s twice", before)
+	}
+}
+
+func TestNewConfigTimezone(t *testing.T) {
+	tests := []struct {
+		name         string
+		timezone     string
+		windowTZ     string
+		wantErr      bool
+		wantTimezone string
+		inside       time.Time // Inside 09:00-17:00 in the window's time zone
+		outside      time.Time // Outside 09:00-17:00 in the window's time zone
+	}{
+		{
+			name:         "default",
+			wantTimezone: "UTC",
+			inside:       time.Date(2024, 3, 11, 9, 0, 0, 0, time.UTC),
+			outside:      time.Date(2024, 3, 11, 17, 0, 0, 0, time.UTC),
+		},
+		{
+			name:         "window in timezone across DST",
+			timezone:     "America/New_York",
+			wantTimezone: "America/New_York",
+			inside:       time.Date(2024, 3, 11, 13, 30, 0, 0, time.UTC), // 09:30 EDT
+			outside:      time.Date(2024, 3, 8, 13, 30, 0, 0, time.UTC),  // 08:30 EST
+		},
+		{
+			name:         "active window time zone takes precedence",
+			timezone:     "America/New_York",
+			windowTZ:     "Europe/Berlin",
+			wantTimezone: "America/New_York",
+			inside:       time.Date(2024, 10, 28, 8, 0, 0, 0, time.UTC),  // 09:00 CET
+			outside:      time.Date(2024, 10, 25, 15, 0, 0, 0, time.UTC), // 17:00 CEST
+		},
+		{name: "unknown timezone", timezone: "Mars/Olympus", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := newConfig(options{cfg: config{
+				entrypoint:     defaultEntrypoint,
+				resource:       "project",
+				workspace:      "12345",
+				rate:           60,
+				pageSize:       defaultPageSize,
+				activeWindow:   "09:00-17:00",
+				activeWindowTZ: tt.windowTZ,
+				timezone:       tt.timezone,
+			}})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if cfg.timezone != tt.wantTimezone || cfg.location.String() != tt.wantTimezone {
+				t.Errorf("timezone = %s (%s), want %s", cfg.timezone, cfg.location, tt.wantTimezone)
+			}
+			if !cfg.window.contains(tt.inside) {
+				t.Errorf("contains(%s) = false, want true", tt.inside)
+			}
+			if cfg.window.contains(tt.outside) {
+				t.Errorf("contains(%s) = true, want false", tt.outside)
+			}
+		})
+	}
+}
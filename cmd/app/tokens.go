@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// loadTokens returns the API tokens requests are authenticated with: the
+// lines of tokenFile if set, otherwise the comma-separated ASANA_API_TOKENS,
+// otherwise ASANA_API_TOKEN. Blank lines, lines starting with # and duplicate
+// tokens are skipped. It returns no tokens if none are configured.
+func loadTokens(tokenFile string) ([]string, error) {
+	var raw []string
+	switch {
+	case tokenFile != "":
+		data, err := os.ReadFile(tokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("read token file: %w", err)
+		}
+		raw = strings.Split(string(data), "\n")
+	case os.Getenv("ASANA_API_TOKENS") != "":
+		raw = strings.Split(os.Getenv("ASANA_API_TOKENS"), ",")
+	default:
+		if token, ok := os.LookupEnv("ASANA_API_TOKEN"); ok {
+			return []string{token}, nil
+		}
+		return nil, nil
+	}
+
+	var tokens []string
+	for _, t := range raw {
+		t = strings.TrimSpace(t)
+		if t == "" || strings.HasPrefix(t, "#") || slices.Contains(tokens, t) {
+			continue
+		}
+		tokens = append(tokens, t)
+	}
+
+	return tokens, nil
+}
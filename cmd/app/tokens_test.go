@@ -0,0 +1,67 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/marintailor/asana-resource-exporter/internal"
+)
+
+func TestLoadTokens(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "tokens")
+	if err := os.WriteFile(file, []byte("# service accounts\nfile-a\n\nfile-b\nfile-a\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write token file: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		tokenFile string
+		tokens    string
+		token     string
+		want      []string
+		wantErr   bool
+	}{
+		{"token file", file, "env-a,env-b", "single", []string{"file-a", "file-b"}, false},
+		{"tokens variable", "", " env-a, env-b,,", "single", []string{"env-a", "env-b"}, false},
+		{"token variable", "", "", "single", []string{"single"}, false},
+		{"no tokens", "", "", "", nil, false},
+		{"missing token file", filepath.Join(t.TempDir(), "missing"), "", "", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("ASANA_API_TOKENS", tt.tokens)
+			t.Setenv("ASANA_API_TOKEN", tt.token)
+			if tt.token == "" {
+				_ = os.Unsetenv("ASANA_API_TOKEN")
+			}
+
+			got, err := loadTokens(tt.tokenFile)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("loadTokens() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("loadTokens() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewAppTokenPool(t *testing.T) {
+	t.Setenv("ASANA_API_TOKENS", "token-a,token-b")
+
+	app, err := newApp([]string{"app", "-resource", "project", "-data-dir", t.TempDir()})
+	if err != nil {
+		t.Fatalf("newApp() error = %v", err)
+	}
+
+	pool, ok := app.client.(*internal.Pool)
+	if !ok {
+		t.Fatalf("newApp() client = %T, want *internal.Pool", app.client)
+	}
+	if pool.Size() != 2 {
+		t.Errorf("pool size = %d, want 2", pool.Size())
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+)
+
+// validationCheck is the outcome of one pre-flight check run by -validate-only.
+type validationCheck struct {
+	Name    string `json:"name"`              // Check name
+	OK      bool   `json:"ok"`                // Whether the check passed
+	Skipped bool   `json:"skipped,omitempty"` // Whether the check was not run
+	Detail  string `json:"detail,omitempty"`  // Failure or skip reason
+}
+
+// validationReport is the structured report printed by -validate-only.
+type validationReport struct {
+	OK     bool              `json:"ok"`     // Whether every check that ran passed
+	Checks []validationCheck `json:"checks"` // Outcome of each check, in run order
+}
+
+// add records the outcome of the named check.
+func (r *validationReport) add(name string, err error) {
+	c := validationCheck{Name: name, OK: err == nil}
+	if err != nil {
+		c.Detail = err.Error()
+		r.OK = false
+	}
+	r.Checks = append(r.Checks, c)
+}
+
+// skip records that the named check was not run and why.
+func (r *validationReport) skip(name, reason string) {
+	r.Checks = append(r.Checks, validationCheck{Name: name, Skipped: true, Detail: reason})
+}
+
+// runValidate validates the configuration in opts without exporting anything
+// and writes a JSON report of every check to w. Connectivity and the token
+// are only probed against the API when -probe is set as well. It reports
+// whether all checks passed.
+func runValidate(ctx context.Context, opts options, w io.Writer) (bool, error) {
+	report := validationReport{OK: true}
+
+	cfg, err := newConfig(opts)
+	report.add("config", err)
+
+	log, err := newLogger(opts)
+	report.add("logging", err)
+
+	report.add("entrypoint", validateEntrypoint(opts.cfg.entrypoint))
+	if opts.cfg.fallbackEntrypoint != "" {
+		report.add("fallback_entrypoint", validateEntrypoint(opts.cfg.fallbackEntrypoint))
+	}
+
+	dataDir, err := expandEnv(opts.cfg.dataDir)
+	if err == nil {
+		err = checkDataDir(dataDir)
+	}
+	report.add("data_dir", err)
+
+	tokens, err := loadTokens(opts.cfg.tokenFile)
+	if err == nil && len(tokens) == 0 && opts.cfg.replay == "" && !opts.cfg.noFetch {
+		err = errors.New("token not present")
+	}
+	report.add("token", err)
+
+	switch {
+	case !opts.probe:
+		report.skip("connectivity", "not requested, set -probe to check it")
+	case !report.OK:
+		report.skip("connectivity", "configuration is invalid")
+	default:
+		report.add("connectivity", probeConnectivity(ctx, cfg, tokens, log))
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return false, fmt.Errorf("marshal report: %w", err)
+	}
+	if _, err := fmt.Fprintf(w, "%s\n", data); err != nil {
+		return false, err
+	}
+
+	return report.OK, nil
+}
+
+// validateEntrypoint checks that the entrypoint, after environment variable
+// expansion, is an absolute http or https URL.
+func validateEntrypoint(entrypoint string) error {
+	expanded, err := expandEnv(entrypoint)
+	if err != nil {
+		return err
+	}
+
+	u, err := url.Parse(expanded)
+	if err != nil {
+		return fmt.Errorf("parse %q: %w", expanded, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("%q: scheme must be http or https", expanded)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("%q: host not provided", expanded)
+	}
+
+	return nil
+}
+
+// probeConnectivity sends the request of -probe with a client built from cfg
+// and discards the output, returning why it failed if it did.
+func probeConnectivity(ctx context.Context, cfg *config, tokens []string, log *slog.Logger) error {
+	clientOpts, err := clientOptions(cfg, log)
+	if err != nil {
+		return fmt.Errorf("client options: %w", err)
+	}
+
+	client, err := newAPIClient(tokens, cfg.rate, log, clientOpts)
+	if err != nil {
+		return fmt.Errorf("new client: %w", err)
+	}
+	defer client.CloseIdleConnections()
+
+	a := &app{cfg: cfg, log: log, client: client, gov: newGovernor(cfg.maxGoroutines, log)}
+	return a.runProbe(ctx, io.Discard)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestRunValidate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data": {"gid": "42", "name": "Jane Smith", "email": "jane@example.com"}}`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name   string
+		args   []string
+		token  string
+		wantOK bool
+		want   map[string]string // Expected outcome per check: ok, failed, or skipped
+	}{
+		{
+			name:   "valid",
+			args:   []string{"-resource", "project"},
+			token:  "token",
+			wantOK: true,
+			want:   map[string]string{"config": "ok", "entrypoint": "ok", "data_dir": "ok", "token": "ok", "connectivity": "skipped"},
+		},
+		{
+			name:   "valid with probe",
+			args:   []string{"-resource", "project", "-entrypoint", server.URL, "-probe"},
+			token:  "token",
+			wantOK: true,
+			want:   map[string]string{"token": "ok", "connectivity": "ok"},
+		},
+		{
+			name: "invalid",
+			args: []string{"-resource", "project", "-entrypoint", "ftp://example.com", "-page-size", "0", "-probe"},
+			want: map[string]string{"config": "failed", "entrypoint": "failed", "data_dir": "ok", "token": "failed", "connectivity": "skipped"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("ASANA_API_TOKENS", "")
+			t.Setenv("ASANA_API_TOKEN", tt.token)
+			if tt.token == "" {
+				_ = os.Unsetenv("ASANA_API_TOKEN")
+			}
+			args := append([]string{"app", "-data-dir", t.TempDir()}, tt.args...)
+			opts, err := newOptions(args)
+			if err != nil {
+				t.Fatalf("newOptions() error = %v", err)
+			}
+
+			var buf bytes.Buffer
+			ok, err := runValidate(context.Background(), opts, &buf)
+			if err != nil {
+				t.Fatalf("runValidate() error = %v", err)
+			}
+			if ok != tt.wantOK {
+				t.Errorf("runValidate() = %v, want %v\n%s", ok, tt.wantOK, buf.String())
+			}
+
+			var report validationReport
+			if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+				t.Fatalf("Failed to unmarshal report: %v", err)
+			}
+			if report.OK != tt.wantOK {
+				t.Errorf("Report ok = %v, want %v", report.OK, tt.wantOK)
+			}
+
+			got := make(map[string]string)
+			for _, c := range report.Checks {
+				switch {
+				case c.Skipped:
+					got[c.Name] = "skipped"
+				case c.OK:
+					got[c.Name] = "ok"
+				default:
+					got[c.Name] = "failed"
+					if c.Detail == "" {
+						t.Errorf("Check %s failed without detail", c.Name)
+					}
+				}
+			}
+			for name, want := range tt.want {
+				if got[name] != want {
+					t.Errorf("Check %s = %q, want %q", name, got[name], want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Rate limit response headers, in order of preference: the common X- prefixed
+// form and the IETF draft form.
+var (
+	rateLimitHeaders     = []string{"X-RateLimit-Limit", "RateLimit-Limit"}
+	rateRemainingHeaders = []string{"X-RateLimit-Remaining", "RateLimit-Remaining"}
+)
+
+// warmup makes a single cheap request for the authenticated user before the
+// export and, if the response advertises the rate limit of the account,
+// configures the client with it instead of the configured rate, so the first
+// burst of requests does not trip the API limits. Failures are logged and
+// the configured rate is kept.
+func (a *app) warmup(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, warmupTimeout)
+	defer cancel()
+
+	resp, err := a.client.Request(ctx, a.cfg.entrypoint+"/users/me?opt_fields=gid", nil)
+	if err != nil {
+		a.log.Warn("warmup request failed, keeping configured rate",
+			slog.String("error", a.client.Redact(err.Error())),
+			slog.Int("rate", a.cfg.rate))
+		return
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			a.log.Error("close request body", slog.String("error", err.Error()))
+		}
+	}()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	limit, ok := rateLimit(resp.Header, rateLimitHeaders)
+	if !ok || limit == 0 {
+		a.log.Info("no rate limit advertised, keeping configured rate",
+			slog.Int("status", resp.StatusCode),
+			slog.Int("rate", a.cfg.rate))
+		return
+	}
+
+	attrs := []any{
+		slog.Int("limit", limit),
+		slog.Int("configured_rate", a.cfg.rate),
+	}
+	if remaining, ok := rateLimit(resp.Header, rateRemainingHeaders); ok {
+		attrs = append(attrs, slog.Int("remaining", remaining))
+	}
+	a.log.Info("rate limit detected", attrs...)
+
+	a.client.SetRate(limit)
+}
+
+// rateLimit returns the value of the first of names present in h, converted
+// to requests per minute. Values may carry a window in seconds as in
+// "1500;w=60"; values without one are taken to be per minute, as Asana's
+// limits are.
+func rateLimit(h http.Header, names []string) (int, bool) {
+	for _, name := range names {
+		v := h.Get(name)
+		if v == "" {
+			continue
+		}
+
+		// Only the first of several comma-separated policies applies.
+		v, _, _ = strings.Cut(v, ",")
+		params := strings.Split(v, ";")
+		n, err := strconv.Atoi(strings.TrimSpace(params[0]))
+		if err != nil || n < 0 {
+			return 0, false
+		}
+
+		window := 60
+		for _, p := range params[1:] {
+			if w, ok := strings.CutPrefix(strings.TrimSpace(p), "w="); ok {
+				if window, err = strconv.Atoi(w); err != nil || window <= 0 {
+					return 0, false
+				}
+			}
+		}
+
+		return n * 60 / window, true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/marintailor/asana-resource-exporter/internal"
+)
+
+func TestRateLimit(t *testing.T) {
+	tests := []struct {
+		name   string
+		header http.Header
+		want   int
+		wantOK bool
+	}{
+		{"missing", http.Header{}, 0, false},
+		{"per minute", http.Header{"X-Ratelimit-Limit": {"1500"}}, 1500, true},
+		{"draft form", http.Header{"Ratelimit-Limit": {"150"}}, 150, true},
+		{"window in seconds", http.Header{"X-Ratelimit-Limit": {"50;w=10"}}, 300, true},
+		{"several policies", http.Header{"Ratelimit-Limit": {"100, 100;w=60, 1000;w=3600"}}, 100, true},
+		{"prefixed preferred", http.Header{"X-Ratelimit-Limit": {"1500"}, "Ratelimit-Limit": {"150"}}, 1500, true},
+		{"invalid", http.Header{"X-Ratelimit-Limit": {"many"}}, 0, false},
+		{"invalid window", http.Header{"X-Ratelimit-Limit": {"50;w=0"}}, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := rateLimit(tt.header, rateLimitHeaders)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("rateLimit() = %d, %v, want %d, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestAppWarmup(t *testing.T) {
+	tests := []struct {
+		name    string
+		limit   string
+		wantLog string
+	}{
+		{"limit advertised", "1500", `"msg":"rate limit detected","limit":1500,"configured_rate":600,"remaining":1499`},
+		{"no limit advertised", "", `"msg":"no rate limit advertised, keeping configured rate"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/users/me" {
+					t.Errorf("Expected warmup request for /users/me, got %s", r.URL.Path)
+				}
+				if tt.limit != "" {
+					w.Header().Set("X-RateLimit-Limit", tt.limit)
+					w.Header().Set("X-RateLimit-Remaining", "1499")
+				}
+				_, _ = w.Write([]byte(`{"data": {"gid": "1"}}`))
+			}))
+			defer server.Close()
+
+			var logs strings.Builder
+			client, _ := internal.NewClient("token", 600)
+			app := &app{
+				cfg:    &config{entrypoint: server.URL, rate: 600},
+				log:    slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{})),
+				client: client,
+			}
+
+			app.warmup(context.Background())
+
+			if !strings.Contains(logs.String(), tt.wantLog) {
+				t.Errorf("Expected log %s, got %s", tt.wantLog, logs.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// activeWindow is a daily time-of-day window during which interval exports
+// are performed. A window whose end is before its start crosses midnight.
+type activeWindow struct {
+	start time.Duration  // Offset of the window start from midnight
+	end   time.Duration  // Offset of the window end from midnight
+	loc   *time.Location // Time zone the window is evaluated in
+}
+
+// parseWindow parses a window in the form "HH:MM-HH:MM" evaluated in the
+// time zone tz, which is an IANA name such as "Europe/Berlin". An empty tz
+// selects the local time zone. Start and end must differ.
+func parseWindow(s, tz string) (*activeWindow, error) {
+	from, to, ok := strings.Cut(s, "-")
+	if !ok {
+		return nil, fmt.Errorf("window %q must have the form HH:MM-HH:MM", s)
+	}
+
+	start, err := parseClock(from)
+	if err != nil {
+		return nil, err
+	}
+	end, err := parseClock(to)
+	if err != nil {
+		return nil, err
+	}
+	if start == end {
+		return nil, errors.New("window start and end must differ")
+	}
+
+	loc := time.Local
+	if tz != "" {
+		if loc, err = loadTimezone(tz); err != nil {
+			return nil, err
+		}
+	}
+
+	return &activeWindow{start: start, end: end, loc: loc}, nil
+}
+
+// parseClock parses a time of day in the form "HH:MM" into its offset from
+// midnight.
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("parse time of day %q: %w", s, err)
+	}
+
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// contains reports whether t falls inside the window. The start is inclusive
+// and the end exclusive.
+func (w *activeWindow) contains(t time.Time) bool {
+	t = t.In(w.loc)
+	now := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+
+	if w.start < w.end {
+		return now >= w.start && now < w.end
+	}
+	return now >= w.start || now < w.end
+}
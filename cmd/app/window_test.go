@@ -0,0 +1,67 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseWindow(t *testing.T) {
+	tests := []struct {
+		name    string
+		window  string
+		tz      string
+		wantErr bool
+	}{
+		{"same day", "09:00-17:00", "", false},
+		{"crossing midnight", "22:00-06:00", "UTC", false},
+		{"named time zone", "22:00-06:00", "America/New_York", false},
+		{"missing separator", "22:00", "", true},
+		{"invalid time", "25:00-06:00", "", true},
+		{"empty window", "06:00-06:00", "", true},
+		{"unknown time zone", "22:00-06:00", "Mars/Olympus", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseWindow(tt.window, tt.tz)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseWindow() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestActiveWindowContains(t *testing.T) {
+	at := func(hour, minute int) time.Time {
+		return time.Date(2024, 6, 1, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name   string
+		window string
+		tz     string
+		t      time.Time
+		want   bool
+	}{
+		{"inside same day", "09:00-17:00", "UTC", at(12, 0), true},
+		{"at start", "09:00-17:00", "UTC", at(9, 0), true},
+		{"at end", "09:00-17:00", "UTC", at(17, 0), false},
+		{"before same day", "09:00-17:00", "UTC", at(8, 59), false},
+		{"late evening across midnight", "22:00-06:00", "UTC", at(23, 30), true},
+		{"early morning across midnight", "22:00-06:00", "UTC", at(5, 59), true},
+		{"midday across midnight", "22:00-06:00", "UTC", at(12, 0), false},
+		{"time zone applied", "22:00-06:00", "Asia/Tokyo", at(14, 0), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, err := parseWindow(tt.window, tt.tz)
+			if err != nil {
+				t.Fatalf("parseWindow() error = %v", err)
+			}
+			if got := w.contains(tt.t); got != tt.want {
+				t.Errorf("contains(%s) = %v, want %v", tt.t.Format(time.Kitchen), got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"path/filepath"
+	"strconv"
+)
+
+// runWorkspaces runs the export of runExport once for every workspace the
+// token has access to, with auto-workspaces. The workspaces are listed first,
+// then exported one after another, each as if configured with -workspace and
+// with the data directory {data-dir}/{workspace_gid}, so every piece of state
+// kept in the data directory, such as checkpoints, is kept per workspace. As
+// the workspaces share the client, the rate limit applies across all of them.
+// A failed workspace does not stop the others; the errors of all failed
+// workspaces are returned together.
+func (a *app) runWorkspaces(ctx context.Context) error {
+	gids, err := a.listGIDs(ctx, resourceTypes["workspace"].path, url.Values{})
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			return nil
+		}
+		a.log.Error("list workspaces", slog.String("error", err.Error()))
+		return fmt.Errorf("list workspaces: %w", err)
+	}
+	a.log.Info("exporting workspaces", slog.Int("workspaces", len(gids)))
+
+	if len(gids) == 0 {
+		return a.degrade(errors.New("no workspaces accessible"))
+	}
+
+	cfg, log := a.cfg, a.log
+	defer func() { a.cfg, a.log = cfg, log }()
+
+	var errs []error
+	for _, gid := range gids {
+		if ctx.Err() != nil {
+			break
+		}
+
+		wsCfg := *cfg
+		wsCfg.workspace = gid
+		wsCfg.dataDir = filepath.Join(cfg.dataDir, gid)
+		a.cfg, a.log = &wsCfg, log.With(slog.String("workspace", gid))
+
+		if err := a.runExport(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("workspace %s: %w", gid, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// listGIDs returns the GIDs of all resources of the collection at path,
+// listed with query, following pagination.
+func (a *app) listGIDs(ctx context.Context, path string, query url.Values) ([]string, error) {
+	query.Set("limit", strconv.Itoa(maxPageSize))
+	query.Set("opt_fields", "gid")
+
+	var gids []string
+	for {
+		data, err := a.fetchPage(ctx, a.entrypoint()+"/"+path+"?"+query.Encode())
+		if err != nil {
+			return nil, err
+		}
+		resources, err := a.resources(data)
+		if err != nil {
+			return nil, err
+		}
+		for _, rc := range resources {
+			gids = append(gids, rc.GID)
+		}
+
+		next, err := a.nextPage(data)
+		if err != nil {
+			return nil, err
+		}
+		if next == nil || next.Offset == "" {
+			return gids, nil
+		}
+		query.Set("offset", next.Offset)
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/marintailor/asana-resource-exporter/internal"
+)
+
+func TestAppRunExportAutoWorkspaces(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/workspaces":
+			if r.URL.Query().Get("offset") == "" {
+				_, _ = w.Write([]byte(`{"data": [{"gid": "111"}, {"gid": "222"}], "next_page": {"offset": "w2"}}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"data": [{"gid": "333"}], "next_page": null}`))
+		case "/projects":
+			switch r.URL.Query().Get("workspace") {
+			case "111":
+				_, _ = w.Write([]byte(`{"data": [{"gid": "1", "name": "Alpha", "resource_type": "project"}], "next_page": null}`))
+			case "222":
+				w.WriteHeader(http.StatusNotFound)
+			case "333":
+				_, _ = w.Write([]byte(`{"data": [
+					{"gid": "2", "name": "Beta", "resource_type": "project"},
+					{"gid": "3", "name": "Gamma", "resource_type": "project"}
+				], "next_page": null}`))
+			default:
+				t.Errorf("Unexpected workspace %q", r.URL.Query().Get("workspace"))
+				w.WriteHeader(http.StatusBadRequest)
+			}
+		default:
+			t.Errorf("Unexpected path %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	dataDir := t.TempDir()
+	client, _ := internal.NewClient("token", 600)
+	cfg := &config{
+		entrypoint:     server.URL,
+		resource:       "project",
+		autoWorkspaces: true,
+		rate:           600,
+		pageSize:       defaultPageSize,
+		dataDir:        dataDir,
+		emptyName:      defaultEmptyName,
+		outputFormat:   formatJSON,
+	}
+	app := &app{
+		cfg:    cfg,
+		log:    slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+		client: client,
+	}
+
+	// The failed workspace does not stop the others.
+	err := app.runExport(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "workspace 222") {
+		t.Fatalf("runExport() error = %v, want error of workspace 222", err)
+	}
+	if app.cfg != cfg {
+		t.Error("Expected the configuration to be restored")
+	}
+
+	for gid, want := range map[string]int{"111": 1, "333": 2} {
+		files, err := os.ReadDir(filepath.Join(dataDir, gid, "project"))
+		if err != nil {
+			t.Fatalf("Failed to read resource directory of workspace %s: %v", gid, err)
+		}
+		if len(files) != want {
+			t.Errorf("Expected %d files in workspace %s, got %d", want, gid, len(files))
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dataDir, "project")); !os.IsNotExist(err) {
+		t.Errorf("Expected no resource directory outside the workspaces, got %v", err)
+	}
+}
+
+func TestAppRunExportAutoWorkspacesNone(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data": [], "next_page": null}`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		strict  bool
+		wantErr bool
+	}{
+		{"tolerated", false, false},
+		{"strict", true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _ := internal.NewClient("token", 600)
+			app := &app{
+				cfg: &config{
+					entrypoint:     server.URL,
+					resource:       "project",
+					autoWorkspaces: true,
+					rate:           600,
+					dataDir:        t.TempDir(),
+					strict:         tt.strict,
+				},
+				log:    slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{})),
+				client: client,
+			}
+
+			if err := app.runExport(context.Background()); (err != nil) != tt.wantErr {
+				t.Errorf("runExport() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -5,28 +5,233 @@ package internal
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"net"
 	"net/http"
+	"net/http/httptrace"
 	"net/url"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"golang.org/x/time/rate"
 )
 
+// DefaultMaxRedirects is the number of redirects followed per request unless
+// configured with WithMaxRedirects. It matches the net/http default.
+const DefaultMaxRedirects = 10
+
+// DefaultAccept is the Accept header sent with every request unless
+// configured with WithAccept.
+const DefaultAccept = "application/json"
+
 var (
-	ErrInvalidEndpoint = errors.New("invalid endpoint")
-	ErrReachedLimit    = errors.New("reached limit")
+	ErrInvalidEndpoint  = errors.New("invalid endpoint")
+	ErrReachedLimit     = errors.New("reached limit")
+	ErrTooManyRedirects = errors.New("too many redirects")
 )
 
+// APIError is returned when the API responds with a non-2xx status code.
+type APIError struct {
+	StatusCode int    // HTTP status code of the response
+	Endpoint   string // Requested endpoint
+	Body       string // Optional response body, included in the message when set
+}
+
+// Error implements the error interface.
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("unexpected status %d %s from %s", e.StatusCode, http.StatusText(e.StatusCode), e.Endpoint)
+	if e.Body != "" {
+		msg += ": " + e.Body
+	}
+	return msg
+}
+
 // Client wraps http.Client to provide Asana API authentication and rate limiting.
 // It ensures requests respect API rate limits and provides clean shutdown functionality.
 type Client struct {
 	*http.Client               // Embedded HTTP client for making HTTP requests
 	token        string        // Asana personal access token for authentication
-	limiter      *rate.Limiter // Rate limiter to control API request frequency
+	limiter      *rate.Limiter // Token bucket controlling API request frequency by default
+	rateLimiter  Limiter       // Limiter requests wait for; limiter unless set with WithLimiter
+	signer       Signer        // Optional request signer applied after authentication
+	maxRedirects int           // Maximum number of redirects followed per request
+	accept       string        // Accept header sent with every request
+	log          *slog.Logger  // Logger for per-request diagnostics
 	shutdown     chan struct{} // Channel for coordinating graceful shutdown
+
+	middlewares []Middleware      // Custom middlewares configured with WithMiddleware
+	chain       http.RoundTripper // Request chain built from the middlewares in NewClient
+
+	rewriteURL RequestURLRewriter // Optional rewriter of request URLs applied before validation
+	throttle   *latencyThrottle   // Optional lowering of the rate on high latency; nil disables it
+
+	transport *http.Transport // Network transport, possibly wrapped by recorder or replayer
+	dialer    *net.Dialer     // Dialer used by transport
+	conns     atomic.Int64    // Number of connections obtained for requests
+	reused    atomic.Int64    // Number of those connections that were reused
+}
+
+// Option configures optional Client behavior in NewClient.
+type Option func(*Client) error
+
+// RequestURLRewriter rewrites the URL of an outgoing request, for example to
+// inject a region into the path or add static query parameters for an
+// Asana-compatible endpoint. An error fails the request.
+type RequestURLRewriter func(url string) (string, error)
+
+// WithRequestURLRewriter sets a RequestURLRewriter applied to the URL of every
+// request before it is validated, so the rewritten URL must be a valid
+// endpoint. Without one, URLs are sent as given.
+func WithRequestURLRewriter(r RequestURLRewriter) Option {
+	return func(c *Client) error {
+		if r == nil {
+			return errors.New("url rewriter must not be nil")
+		}
+		c.rewriteURL = r
+		return nil
+	}
+}
+
+// WithSigner sets a Signer that is applied to every request after the
+// authentication headers are set.
+func WithSigner(s Signer) Option {
+	return func(c *Client) error {
+		c.signer = s
+		return nil
+	}
+}
+
+// WithMaxRedirects sets the maximum number of redirects followed per request.
+// Zero disables following redirects, so any redirect fails the request.
+func WithMaxRedirects(n int) Option {
+	return func(c *Client) error {
+		if n < 0 {
+			return errors.New("max redirects must not be negative")
+		}
+		c.maxRedirects = n
+		return nil
+	}
+}
+
+// WithAccept sets the Accept header sent with every request, overriding
+// DefaultAccept.
+func WithAccept(accept string) Option {
+	return func(c *Client) error {
+		if accept == "" {
+			return errors.New("accept header must not be empty")
+		}
+		c.accept = accept
+		return nil
+	}
+}
+
+// WithKeepAlive tunes connection reuse: idle connections are closed after
+// idleTimeout, and up to maxIdlePerHost idle connections are kept per host.
+// Zero values keep the net/http defaults.
+func WithKeepAlive(idleTimeout time.Duration, maxIdlePerHost int) Option {
+	return func(c *Client) error {
+		if idleTimeout < 0 || maxIdlePerHost < 0 {
+			return errors.New("keep-alive settings must not be negative")
+		}
+		if idleTimeout > 0 {
+			c.transport.IdleConnTimeout = idleTimeout
+		}
+		if maxIdlePerHost > 0 {
+			c.transport.MaxIdleConnsPerHost = maxIdlePerHost
+		}
+		return nil
+	}
+}
+
+// WithoutHTTP2 restricts the client to HTTP/1.1 instead of negotiating
+// HTTP/2 over TLS, for proxies and middleboxes that mishandle HTTP/2.
+func WithoutHTTP2() Option {
+	return func(c *Client) error {
+		c.transport.ForceAttemptHTTP2 = false
+		c.transport.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+		return nil
+	}
+}
+
+// WithDNSCache caches DNS lookups in process for ttl, so new connections to
+// the same host do not resolve it again until the entry expires.
+func WithDNSCache(ttl time.Duration) Option {
+	return func(c *Client) error {
+		if ttl <= 0 {
+			return errors.New("dns cache ttl must be positive")
+		}
+		c.transport.DialContext = newDNSCache(ttl, c.dialer).dialContext
+		return nil
+	}
+}
+
+// WithUnixSocket dials every connection to the Unix domain socket at path,
+// such as that of a local authenticating proxy, instead of the endpoint host,
+// which then only serves as a placeholder. Endpoints should use the http
+// scheme unless the proxy terminates TLS itself.
+func WithUnixSocket(path string) Option {
+	return func(c *Client) error {
+		if path == "" {
+			return errors.New("unix socket path must not be empty")
+		}
+		c.transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return c.dialer.DialContext(ctx, "unix", path)
+		}
+		return nil
+	}
+}
+
+// WithClientCertificate presents the X.509 key pair loaded from the PEM
+// encoded certFile and keyFile in TLS handshakes, for gateways requiring
+// mutual TLS. It returns an error if the pair cannot be loaded.
+func WithClientCertificate(certFile, keyFile string) Option {
+	return func(c *Client) error {
+		if certFile == "" || keyFile == "" {
+			return errors.New("client certificate and key files must not be empty")
+		}
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return fmt.Errorf("load client certificate: %w", err)
+		}
+		if c.transport.TLSClientConfig == nil {
+			c.transport.TLSClientConfig = &tls.Config{}
+		}
+		c.transport.TLSClientConfig.Certificates = []tls.Certificate{cert}
+		return nil
+	}
+}
+
+// ConnStats returns the number of connections obtained for requests so far
+// and how many of them were reused from the idle pool.
+func (c *Client) ConnStats() (conns, reused int64) {
+	return c.conns.Load(), c.reused.Load()
+}
+
+// SetRate changes the rate limit to r requests per minute, for example once
+// the actual limit of the account is known. Requests already waiting for the
+// limiter are affected as well. A limiter set with WithLimiter is only
+// changed if it implements RateSetter. While WithLatencyThrottle has lowered
+// the rate, r becomes the rate restored once latency recovers.
+func (c *Client) SetRate(r int) {
+	if c.rateLimiter != Limiter(c.limiter) {
+		if s, ok := c.rateLimiter.(RateSetter); ok {
+			s.SetRate(r)
+		}
+		return
+	}
+	if c.throttle != nil {
+		c.throttle.setRate(c.limiter, rate.Limit(r/60), r)
+		return
+	}
+
+	c.limiter.SetBurst(r)
+	c.limiter.SetLimit(rate.Limit(r / 60))
 }
 
 // CloseIdleConnections closes any idle connections held by the underlying HTTP client.
@@ -35,16 +240,50 @@ func (c *Client) CloseIdleConnections() {
 	c.Client.CloseIdleConnections()
 }
 
+// WithLogger sets the logger used for per-request diagnostics such as latency.
+func WithLogger(l *slog.Logger) Option {
+	return func(c *Client) error {
+		c.log = l
+		return nil
+	}
+}
+
 // NewClient creates a new Client with the specified API token and rate limit.
-// The rate parameter defines the maximum number of requests allowed per minute.
-// It returns an error if initialization fails.
-func NewClient(t string, r int) (*Client, error) {
-	return &Client{
-		Client:   &http.Client{},
-		token:    t,
-		limiter:  rate.NewLimiter(rate.Limit(r/60), r),
-		shutdown: make(chan struct{}),
-	}, nil
+// The rate parameter defines the maximum number of requests allowed per minute,
+// enforced by a token bucket unless WithLimiter replaces it.
+// Options are applied in order. It returns an error if initialization fails.
+func NewClient(t string, r int, opts ...Option) (*Client, error) {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+
+	c := &Client{
+		Client:       &http.Client{Transport: transport},
+		token:        t,
+		limiter:      rate.NewLimiter(rate.Limit(r/60), r),
+		maxRedirects: DefaultMaxRedirects,
+		accept:       DefaultAccept,
+		log:          slog.New(slog.DiscardHandler),
+		shutdown:     make(chan struct{}),
+		transport:    transport,
+		dialer:       dialer,
+	}
+	c.rateLimiter = c.limiter
+	c.CheckRedirect = c.checkRedirect
+
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			return nil, err
+		}
+	}
+	if c.throttle != nil && c.rateLimiter != Limiter(c.limiter) {
+		return nil, errors.New("latency throttle cannot be combined with a custom limiter")
+	}
+
+	middlewares := append([]Middleware{c.rateLimit, c.authenticate}, c.middlewares...)
+	c.chain = chain(RoundTripperFunc(c.do), append(middlewares, c.sign)...)
+
+	return c, nil
 }
 
 // Request performs an authenticated HTTP GET request to the specified Asana endpoint.
@@ -52,6 +291,27 @@ func NewClient(t string, r int) (*Client, error) {
 // The request respects context cancellation and returns the raw HTTP response.
 // If the request fails or is cancelled, returns an error.
 func (c *Client) Request(ctx context.Context, url string, body io.Reader) (*http.Response, error) {
+	return c.send(ctx, http.MethodGet, url, body)
+}
+
+// PostJSON performs an authenticated HTTP POST request with a JSON body to the
+// specified Asana endpoint. It behaves like Request otherwise.
+func (c *Client) PostJSON(ctx context.Context, url string, body io.Reader) (*http.Response, error) {
+	return c.send(ctx, http.MethodPost, url, body)
+}
+
+// send performs a request with the given method through the request chain:
+// rate limiting, authentication, custom middlewares, and signing. The URL is
+// rewritten first if a RequestURLRewriter is set.
+func (c *Client) send(ctx context.Context, method, url string, body io.Reader) (*http.Response, error) {
+	if c.rewriteURL != nil {
+		rewritten, err := c.rewriteURL(url)
+		if err != nil {
+			return nil, fmt.Errorf("rewrite url: %w", err)
+		}
+		url = rewritten
+	}
+
 	if !validEndpoint(url) {
 		return nil, ErrInvalidEndpoint
 	}
@@ -60,17 +320,31 @@ func (c *Client) Request(ctx context.Context, url string, body io.Reader) (*http
 		return nil, err
 	}
 
-	if err := c.limiter.Wait(ctx); err != nil {
-		return nil, fmt.Errorf("rate limit wait: %w", err)
-	}
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, body)
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
 	if err != nil {
 		return nil, fmt.Errorf("new request: %w", err)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+c.token)
+	return c.chain.RoundTrip(req)
+}
+
+// do sends req as the last step of the request chain, recording connection
+// reuse and logging the latency of the request once its body is consumed.
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	var reused bool
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			reused = info.Reused
+			c.conns.Add(1)
+			if info.Reused {
+				c.reused.Add(1)
+			}
+		},
+	}))
 
+	start := time.Now()
 	resp, err := c.Do(req)
 	if err != nil {
 		if ctx.Err() != nil {
@@ -81,10 +355,92 @@ func (c *Client) Request(ctx context.Context, url string, body io.Reader) (*http
 		}
 		return nil, fmt.Errorf("do request: %w", err)
 	}
+	if c.throttle != nil {
+		c.observeLatency(time.Since(start))
+	}
+
+	endpoint := c.redact(req.URL.String())
+	status := resp.StatusCode
+	proto := resp.Proto
+	resp.Body = &timedBody{
+		ReadCloser: resp.Body,
+		start:      start,
+		done: func(n int64, d time.Duration) {
+			c.log.Debug("api request",
+				slog.String("endpoint", endpoint),
+				slog.Int("status", status),
+				slog.String("proto", proto),
+				slog.Bool("reused", reused),
+				slog.Int64("bytes", n),
+				slog.String("duration", d.String()))
+		},
+	}
 
 	return resp, nil
 }
 
+// checkRedirect logs each redirect at debug level and stops following
+// redirects once the configured maximum is exceeded.
+func (c *Client) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) > c.maxRedirects {
+		return fmt.Errorf("redirect to %s not followed: %w (max %d)", c.redact(req.URL.String()), ErrTooManyRedirects, c.maxRedirects)
+	}
+
+	c.log.Debug("api redirect",
+		slog.String("from", c.redact(via[len(via)-1].URL.String())),
+		slog.String("to", c.redact(req.URL.String())),
+		slog.Int("redirects", len(via)))
+
+	return nil
+}
+
+// Redact removes the API token from s so it can be safely logged or
+// included in errors.
+func (c *Client) Redact(s string) string {
+	return c.redact(s)
+}
+
+// redact removes the API token from s so it can be safely logged.
+func (c *Client) redact(s string) string {
+	if c.token == "" {
+		return s
+	}
+	return strings.ReplaceAll(s, c.token, "[REDACTED]")
+}
+
+// timedBody wraps a response body to measure the full round-trip of a request,
+// including reading the body. It reports the byte count and elapsed time once,
+// when the body reaches EOF or is closed, whichever happens first.
+type timedBody struct {
+	io.ReadCloser
+	start time.Time                      // Time the request was sent
+	n     int64                          // Bytes read so far
+	once  sync.Once                      // Ensures done is reported once
+	done  func(n int64, d time.Duration) // Receives byte count and duration
+}
+
+// Read reads from the underlying body, reporting completion at EOF.
+func (b *timedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	if err == io.EOF {
+		b.finish()
+	}
+	return n, err
+}
+
+// Close closes the underlying body, reporting completion if not yet reported.
+func (b *timedBody) Close() error {
+	b.finish()
+	return b.ReadCloser.Close()
+}
+
+func (b *timedBody) finish() {
+	b.once.Do(func() {
+		b.done(b.n, time.Since(b.start))
+	})
+}
+
 // validEndpoint validates if the given string is a valid API endpoint URL.
 // It enforces URL format rules including:
 // - Proper URL structure and non-empty scheme/host
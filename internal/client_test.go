@@ -1,10 +1,26 @@
 package internal
 
 import (
+	"bytes"
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
 	"io"
+	"log/slog"
+	"math/big"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -183,6 +199,22 @@ func TestClient_CloseIdleConnections(t *testing.T) {
 	client.CloseIdleConnections()
 }
 
+func TestClient_SetRate(t *testing.T) {
+	client, err := NewClient("test-token", 60)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	client.SetRate(1500)
+
+	if got := client.limiter.Limit(); got != 25 {
+		t.Errorf("Expected limit of 25 requests per second, got %v", got)
+	}
+	if got := client.limiter.Burst(); got != 1500 {
+		t.Errorf("Expected burst of 1500, got %d", got)
+	}
+}
+
 func TestClient_RequestRateLimit(t *testing.T) {
 	client, err := NewClient("test-token", 2) // 2 requests per minute
 	if err != nil {
@@ -270,3 +302,458 @@ func TestClient_RequestInvalidEndpoint(t *testing.T) {
 		})
 	}
 }
+
+func TestClient_RequestLatencyLogging(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("0123456789"))
+	}))
+	defer server.Close()
+
+	buf := new(bytes.Buffer)
+	logger := slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	client, err := NewClient("secret-token", 60, WithLogger(logger))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	resp, err := client.Request(context.Background(), server.URL+"/projects?token=secret-token", nil)
+	if err != nil {
+		t.Fatalf("Request() error = %v", err)
+	}
+
+	if buf.Len() != 0 {
+		t.Error("Expected latency to be logged only after the body is consumed")
+	}
+
+	if _, err := io.ReadAll(resp.Body); err != nil {
+		t.Fatalf("Failed to read body: %v", err)
+	}
+	_ = resp.Body.Close()
+
+	var entry struct {
+		Msg      string `json:"msg"`
+		Endpoint string `json:"endpoint"`
+		Status   int    `json:"status"`
+		Bytes    int64  `json:"bytes"`
+		Duration string `json:"duration"`
+	}
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	if len(lines) != 1 {
+		t.Fatalf("Expected 1 log entry, got %d", len(lines))
+	}
+	if err := json.Unmarshal(lines[0], &entry); err != nil {
+		t.Fatalf("Failed to decode log entry: %v", err)
+	}
+
+	if entry.Msg != "api request" {
+		t.Errorf("log msg = %v, want %v", entry.Msg, "api request")
+	}
+	if strings.Contains(entry.Endpoint, "secret-token") {
+		t.Errorf("log endpoint %q contains the token", entry.Endpoint)
+	}
+	if entry.Status != http.StatusOK {
+		t.Errorf("log status = %v, want %v", entry.Status, http.StatusOK)
+	}
+	if entry.Bytes != 10 {
+		t.Errorf("log bytes = %v, want %v", entry.Bytes, 10)
+	}
+	if _, err := time.ParseDuration(entry.Duration); err != nil {
+		t.Errorf("log duration %q not parseable: %v", entry.Duration, err)
+	}
+}
+
+func TestClient_RequestRedirects(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/old", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/login", http.StatusFound)
+	})
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	tests := []struct {
+		name         string
+		maxRedirects int
+		wantErr      bool
+		wantLogs     int
+	}{
+		{
+			name:         "redirect followed and logged",
+			maxRedirects: DefaultMaxRedirects,
+			wantErr:      false,
+			wantLogs:     1,
+		},
+		{
+			name:         "redirects disabled",
+			maxRedirects: 0,
+			wantErr:      true,
+			wantLogs:     0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			logger := slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+			client, err := NewClient("token", 60, WithLogger(logger), WithMaxRedirects(tt.maxRedirects))
+			if err != nil {
+				t.Fatalf("Failed to create client: %v", err)
+			}
+
+			resp, err := client.Request(context.Background(), server.URL+"/old", nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Request() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if !errors.Is(err, ErrTooManyRedirects) {
+					t.Errorf("Request() error = %v, want %v", err, ErrTooManyRedirects)
+				}
+			} else {
+				_ = resp.Body.Close()
+			}
+
+			if got := strings.Count(buf.String(), `"msg":"api redirect"`); got != tt.wantLogs {
+				t.Errorf("logged %d redirects, want %d", got, tt.wantLogs)
+			}
+		})
+	}
+}
+
+func TestWithMaxRedirectsNegative(t *testing.T) {
+	if _, err := NewClient("token", 60, WithMaxRedirects(-1)); err == nil {
+		t.Error("NewClient() error = nil, want error for negative max redirects")
+	}
+}
+
+func TestClient_ConnStats(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client, err := NewClient("token", 600, WithKeepAlive(time.Minute, 4))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	for range 3 {
+		resp, err := client.Request(context.Background(), server.URL+"/projects", nil)
+		if err != nil {
+			t.Fatalf("Request() error = %v", err)
+		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}
+
+	conns, reused := client.ConnStats()
+	if conns != 3 || reused != 2 {
+		t.Errorf("ConnStats() = %d, %d, want 3, 2", conns, reused)
+	}
+}
+
+func TestClient_RequestUnixSocket(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "proxy.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("Failed to listen on unix socket: %v", err)
+	}
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/projects" {
+			t.Errorf("Expected path /projects, got %s", r.URL.Path)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	server.Listener = listener
+	server.Start()
+	defer server.Close()
+
+	client, err := NewClient("token", 600, WithUnixSocket(socket))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	resp, err := client.Request(context.Background(), "http://asana-proxy/projects", nil)
+	if err != nil {
+		t.Fatalf("Request() error = %v", err)
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+}
+
+func TestWithUnixSocketEmptyPath(t *testing.T) {
+	if _, err := NewClient("token", 60, WithUnixSocket("")); err == nil {
+		t.Error("NewClient() error = nil, want error for empty path")
+	}
+}
+
+func TestClient_RequestAccept(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{"default", nil, DefaultAccept},
+		{"override", []Option{WithAccept("application/vnd.example+json")}, "application/vnd.example+json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if got := r.Header.Get("Accept"); got != tt.want {
+					t.Errorf("Accept = %q, want %q", got, tt.want)
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			client, err := NewClient("token", 600, tt.opts...)
+			if err != nil {
+				t.Fatalf("Failed to create client: %v", err)
+			}
+
+			resp, err := client.Request(context.Background(), server.URL, nil)
+			if err != nil {
+				t.Fatalf("Request() error = %v", err)
+			}
+			_ = resp.Body.Close()
+		})
+	}
+}
+
+func TestWithAcceptEmpty(t *testing.T) {
+	if _, err := NewClient("token", 60, WithAccept("")); err == nil {
+		t.Error("NewClient() error = nil, want error for empty accept header")
+	}
+}
+
+func TestClient_RequestHTTP2(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{"negotiated", nil, "HTTP/2.0"},
+		{"disabled", []Option{WithoutHTTP2()}, "HTTP/1.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+			server.EnableHTTP2 = true
+			server.StartTLS()
+			defer server.Close()
+
+			client, err := NewClient("token", 600, tt.opts...)
+			if err != nil {
+				t.Fatalf("Failed to create client: %v", err)
+			}
+			client.transport.TLSClientConfig = server.Client().Transport.(*http.Transport).TLSClientConfig
+
+			resp, err := client.Request(context.Background(), server.URL, nil)
+			if err != nil {
+				t.Fatalf("Request() error = %v", err)
+			}
+			_ = resp.Body.Close()
+
+			if resp.Proto != tt.want {
+				t.Errorf("Expected protocol %s, got %s", tt.want, resp.Proto)
+			}
+		})
+	}
+}
+
+// writeClientCertificate writes a self-signed client certificate and its key
+// as PEM files to dir and returns their paths and the parsed certificate.
+func writeClientCertificate(t *testing.T, dir string) (string, string, *x509.Certificate) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "exporter"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("Failed to parse certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("Failed to marshal key: %v", err)
+	}
+
+	certFile := filepath.Join(dir, "client.crt")
+	keyFile := filepath.Join(dir, "client.key")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatalf("Failed to write certificate: %v", err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatalf("Failed to write key: %v", err)
+	}
+
+	return certFile, keyFile, cert
+}
+
+func TestWithClientCertificate(t *testing.T) {
+	certFile, keyFile, cert := writeClientCertificate(t, t.TempDir())
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) == 0 || r.TLS.PeerCertificates[0].Subject.CommonName != "exporter" {
+			t.Errorf("Expected the client certificate, got %v", r.TLS.PeerCertificates)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(cert)
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	server.StartTLS()
+	defer server.Close()
+
+	rootCAs := x509.NewCertPool()
+	rootCAs.AddCert(server.Certificate())
+
+	tests := []struct {
+		name    string
+		opts    []Option
+		wantErr bool
+	}{
+		{"with certificate", []Option{WithClientCertificate(certFile, keyFile)}, false},
+		{"without certificate", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewClient("token", 600, tt.opts...)
+			if err != nil {
+				t.Fatalf("Failed to create client: %v", err)
+			}
+			if client.transport.TLSClientConfig == nil {
+				client.transport.TLSClientConfig = &tls.Config{}
+			}
+			client.transport.TLSClientConfig.RootCAs = rootCAs
+
+			resp, err := client.Request(context.Background(), server.URL, nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Request() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil {
+				_ = resp.Body.Close()
+				if resp.StatusCode != http.StatusOK {
+					t.Errorf("Expected status %d, got %d", http.StatusOK, resp.StatusCode)
+				}
+			}
+		})
+	}
+}
+
+func TestWithClientCertificateInvalid(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile, _ := writeClientCertificate(t, dir)
+	otherDir := t.TempDir()
+	_, otherKey, _ := writeClientCertificate(t, otherDir)
+
+	tests := []struct {
+		name     string
+		certFile string
+		keyFile  string
+	}{
+		{"empty key", certFile, ""},
+		{"missing certificate", filepath.Join(dir, "missing.crt"), keyFile},
+		{"key of another certificate", certFile, otherKey},
+		{"key as certificate", keyFile, keyFile},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewClient("token", 60, WithClientCertificate(tt.certFile, tt.keyFile)); err == nil {
+				t.Error("NewClient() error = nil, want error for invalid key pair")
+			}
+		})
+	}
+}
+
+func TestWithRequestURLRewriter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("region"); got != "eu" {
+			t.Errorf("region = %q, want %q", got, "eu")
+		}
+		if got := r.URL.Query().Get("limit"); got != "10" {
+			t.Errorf("limit = %q, want %q", got, "10")
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	appendRegion := func(rawURL string) (string, error) {
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			return "", err
+		}
+		query := u.Query()
+		query.Set("region", "eu")
+		u.RawQuery = query.Encode()
+		return u.String(), nil
+	}
+
+	client, err := NewClient("token", 600, WithRequestURLRewriter(appendRegion))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	resp, err := client.Request(context.Background(), server.URL+"/projects?limit=10", nil)
+	if err != nil {
+		t.Fatalf("Request() error = %v", err)
+	}
+	_ = resp.Body.Close()
+}
+
+func TestWithRequestURLRewriterErrors(t *testing.T) {
+	errRewrite := errors.New("no route")
+
+	tests := []struct {
+		name    string
+		rewrite RequestURLRewriter
+		wantErr error
+	}{
+		{"rewriter error", func(string) (string, error) { return "", errRewrite }, errRewrite},
+		{"invalid rewritten url", func(string) (string, error) { return "ftp://example.com", nil }, ErrInvalidEndpoint},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewClient("token", 600, WithRequestURLRewriter(tt.rewrite))
+			if err != nil {
+				t.Fatalf("Failed to create client: %v", err)
+			}
+
+			if _, err := client.Request(context.Background(), "https://app.asana.com/api/1.0/projects", nil); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Request() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if _, err := NewClient("token", 600, WithRequestURLRewriter(nil)); err == nil {
+		t.Error("NewClient() error = nil, want error for nil rewriter")
+	}
+}
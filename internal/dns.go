@@ -0,0 +1,89 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// dnsEntry holds the resolved addresses of a host until they expire.
+type dnsEntry struct {
+	addrs   []string  // Resolved IP addresses
+	expires time.Time // Time after which the host is resolved again
+}
+
+// dnsCache caches host lookups in process for a fixed TTL, so repeated
+// connections to the same host skip DNS resolution.
+type dnsCache struct {
+	ttl    time.Duration                                            // Time resolved addresses are reused
+	lookup func(ctx context.Context, host string) ([]string, error) // Resolves a host to IP addresses
+	dialer *net.Dialer                                              // Dialer used for resolved addresses
+
+	mu      sync.Mutex          // Guards entries
+	entries map[string]dnsEntry // Cached lookups by host
+}
+
+// newDNSCache creates a dnsCache resolving hosts with the default resolver.
+func newDNSCache(ttl time.Duration, dialer *net.Dialer) *dnsCache {
+	return &dnsCache{
+		ttl:     ttl,
+		lookup:  net.DefaultResolver.LookupHost,
+		dialer:  dialer,
+		entries: make(map[string]dnsEntry),
+	}
+}
+
+// resolve returns the addresses of host, from the cache while they are fresh.
+func (d *dnsCache) resolve(ctx context.Context, host string) ([]string, error) {
+	d.mu.Lock()
+	entry, ok := d.entries[host]
+	d.mu.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.addrs, nil
+	}
+
+	addrs, err := d.lookup(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("lookup %s: no addresses", host)
+	}
+
+	d.mu.Lock()
+	d.entries[host] = dnsEntry{addrs: addrs, expires: time.Now().Add(d.ttl)}
+	d.mu.Unlock()
+
+	return addrs, nil
+}
+
+// dialContext dials addr using cached addresses for its host, trying each
+// address in turn until a connection succeeds.
+func (d *dnsCache) dialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	if net.ParseIP(host) != nil {
+		return d.dialer.DialContext(ctx, network, addr)
+	}
+
+	addrs, err := d.resolve(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	var errs []error
+	for _, ip := range addrs {
+		conn, err := d.dialer.DialContext(ctx, network, net.JoinHostPort(ip, port))
+		if err == nil {
+			return conn, nil
+		}
+		errs = append(errs, err)
+	}
+
+	return nil, errors.Join(errs...)
+}
@@ -0,0 +1,98 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestDNSCache_Resolve(t *testing.T) {
+	lookups := 0
+	cache := newDNSCache(time.Hour, &net.Dialer{})
+	cache.lookup = func(ctx context.Context, host string) ([]string, error) {
+		lookups++
+		return []string{"127.0.0.1"}, nil
+	}
+
+	for range 3 {
+		addrs, err := cache.resolve(context.Background(), "asana.test")
+		if err != nil {
+			t.Fatalf("resolve() error = %v", err)
+		}
+		if len(addrs) != 1 || addrs[0] != "127.0.0.1" {
+			t.Errorf("resolve() = %v, want [127.0.0.1]", addrs)
+		}
+	}
+	if lookups != 1 {
+		t.Errorf("lookups = %d, want 1", lookups)
+	}
+
+	cache.mu.Lock()
+	entry := cache.entries["asana.test"]
+	entry.expires = time.Now().Add(-time.Second)
+	cache.entries["asana.test"] = entry
+	cache.mu.Unlock()
+
+	if _, err := cache.resolve(context.Background(), "asana.test"); err != nil {
+		t.Fatalf("resolve() error = %v", err)
+	}
+	if lookups != 2 {
+		t.Errorf("lookups after expiry = %d, want 2", lookups)
+	}
+}
+
+func TestDNSCache_ResolveError(t *testing.T) {
+	errLookup := errors.New("no such host")
+	cache := newDNSCache(time.Hour, &net.Dialer{})
+	cache.lookup = func(ctx context.Context, host string) ([]string, error) {
+		return nil, errLookup
+	}
+
+	if _, err := cache.resolve(context.Background(), "asana.test"); !errors.Is(err, errLookup) {
+		t.Errorf("resolve() error = %v, want %v", err, errLookup)
+	}
+	if len(cache.entries) != 0 {
+		t.Error("Expected failed lookup not to be cached")
+	}
+}
+
+func TestClient_RequestDNSCache(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse server URL: %v", err)
+	}
+
+	client, err := NewClient("token", 600, WithDNSCache(time.Minute))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	// Resolve the fake host to the test server without touching the network.
+	cache := newDNSCache(time.Minute, client.dialer)
+	cache.lookup = func(ctx context.Context, host string) ([]string, error) {
+		return []string{"127.0.0.1"}, nil
+	}
+	client.transport.DialContext = cache.dialContext
+
+	resp, err := client.Request(context.Background(), "http://asana.test:"+u.Port()+"/projects", nil)
+	if err != nil {
+		t.Fatalf("Request() error = %v", err)
+	}
+	_ = resp.Body.Close()
+}
+
+func TestWithDNSCacheInvalidTTL(t *testing.T) {
+	if _, err := NewClient("token", 60, WithDNSCache(0)); err == nil {
+		t.Error("NewClient() error = nil, want error for zero ttl")
+	}
+}
@@ -0,0 +1,35 @@
+package internal
+
+import (
+	"context"
+	"errors"
+)
+
+// Limiter paces the requests of a Client. Wait blocks until a request may be
+// sent, or returns an error if ctx is done first or the limiter fails, in
+// which case the request is not sent. A Client uses a token bucket allowing
+// the configured number of requests per minute unless WithLimiter replaces
+// it, e.g. with a limiter backed by a shared store to coordinate several
+// instances against the per-account limit of the API.
+type Limiter interface {
+	Wait(ctx context.Context) error
+}
+
+// RateSetter is implemented by Limiters whose rate can be changed at runtime.
+// Client.SetRate forwards the new rate, in requests per minute, to limiters
+// implementing it and leaves others unchanged.
+type RateSetter interface {
+	SetRate(r int)
+}
+
+// WithLimiter replaces the token bucket of the client with l. The rate passed
+// to NewClient then has no effect.
+func WithLimiter(l Limiter) Option {
+	return func(c *Client) error {
+		if l == nil {
+			return errors.New("limiter must not be nil")
+		}
+		c.rateLimiter = l
+		return nil
+	}
+}
@@ -0,0 +1,85 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+// countingLimiter counts the requests it lets through and fails with err,
+// if set, instead.
+type countingLimiter struct {
+	waits atomic.Int32
+	rate  int
+	err   error
+}
+
+func (l *countingLimiter) Wait(ctx context.Context) error {
+	l.waits.Add(1)
+	return l.err
+}
+
+func (l *countingLimiter) SetRate(r int) {
+	l.rate = r
+}
+
+func TestClient_RequestLimiter(t *testing.T) {
+	var served atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		served.Add(1)
+	}))
+	defer server.Close()
+
+	// A rate of one request per minute would block the second request.
+	limiter := &countingLimiter{}
+	client, err := NewClient("test-token", 1, WithLimiter(limiter))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	for range 3 {
+		resp, err := client.Request(context.Background(), server.URL, nil)
+		if err != nil {
+			t.Fatalf("Request() error = %v", err)
+		}
+		_ = resp.Body.Close()
+	}
+	if limiter.waits.Load() != 3 || served.Load() != 3 {
+		t.Errorf("Expected 3 waits and 3 requests, got %d and %d", limiter.waits.Load(), served.Load())
+	}
+
+	client.SetRate(1500)
+	if limiter.rate != 1500 {
+		t.Errorf("Expected SetRate to reach the limiter, got rate %d", limiter.rate)
+	}
+}
+
+func TestClient_RequestLimiterError(t *testing.T) {
+	var served atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		served.Add(1)
+	}))
+	defer server.Close()
+
+	errUnavailable := errors.New("limiter store unavailable")
+	client, err := NewClient("test-token", 60, WithLimiter(&countingLimiter{err: errUnavailable}))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	if _, err := client.Request(context.Background(), server.URL, nil); !errors.Is(err, errUnavailable) {
+		t.Errorf("Request() error = %v, want %v", err, errUnavailable)
+	}
+	if served.Load() != 0 {
+		t.Errorf("Expected no request to be sent, got %d", served.Load())
+	}
+}
+
+func TestWithLimiterNil(t *testing.T) {
+	if _, err := NewClient("test-token", 60, WithLimiter(nil)); err == nil {
+		t.Error("NewClient() error = nil, want error for nil limiter")
+	}
+}
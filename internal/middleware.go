@@ -0,0 +1,77 @@
+package internal
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// RoundTripperFunc adapts a function to the http.RoundTripper interface.
+type RoundTripperFunc func(req *http.Request) (*http.Response, error)
+
+// RoundTrip calls f(req).
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Middleware wraps the next step of a Client's request chain, for example to
+// add headers, collect metrics, or retry. Unlike a plain http.RoundTripper, a
+// middleware may modify the request, which the Client creates for each call,
+// and it can stop the chain by returning without calling next.
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// WithMiddleware appends middlewares to the request chain. They run in the
+// order given, after rate limiting and authentication and before signing, so
+// a signature covers the headers they set.
+func WithMiddleware(m ...Middleware) Option {
+	return func(c *Client) error {
+		c.middlewares = append(c.middlewares, m...)
+		return nil
+	}
+}
+
+// chain returns last wrapped by middlewares, the first being the outermost.
+func chain(last http.RoundTripper, middlewares ...Middleware) http.RoundTripper {
+	rt := last
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		rt = middlewares[i](rt)
+	}
+	return rt
+}
+
+// rateLimit waits for the rate limiter before passing the request on.
+func (c *Client) rateLimit(next http.RoundTripper) http.RoundTripper {
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if err := c.rateLimiter.Wait(req.Context()); err != nil {
+			return nil, fmt.Errorf("rate limit wait: %w", err)
+		}
+		return next.RoundTrip(req)
+	})
+}
+
+// authenticate sets the API token, unless the client has none, the Accept
+// header and, for POST requests, the JSON content type.
+func (c *Client) authenticate(next http.RoundTripper) http.RoundTripper {
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		req.Header.Set("Accept", c.accept)
+		if req.Method == http.MethodPost {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		return next.RoundTrip(req)
+	})
+}
+
+// sign applies the configured Signer, if any, as the last step before the
+// request is sent.
+func (c *Client) sign(next http.RoundTripper) http.RoundTripper {
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if c.signer != nil {
+			if err := c.signer.Sign(req); err != nil {
+				return nil, fmt.Errorf("sign request: %w", err)
+			}
+		}
+		return next.RoundTrip(req)
+	})
+}
@@ -0,0 +1,133 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestClient_RequestMiddleware(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("X-Custom"); got != "second" {
+			t.Errorf("X-Custom header = %q, want %q", got, "second")
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	var order []string
+	header := func(name, value string) Middleware {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				order = append(order, value)
+				if req.Header.Get("Authorization") != "Bearer test-token" {
+					t.Errorf("Expected authentication before middleware %s", value)
+				}
+				req.Header.Set(name, value)
+				return next.RoundTrip(req)
+			})
+		}
+	}
+
+	client, err := NewClient("test-token", 600, WithMiddleware(header("X-Custom", "first"), header("X-Custom", "second")))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	resp, err := client.Request(context.Background(), server.URL, nil)
+	if err != nil {
+		t.Fatalf("Request() error = %v", err)
+	}
+	_ = resp.Body.Close()
+
+	if got := strings.Join(order, ","); got != "first,second" {
+		t.Errorf("middleware order = %s, want first,second", got)
+	}
+}
+
+func TestClient_RequestMiddlewareShortCircuit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Expected request to be stopped by middleware")
+	}))
+	defer server.Close()
+
+	errBlocked := errors.New("blocked")
+	block := func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			return nil, errBlocked
+		})
+	}
+
+	client, err := NewClient("test-token", 600, WithMiddleware(block))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	if _, err := client.Request(context.Background(), server.URL, nil); !errors.Is(err, errBlocked) {
+		t.Errorf("Request() error = %v, want %v", err, errBlocked)
+	}
+}
+
+func TestClient_RequestMiddlewareSigned(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(DefaultSigningHeader) == "" {
+			t.Error("Expected request to be signed")
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	signer, err := NewHMACSigner("key", "")
+	if err != nil {
+		t.Fatalf("NewHMACSigner() error = %v", err)
+	}
+	var signedEarly bool
+	check := func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			signedEarly = req.Header.Get(DefaultSigningHeader) != ""
+			return next.RoundTrip(req)
+		})
+	}
+
+	client, err := NewClient("test-token", 600, WithSigner(signer), WithMiddleware(check))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	resp, err := client.Request(context.Background(), server.URL, nil)
+	if err != nil {
+		t.Fatalf("Request() error = %v", err)
+	}
+	_ = resp.Body.Close()
+
+	if signedEarly {
+		t.Error("Expected middlewares to run before signing")
+	}
+}
+
+func TestClient_RequestWithoutToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Header["Authorization"]; ok {
+			t.Errorf("Authorization header = %q, want none", r.Header.Get("Authorization"))
+		}
+		if got := r.Header.Get("Content-Type"); got != "application/json" {
+			t.Errorf("Content-Type header = %q, want application/json", got)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client, err := NewClient("", 600)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	resp, err := client.PostJSON(context.Background(), server.URL, strings.NewReader(`{}`))
+	if err != nil {
+		t.Fatalf("PostJSON() error = %v", err)
+	}
+	_ = resp.Body.Close()
+}
@@ -0,0 +1,167 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+)
+
+// ErrNoTokens is returned by a Pool whose tokens have all been rejected.
+var ErrNoTokens = errors.New("no usable tokens")
+
+// Pool spreads requests round-robin across Clients authenticated with
+// different API tokens, each with its own rate limiter, to multiply the
+// effective request rate. A token the API rejects with 401 Unauthorized is
+// removed from the pool and the request is retried with the next one.
+type Pool struct {
+	mu      sync.Mutex   // Guards clients and next
+	clients []*Client    // Clients with usable tokens
+	next    int          // Index of the client serving the next request
+	all     []*Client    // All clients, including removed ones, for cleanup and redaction
+	log     *slog.Logger // Logger notified when a token is removed
+}
+
+// NewPool creates a Pool with one Client per token. Each Client allows r
+// requests per minute and is configured with opts.
+func NewPool(tokens []string, r int, log *slog.Logger, opts ...Option) (*Pool, error) {
+	if len(tokens) == 0 {
+		return nil, ErrNoTokens
+	}
+
+	p := &Pool{log: log}
+	for _, t := range tokens {
+		c, err := NewClient(t, r, opts...)
+		if err != nil {
+			return nil, err
+		}
+		p.clients = append(p.clients, c)
+	}
+	p.all = p.clients
+
+	return p, nil
+}
+
+// Request performs an authenticated HTTP GET request with the next client in
+// the pool. See Client.Request.
+func (p *Pool) Request(ctx context.Context, url string, body io.Reader) (*http.Response, error) {
+	return p.send(ctx, url, body, (*Client).Request)
+}
+
+// PostJSON performs an authenticated HTTP POST request with a JSON body with
+// the next client in the pool. See Client.PostJSON.
+func (p *Pool) PostJSON(ctx context.Context, url string, body io.Reader) (*http.Response, error) {
+	return p.send(ctx, url, body, (*Client).PostJSON)
+}
+
+// send performs a request with the next client in the pool, removing clients
+// whose token is rejected until one succeeds. The response of the last
+// remaining client is returned as is, so the caller sees the rejection.
+func (p *Pool) send(ctx context.Context, url string, body io.Reader, do func(*Client, context.Context, string, io.Reader) (*http.Response, error)) (*http.Response, error) {
+	var payload []byte
+	if body != nil {
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return nil, err
+		}
+		payload = data
+	}
+
+	for {
+		c, last := p.pick()
+		if c == nil {
+			return nil, ErrNoTokens
+		}
+
+		var r io.Reader
+		if payload != nil {
+			r = bytes.NewReader(payload)
+		}
+		resp, err := do(c, ctx, url, r)
+		if err != nil || resp.StatusCode != http.StatusUnauthorized || last {
+			return resp, err
+		}
+
+		_ = resp.Body.Close()
+		p.remove(c)
+	}
+}
+
+// pick returns the next client in round-robin order and whether it is the
+// only one left.
+func (p *Pool) pick() (*Client, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.clients) == 0 {
+		return nil, false
+	}
+
+	c := p.clients[p.next%len(p.clients)]
+	p.next = (p.next + 1) % len(p.clients)
+
+	return c, len(p.clients) == 1
+}
+
+// remove drops c from the pool after its token was rejected.
+func (p *Pool) remove(c *Client) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for i, pc := range p.clients {
+		if pc == c {
+			p.clients = append(p.clients[:i:i], p.clients[i+1:]...)
+			break
+		}
+	}
+	if len(p.clients) > 0 {
+		p.next %= len(p.clients)
+	}
+
+	p.log.Warn("token rejected, removed from pool",
+		slog.Int("remaining", len(p.clients)))
+}
+
+// Size returns the number of clients with usable tokens.
+func (p *Pool) Size() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return len(p.clients)
+}
+
+// ConnStats returns the connection statistics summed over all clients.
+func (p *Pool) ConnStats() (conns, reused int64) {
+	for _, c := range p.all {
+		cc, cr := c.ConnStats()
+		conns += cc
+		reused += cr
+	}
+	return conns, reused
+}
+
+// SetRate changes the rate limit of every client, each using its own token,
+// to r requests per minute. See Client.SetRate.
+func (p *Pool) SetRate(r int) {
+	for _, c := range p.all {
+		c.SetRate(r)
+	}
+}
+
+// CloseIdleConnections closes idle connections of all clients.
+func (p *Pool) CloseIdleConnections() {
+	for _, c := range p.all {
+		c.CloseIdleConnections()
+	}
+}
+
+// Redact removes every token of the pool from s.
+func (p *Pool) Redact(s string) string {
+	for _, c := range p.all {
+		s = c.Redact(s)
+	}
+	return s
+}
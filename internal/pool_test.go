@@ -0,0 +1,123 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestNewPoolNoTokens(t *testing.T) {
+	if _, err := NewPool(nil, 60, slog.New(slog.DiscardHandler)); !errors.Is(err, ErrNoTokens) {
+		t.Errorf("NewPool() error = %v, want %v", err, ErrNoTokens)
+	}
+}
+
+func TestPool_RequestRoundRobin(t *testing.T) {
+	var mu sync.Mutex
+	var seen []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		seen = append(seen, strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	pool, err := NewPool([]string{"a", "b", "c"}, 600, slog.New(slog.DiscardHandler))
+	if err != nil {
+		t.Fatalf("NewPool() error = %v", err)
+	}
+
+	for range 6 {
+		resp, err := pool.Request(context.Background(), server.URL, nil)
+		if err != nil {
+			t.Fatalf("Request() error = %v", err)
+		}
+		_ = resp.Body.Close()
+	}
+
+	if got := strings.Join(seen, ","); got != "a,b,c,a,b,c" {
+		t.Errorf("tokens used = %s, want a,b,c,a,b,c", got)
+	}
+}
+
+func TestPool_RequestUnauthorized(t *testing.T) {
+	var mu sync.Mutex
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, string(body))
+		mu.Unlock()
+		if r.Header.Get("Authorization") == "Bearer revoked" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	pool, err := NewPool([]string{"revoked", "valid"}, 600, slog.New(slog.DiscardHandler))
+	if err != nil {
+		t.Fatalf("NewPool() error = %v", err)
+	}
+
+	resp, err := pool.PostJSON(context.Background(), server.URL, strings.NewReader(`{"data":{}}`))
+	if err != nil {
+		t.Fatalf("PostJSON() error = %v", err)
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status %d after retry, got %d", http.StatusOK, resp.StatusCode)
+	}
+	if pool.Size() != 1 {
+		t.Errorf("Expected 1 token left in pool, got %d", pool.Size())
+	}
+	if got := strings.Join(bodies, "|"); got != `{"data":{}}|{"data":{}}` {
+		t.Errorf("Expected body resent on retry, got %s", got)
+	}
+}
+
+func TestPool_RequestAllUnauthorized(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	pool, err := NewPool([]string{"a", "b"}, 600, slog.New(slog.DiscardHandler))
+	if err != nil {
+		t.Fatalf("NewPool() error = %v", err)
+	}
+
+	resp, err := pool.Request(context.Background(), server.URL, nil)
+	if err != nil {
+		t.Fatalf("Request() error = %v", err)
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected status %d from last token, got %d", http.StatusUnauthorized, resp.StatusCode)
+	}
+	if pool.Size() != 1 {
+		t.Errorf("Expected last token kept in pool, got %d", pool.Size())
+	}
+}
+
+func TestPool_Redact(t *testing.T) {
+	pool, err := NewPool([]string{"first-token", "second-token"}, 60, slog.New(slog.DiscardHandler))
+	if err != nil {
+		t.Fatalf("NewPool() error = %v", err)
+	}
+
+	got := pool.Redact("first-token and second-token")
+	if got != "[REDACTED] and [REDACTED]" {
+		t.Errorf("Redact() = %q", got)
+	}
+}
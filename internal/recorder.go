@@ -0,0 +1,169 @@
+package internal
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+var ErrNoRecording = errors.New("no recording")
+
+// recording is the on-disk representation of a recorded request/response pair.
+type recording struct {
+	Method     string      `json:"method"`      // Request method
+	URL        string      `json:"url"`         // Request URL with normalized query
+	StatusCode int         `json:"status_code"` // Response status code
+	Header     http.Header `json:"header"`      // Response headers
+	Body       []byte      `json:"body"`        // Response body
+}
+
+// recordingKey derives the recording file name from the request method, URL
+// and body. Query parameters are normalized so their order does not matter.
+func recordingKey(req *http.Request) (string, string, error) {
+	u := *req.URL
+	u.RawQuery = u.Query().Encode()
+
+	h := sha256.New()
+	h.Write([]byte(req.Method + " " + u.String()))
+
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return "", "", fmt.Errorf("get request body: %w", err)
+		}
+		defer func() {
+			_ = body.Close()
+		}()
+
+		h.Write([]byte("\n"))
+		if _, err := io.Copy(h, body); err != nil {
+			return "", "", fmt.Errorf("read request body: %w", err)
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil)) + ".json", u.String(), nil
+}
+
+// recordTransport saves every response returned by the wrapped transport to dir.
+type recordTransport struct {
+	next http.RoundTripper // Transport performing the actual request
+	dir  string            // Directory receiving recordings
+}
+
+// RoundTrip performs the request and records the response. The response body
+// is buffered so it can be both recorded and returned to the caller.
+func (t *recordTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("read response body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	name, target, err := recordingKey(req)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(recording{
+		Method:     req.Method,
+		URL:        target,
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       body,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marshal recording: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(t.dir, name), data, 0600); err != nil {
+		return nil, fmt.Errorf("write recording: %w", err)
+	}
+
+	return resp, nil
+}
+
+// replayTransport serves responses from recordings in dir without using the network.
+type replayTransport struct {
+	dir string // Directory holding recordings
+}
+
+// RoundTrip returns the recorded response for req, or ErrNoRecording if the
+// request was never recorded.
+func (t *replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	name, target, err := recordingKey(req)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(filepath.Join(t.dir, name))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("%w for %s %s", ErrNoRecording, req.Method, target)
+		}
+		return nil, fmt.Errorf("read recording: %w", err)
+	}
+
+	var rec recording
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, fmt.Errorf("unmarshal recording: %w", err)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", rec.StatusCode, http.StatusText(rec.StatusCode)),
+		StatusCode:    rec.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        rec.Header,
+		Body:          io.NopCloser(bytes.NewReader(rec.Body)),
+		ContentLength: int64(len(rec.Body)),
+		Request:       req,
+	}, nil
+}
+
+// WithRecorder records every request/response pair to dir, which is created
+// if it does not exist. Recordings can be served later with WithReplayer.
+func WithRecorder(dir string) Option {
+	return func(c *Client) error {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("recording directory: %w", err)
+		}
+
+		next := c.Client.Transport
+		if next == nil {
+			next = http.DefaultTransport
+		}
+		c.Client.Transport = &recordTransport{next: next, dir: dir}
+		return nil
+	}
+}
+
+// WithReplayer serves every request from recordings in dir instead of the
+// network. Requests without a recording fail with ErrNoRecording.
+func WithReplayer(dir string) Option {
+	return func(c *Client) error {
+		info, err := os.Stat(dir)
+		if err != nil {
+			return fmt.Errorf("replay directory: %w", err)
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("replay directory: %q is not a directory", dir)
+		}
+
+		c.Client.Transport = &replayTransport{dir: dir}
+		return nil
+	}
+}
@@ -0,0 +1,89 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestClient_RecordReplay(t *testing.T) {
+	dir := t.TempDir()
+
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Retry-After", "7")
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte(`{"data": [{"gid": "1"}]}`))
+	}))
+
+	recorder, err := NewClient("test-token", 600, WithRecorder(dir))
+	if err != nil {
+		t.Fatalf("Failed to create recording client: %v", err)
+	}
+
+	resp, err := recorder.Request(context.Background(), server.URL+"/projects?limit=10&opt_fields=name", nil)
+	if err != nil {
+		t.Fatalf("Request() error = %v", err)
+	}
+	recorded, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	server.Close()
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to read recording directory: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 recording, got %d", len(entries))
+	}
+
+	replayer, err := NewClient("test-token", 600, WithReplayer(dir))
+	if err != nil {
+		t.Fatalf("Failed to create replaying client: %v", err)
+	}
+
+	// Query parameter order does not affect the recording key.
+	resp, err = replayer.Request(context.Background(), server.URL+"/projects?opt_fields=name&limit=10", nil)
+	if err != nil {
+		t.Fatalf("Request() replay error = %v", err)
+	}
+	replayed, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+
+	if string(replayed) != string(recorded) {
+		t.Errorf("replayed body = %s, want %s", replayed, recorded)
+	}
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("replayed status = %d, want %d", resp.StatusCode, http.StatusTooManyRequests)
+	}
+	if resp.Header.Get("Retry-After") != "7" {
+		t.Errorf("replayed Retry-After = %q, want %q", resp.Header.Get("Retry-After"), "7")
+	}
+	if calls != 1 {
+		t.Errorf("server received %d calls, want 1", calls)
+	}
+
+	_, err = replayer.Request(context.Background(), server.URL+"/users", nil)
+	if !errors.Is(err, ErrNoRecording) {
+		t.Errorf("Request() unrecorded error = %v, want %v", err, ErrNoRecording)
+	}
+}
+
+func TestWithReplayerInvalidDir(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0600); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	for _, dir := range []string{filepath.Join(t.TempDir(), "missing"), file} {
+		if _, err := NewClient("test-token", 60, WithReplayer(dir)); err == nil {
+			t.Errorf("NewClient() with replay dir %q expected error", dir)
+		}
+	}
+}
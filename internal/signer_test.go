@@ -0,0 +1,163 @@
+package internal
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNewHMACSigner(t *testing.T) {
+	tests := []struct {
+		name       string
+		key        string
+		header     string
+		wantHeader string
+		wantErr    bool
+	}{
+		{
+			name:       "custom header",
+			key:        "secret",
+			header:     "X-Custom-Signature",
+			wantHeader: "X-Custom-Signature",
+			wantErr:    false,
+		},
+		{
+			name:       "default header",
+			key:        "secret",
+			header:     "",
+			wantHeader: DefaultSigningHeader,
+			wantErr:    false,
+		},
+		{
+			name:    "empty key",
+			key:     "",
+			header:  "X-Signature",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer, err := NewHMACSigner(tt.key, tt.header)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewHMACSigner() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err == nil && signer.header != tt.wantHeader {
+				t.Errorf("NewHMACSigner() header = %v, want %v", signer.header, tt.wantHeader)
+			}
+		})
+	}
+}
+
+func TestHMACSigner_Sign(t *testing.T) {
+	signer, err := NewHMACSigner("secret", "X-Signature")
+	if err != nil {
+		t.Fatalf("Failed to create signer: %v", err)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, "https://example.com/api/1.0/projects?limit=10", nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+
+	if err := signer.Sign(req); err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+
+	mac := hmac.New(sha256.New, []byte("secret"))
+	empty := sha256.Sum256(nil)
+	mac.Write([]byte("GET\n/api/1.0/projects?limit=10\n" + hex.EncodeToString(empty[:])))
+	want := hex.EncodeToString(mac.Sum(nil))
+
+	if got := req.Header.Get("X-Signature"); got != want {
+		t.Errorf("Sign() header = %v, want %v", got, want)
+	}
+}
+
+func TestHMACSigner_SignBody(t *testing.T) {
+	signer, err := NewHMACSigner("secret", "X-Signature")
+	if err != nil {
+		t.Fatalf("Failed to create signer: %v", err)
+	}
+
+	const body = `{"data": {"name": "Q3 Plan"}}`
+	req, err := http.NewRequest(http.MethodPost, "https://example.com/api/1.0/projects", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+
+	if err := signer.Sign(req); err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+
+	digest := sha256.Sum256([]byte(body))
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte("POST\n/api/1.0/projects\n" + hex.EncodeToString(digest[:])))
+	want := hex.EncodeToString(mac.Sum(nil))
+
+	if got := req.Header.Get("X-Signature"); got != want {
+		t.Errorf("Sign() header = %v, want %v", got, want)
+	}
+
+	// The body must still be readable in full by the transport.
+	sent, err := io.ReadAll(req.Body)
+	if err != nil {
+		t.Fatalf("Failed to read body: %v", err)
+	}
+	if string(sent) != body {
+		t.Errorf("Sign() left body %q, want %q", sent, body)
+	}
+}
+
+func TestHMACSigner_SignUnreadableBody(t *testing.T) {
+	signer, err := NewHMACSigner("secret", "X-Signature")
+	if err != nil {
+		t.Fatalf("Failed to create signer: %v", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, "https://example.com/api/1.0/projects", io.NopCloser(strings.NewReader("{}")))
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+
+	if err := signer.Sign(req); !errors.Is(err, ErrUnsignableBody) {
+		t.Errorf("Sign() error = %v, want %v", err, ErrUnsignableBody)
+	}
+}
+
+func TestClient_RequestSigned(t *testing.T) {
+	var got string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("X-Signature")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	signer, err := NewHMACSigner("secret", "")
+	if err != nil {
+		t.Fatalf("Failed to create signer: %v", err)
+	}
+
+	client, err := NewClient("test-token", 60, WithSigner(signer))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	resp, err := client.Request(context.Background(), server.URL+"/projects", nil)
+	if err != nil {
+		t.Fatalf("Request() error = %v", err)
+	}
+	_ = resp.Body.Close()
+
+	if got == "" {
+		t.Error("Expected signature header to be set")
+	}
+}
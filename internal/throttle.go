@@ -0,0 +1,119 @@
+package internal
+
+import (
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// LatencyWindow is the number of most recent requests whose average latency
+// WithLatencyThrottle compares against its thresholds. No adjustment is made
+// before as many requests have completed, so a single slow request, such as
+// the first one of a connection, never lowers the rate.
+const LatencyWindow = 20
+
+// latencyThrottle tracks the rolling average latency of the requests of a
+// Client and the rate to restore while the rate is lowered.
+type latencyThrottle struct {
+	high   time.Duration // Average latency above which the rate is lowered
+	low    time.Duration // Average latency at or below which the rate is restored
+	factor float64       // Fraction of the rate used while lowered
+
+	mu        sync.Mutex
+	samples   []time.Duration // Latencies of the last requests, oldest at next once full
+	next      int             // Position in samples of the oldest latency once full
+	sum       time.Duration   // Sum of samples
+	throttled bool            // Whether the rate is lowered
+	limit     rate.Limit      // Limit of the limiter to restore
+	burst     int             // Burst of the limiter to restore
+}
+
+// WithLatencyThrottle lowers the rate limit to factor times its configured
+// value while the average latency of the last LatencyWindow requests exceeds
+// high, as slow responses are a sign of an overloaded API, and restores it
+// once the average is back at low or below. Latency is measured until the
+// response headers arrive, so it does not depend on the size of a page.
+// Adjustments are logged. The throttle applies to the built-in token bucket
+// and cannot be combined with WithLimiter.
+func WithLatencyThrottle(high, low time.Duration, factor float64) Option {
+	return func(c *Client) error {
+		switch {
+		case high <= 0:
+			return errors.New("latency threshold must be positive")
+		case low <= 0 || low > high:
+			return errors.New("recovery latency must be positive and not above the latency threshold")
+		case factor <= 0 || factor >= 1:
+			return errors.New("throttle factor must be between 0 and 1")
+		}
+		c.throttle = &latencyThrottle{high: high, low: low, factor: factor}
+		return nil
+	}
+}
+
+// observeLatency records the latency d of a request and lowers or restores
+// the rate of the limiter once the average latency crosses a threshold.
+func (c *Client) observeLatency(d time.Duration) {
+	t := c.throttle
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.samples) < LatencyWindow {
+		t.samples = append(t.samples, d)
+		t.sum += d
+		if len(t.samples) < LatencyWindow {
+			return
+		}
+	} else {
+		t.sum += d - t.samples[t.next]
+		t.samples[t.next] = d
+		t.next = (t.next + 1) % LatencyWindow
+	}
+	avg := t.sum / LatencyWindow
+
+	switch {
+	case !t.throttled && avg > t.high:
+		t.throttled = true
+		t.limit, t.burst = c.limiter.Limit(), c.limiter.Burst()
+		t.lower(c.limiter)
+		c.log.Warn("response latency high, lowering request rate",
+			slog.String("latency", avg.String()),
+			slog.String("threshold", t.high.String()),
+			slog.Float64("rate", perMinute(c.limiter.Limit())))
+	case t.throttled && avg <= t.low:
+		t.throttled = false
+		c.limiter.SetLimit(t.limit)
+		c.limiter.SetBurst(t.burst)
+		c.log.Info("response latency recovered, restoring request rate",
+			slog.String("latency", avg.String()),
+			slog.Float64("rate", perMinute(t.limit)))
+	}
+}
+
+// setRate changes the rate to restore to limit and burst and applies it to
+// l, lowered if the rate is currently lowered.
+func (t *latencyThrottle) setRate(l *rate.Limiter, limit rate.Limit, burst int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.limit, t.burst = limit, burst
+	if t.throttled {
+		t.lower(l)
+		return
+	}
+	l.SetBurst(burst)
+	l.SetLimit(limit)
+}
+
+// lower applies the lowered rate to l.
+func (t *latencyThrottle) lower(l *rate.Limiter) {
+	l.SetBurst(max(1, int(float64(t.burst)*t.factor)))
+	l.SetLimit(t.limit * rate.Limit(t.factor))
+}
+
+// perMinute returns limit in requests per minute.
+func perMinute(limit rate.Limit) float64 {
+	return float64(limit) * 60
+}
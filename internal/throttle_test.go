@@ -0,0 +1,113 @@
+package internal
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+func TestWithLatencyThrottle(t *testing.T) {
+	var delay atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(time.Duration(delay.Load()))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client, err := NewClient("token", 6000, WithLatencyThrottle(20*time.Millisecond, 10*time.Millisecond, 0.5))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	request := func(n int) {
+		t.Helper()
+		for range n {
+			resp, err := client.Request(context.Background(), server.URL, nil)
+			if err != nil {
+				t.Fatalf("Request() error = %v", err)
+			}
+			_ = resp.Body.Close()
+		}
+	}
+
+	delay.Store(int64(30 * time.Millisecond))
+	request(LatencyWindow - 1)
+	if got := client.limiter.Limit(); got != 100 {
+		t.Fatalf("Expected no adjustment before %d requests, got limit %v", LatencyWindow, got)
+	}
+	request(1)
+	if got, burst := client.limiter.Limit(), client.limiter.Burst(); got != 50 || burst != 3000 {
+		t.Errorf("Expected lowered limit 50 and burst 3000, got %v and %d", got, burst)
+	}
+
+	delay.Store(0)
+	request(LatencyWindow)
+	if got, burst := client.limiter.Limit(), client.limiter.Burst(); got != 100 || burst != 6000 {
+		t.Errorf("Expected restored limit 100 and burst 6000, got %v and %d", got, burst)
+	}
+}
+
+func TestLatencyThrottleSetRate(t *testing.T) {
+	client, err := NewClient("token", 600, WithLatencyThrottle(time.Second, 500*time.Millisecond, 0.25))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	for range LatencyWindow {
+		client.observeLatency(2 * time.Second)
+	}
+	if got := client.limiter.Limit(); got != 2.5 {
+		t.Fatalf("Expected lowered limit 2.5, got %v", got)
+	}
+
+	// A rate set while lowered is lowered too and restored on recovery.
+	client.SetRate(1200)
+	if got := client.limiter.Limit(); got != 5 {
+		t.Errorf("Expected lowered limit 5 after SetRate, got %v", got)
+	}
+
+	// The average of 6 slow requests in the window is still above 500ms.
+	for range LatencyWindow - 6 {
+		client.observeLatency(0)
+	}
+	if got := client.limiter.Limit(); got != 5 {
+		t.Errorf("Expected the limit to stay lowered above the recovery latency, got %v", got)
+	}
+	client.observeLatency(0)
+	if got := client.limiter.Limit(); got != 20 {
+		t.Errorf("Expected restored limit 20, got %v", got)
+	}
+}
+
+func TestWithLatencyThrottleInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		high   time.Duration
+		low    time.Duration
+		factor float64
+	}{
+		{"zero threshold", 0, 0, 0.5},
+		{"recovery above threshold", time.Second, 2 * time.Second, 0.5},
+		{"zero recovery", time.Second, 0, 0.5},
+		{"zero factor", time.Second, time.Second, 0},
+		{"factor of one", time.Second, time.Second, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewClient("token", 600, WithLatencyThrottle(tt.high, tt.low, tt.factor)); err == nil {
+				t.Error("NewClient() error = nil, want error")
+			}
+		})
+	}
+
+	limiter := WithLimiter(rate.NewLimiter(1, 1))
+	if _, err := NewClient("token", 600, WithLatencyThrottle(time.Second, time.Second, 0.5), limiter); err == nil {
+		t.Error("NewClient() with custom limiter error = nil, want error")
+	}
+}